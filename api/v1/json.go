@@ -102,3 +102,37 @@ type (
 		Size int64 `json:"size"`
 	}
 )
+
+// PodExitStatus tells whether the apps of a pod exited successfully.
+type PodExitStatus string
+
+const (
+	// PodExitStatusUnknown means that some apps did not exit yet
+	// or their exit codes are not known.
+	PodExitStatusUnknown PodExitStatus = "unknown"
+	// PodExitStatusSucceeded means that all the apps exited with
+	// a zero exit code.
+	PodExitStatusSucceeded PodExitStatus = "succeeded"
+	// PodExitStatusFailed means that at least one app exited with
+	// a non-zero exit code.
+	PodExitStatusFailed PodExitStatus = "failed"
+)
+
+// ExitStatus derives the exit status of the pod from the exit codes of
+// its apps. It does not change the pod state, an exited pod is in the
+// exited state regardless of its exit status.
+func (p *Pod) ExitStatus() PodExitStatus {
+	status := PodExitStatusUnknown
+	if len(p.Apps) > 0 {
+		status = PodExitStatusSucceeded
+	}
+	for _, app := range p.Apps {
+		switch {
+		case app.ExitCode == nil:
+			status = PodExitStatusUnknown
+		case *app.ExitCode != 0:
+			return PodExitStatusFailed
+		}
+	}
+	return status
+}
@@ -0,0 +1,54 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1
+
+import (
+	"testing"
+)
+
+func exitedApp(name string, exitCode int32) *App {
+	return &App{
+		Name:     name,
+		State:    AppStateExited,
+		ExitCode: &exitCode,
+	}
+}
+
+func TestPodExitStatus(t *testing.T) {
+	tests := []struct {
+		apps     []*App
+		expected PodExitStatus
+	}{
+		{nil, PodExitStatusUnknown},
+		{[]*App{exitedApp("a", 0)}, PodExitStatusSucceeded},
+		{[]*App{exitedApp("a", 0), exitedApp("b", 0)}, PodExitStatusSucceeded},
+		{[]*App{exitedApp("a", 0), exitedApp("b", 1)}, PodExitStatusFailed},
+		{[]*App{exitedApp("a", 137), exitedApp("b", 0)}, PodExitStatusFailed},
+		{[]*App{exitedApp("a", 0), {Name: "b", State: AppStateRunning}}, PodExitStatusUnknown},
+		{[]*App{{Name: "a", State: AppStateRunning}, exitedApp("b", 2)}, PodExitStatusFailed},
+	}
+	for i, tt := range tests {
+		p := &Pod{
+			State: "exited",
+			Apps:  tt.apps,
+		}
+		if status := p.ExitStatus(); status != tt.expected {
+			t.Errorf("#%d: expected exit status %q, got %q", i, tt.expected, status)
+		}
+		if p.State != "exited" {
+			t.Errorf("#%d: expected the pod state to be unchanged, got %q", i, p.State)
+		}
+	}
+}
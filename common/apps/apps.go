@@ -19,6 +19,7 @@ package apps
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
 
 	"github.com/appc/spec/schema"
@@ -28,6 +29,7 @@ import (
 var (
 	ErrInvalidSeccompMode     = errors.New("invalid seccomp mode command-line override")
 	ErrInvalidSeccompOverride = errors.New("invalid seccomp command-line override")
+	ErrInvalidSeccompSyscall  = errors.New("invalid syscall in seccomp command-line override")
 )
 
 // AppIO describes the type of an application stream at runtime (stdin/stdout/stderr).
@@ -39,17 +41,92 @@ func (a AppIO) String() string {
 
 const (
 	AppIOInteractive AppIO = "interactive" // interactive I/O (parent terminal)
+	AppIOJournal     AppIO = "journal"     // journal-only I/O, not forwarded to the console
 	AppIOLog         AppIO = "log"         // log-only I/O
 	AppIONull        AppIO = "null"        // null I/O
 	AppIOStream      AppIO = "stream"      // attachable I/O
 	AppIOTTY         AppIO = "tty"         // I/O over TTY
 )
 
+// ValidateHealthCheck checks that a health check command is given and that
+// its executable is an absolute path.
+func ValidateHealthCheck(cmd []string) error {
+	if len(cmd) == 0 || cmd[0] == "" {
+		return errors.New("health check command is empty")
+	}
+	if !filepath.IsAbs(cmd[0]) {
+		return fmt.Errorf("health check command %q is not an absolute path", cmd[0])
+	}
+	return nil
+}
+
+// RestartPolicy describes when an external supervisor should restart an app.
+type RestartPolicy string
+
+func (r RestartPolicy) String() string {
+	return string(r)
+}
+
+const (
+	RestartPolicyNever     RestartPolicy = "Never"     // never restart the app
+	RestartPolicyOnFailure RestartPolicy = "OnFailure" // restart the app when it exits with a non-zero code
+	RestartPolicyAlways    RestartPolicy = "Always"    // always restart the app when it exits
+)
+
+// NewRestartPolicy parses a restart policy, returning an error for unknown
+// policies.
+func NewRestartPolicy(s string) (RestartPolicy, error) {
+	switch r := RestartPolicy(s); r {
+	case RestartPolicyNever, RestartPolicyOnFailure, RestartPolicyAlways:
+		return r, nil
+	}
+	return "", fmt.Errorf("invalid restart policy %q, must be one of %q, %q or %q", s, RestartPolicyNever, RestartPolicyOnFailure, RestartPolicyAlways)
+}
+
+// allowedUnitOptions lists, per section, the systemd unit options that can be
+// set for an app. They only tune the app's resource limits and how it is
+// stopped, so they cannot be used to escape the app's isolation.
+var allowedUnitOptions = map[string]map[string]bool{
+	"Service": {
+		"KillSignal":     true,
+		"LimitCORE":      true,
+		"LimitFSIZE":     true,
+		"LimitMEMLOCK":   true,
+		"LimitNOFILE":    true,
+		"LimitNPROC":     true,
+		"LimitSTACK":     true,
+		"Nice":           true,
+		"TimeoutStopSec": true,
+	},
+}
+
+// ParseUnitOption parses a systemd unit option for an app in the
+// Section.Key=Value form. It returns an error for malformed options and for
+// options which are not allowed.
+func ParseUnitOption(s string) (section, name, value string, err error) {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 || kv[1] == "" {
+		return "", "", "", fmt.Errorf("invalid unit option %q, must be in the Section.Key=Value form", s)
+	}
+	sn := strings.SplitN(kv[0], ".", 2)
+	if len(sn) != 2 || sn[0] == "" || sn[1] == "" {
+		return "", "", "", fmt.Errorf("invalid unit option %q, must be in the Section.Key=Value form", s)
+	}
+	section, name, value = sn[0], sn[1], kv[1]
+	if !allowedUnitOptions[section][name] {
+		return "", "", "", fmt.Errorf("unit option %s.%s is not allowed", section, name)
+	}
+	if strings.ContainsAny(value, "\n\r") {
+		return "", "", "", fmt.Errorf("invalid value for unit option %s.%s: newlines are not allowed", section, name)
+	}
+	return section, name, value, nil
+}
+
 type App struct {
 	Name              string                            // the name of the app. If not set, the image's name will be used.
 	Image             string                            // the image reference as supplied by the user on the cli
 	Args              []string                          // any arguments the user supplied for this app
-	Asc               string                            // signature file override for image verification (if fetching occurs)
+	Asc               []string                          // signature file overrides for image verification (if fetching occurs), any of them validating is enough
 	Exec              string                            // exec override for image
 	WorkingDir        string                            // working directory override for image
 	ReadOnlyRootFS    bool                              // read-only rootfs override.
@@ -70,6 +147,9 @@ type App struct {
 	Stdin             AppIO                             // mode for stdin
 	Stdout            AppIO                             // mode for stdout
 	Stderr            AppIO                             // mode for stderr
+	HealthCheck       []string                          // health check command to run inside the app
+	RestartPolicy     RestartPolicy                     // restart policy for an external supervisor
+	UnitOptions       []string                          // extra systemd unit options in the Section.Key=Value form
 
 	// TODO(jonboulle): These images are partially-populated hashes, this should be clarified.
 	ImageID types.Hash // resolved image identifier
@@ -102,12 +182,36 @@ func (a *App) SeccompOverride() (mode string, errno string, set []string, e erro
 				e = ErrInvalidSeccompOverride
 			}
 		} else {
+			if !validSeccompSetItem(i) {
+				e = ErrInvalidSeccompSyscall
+			}
 			set = append(set, i)
 		}
 	}
+	if e == nil && mode == "" {
+		e = ErrInvalidSeccompMode
+	}
 	return
 }
 
+// validSeccompSetItem tells whether s looks like a syscall name or a
+// wildcard set (e.g. "@rkt/default-blacklist"). Whether a syscall
+// actually exists is left to stage1.
+func validSeccompSetItem(s string) bool {
+	if strings.HasPrefix(s, "@") {
+		return len(s) > 1
+	}
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
 // Reset creates a new slice for al.apps, needed by tests
 func (al *Apps) Reset() {
 	al.apps = make([]App, 0)
@@ -0,0 +1,101 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// Backoff retries failing operations with an exponentially growing
+// delay between the attempts.
+type Backoff struct {
+	// Initial is the delay before the first retry.
+	Initial time.Duration
+	// Max caps the delay, it is not capped if zero.
+	Max time.Duration
+	// Multiplier is the factor the delay grows by after every
+	// retry, it defaults to 2 if lower than 1.
+	Multiplier float64
+	// Retryable tells whether it makes sense to retry after the
+	// given error. If nil, all errors are retried.
+	Retryable func(error) bool
+	// Notify, if not nil, is called before waiting for a retry
+	// with the error of the failed attempt and the delay.
+	Notify func(err error, delay time.Duration)
+
+	// sleep waits for the given delay or until the context is
+	// done, it is replaced in tests.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// Delay returns the delay before the retry following the given
+// failed attempt, counted from zero.
+func (b *Backoff) Delay(attempt int) time.Duration {
+	multiplier := b.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	d := float64(b.Initial)
+	for i := 0; i < attempt; i++ {
+		d *= multiplier
+		if b.Max > 0 && d >= float64(b.Max) {
+			return b.Max
+		}
+	}
+	if b.Max > 0 && d > float64(b.Max) {
+		return b.Max
+	}
+	return time.Duration(d)
+}
+
+// Retry calls fn until it succeeds, at most attempts times, and
+// returns the error of the last call. It stops early if the error is
+// not retryable, or if the context is done while waiting, in which
+// case the context error is returned.
+func (b *Backoff) Retry(ctx context.Context, attempts int, fn func() error) error {
+	sleep := b.sleep
+	if sleep == nil {
+		sleep = sleepContext
+	}
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		if attempt+1 >= attempts || (b.Retryable != nil && !b.Retryable(err)) {
+			return err
+		}
+		delay := b.Delay(attempt)
+		if b.Notify != nil {
+			b.Notify(err, delay)
+		}
+		if err := sleep(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+var (
+	errTransient = errors.New("transient")
+	errFatal     = errors.New("fatal")
+)
+
+// recordSleeps makes b record its delays instead of sleeping.
+func recordSleeps(b *Backoff) *[]time.Duration {
+	var delays []time.Duration
+	b.sleep = func(ctx context.Context, d time.Duration) error {
+		delays = append(delays, d)
+		return ctx.Err()
+	}
+	return &delays
+}
+
+func TestBackoffDelay(t *testing.T) {
+	for _, tt := range []struct {
+		backoff  Backoff
+		expected []time.Duration
+	}{
+		{
+			backoff:  Backoff{Initial: time.Second},
+			expected: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second},
+		},
+		{
+			backoff:  Backoff{Initial: time.Second, Max: 5 * time.Second},
+			expected: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second},
+		},
+		{
+			backoff:  Backoff{Initial: 100 * time.Millisecond, Multiplier: 1.5},
+			expected: []time.Duration{100 * time.Millisecond, 150 * time.Millisecond, 225 * time.Millisecond},
+		},
+		{
+			backoff:  Backoff{Initial: 10 * time.Second, Max: time.Second},
+			expected: []time.Duration{time.Second, time.Second},
+		},
+	} {
+		var delays []time.Duration
+		for i := range tt.expected {
+			delays = append(delays, tt.backoff.Delay(i))
+		}
+		if !reflect.DeepEqual(delays, tt.expected) {
+			t.Errorf("%+v: expected delays %v, got %v", tt.backoff, tt.expected, delays)
+		}
+	}
+}
+
+func TestBackoffRetry(t *testing.T) {
+	retryable := func(err error) bool { return err == errTransient }
+
+	for _, tt := range []struct {
+		attempts  int
+		errs      []error
+		err       error
+		calls     int
+		retryable func(error) bool
+	}{
+		// success at once
+		{attempts: 3, errs: nil, err: nil, calls: 1},
+		// success after retries
+		{attempts: 3, errs: []error{errTransient, errTransient}, err: nil, calls: 3},
+		// attempt limit reached
+		{attempts: 3, errs: []error{errTransient, errTransient, errTransient, errTransient}, err: errTransient, calls: 3},
+		// a single attempt is never retried
+		{attempts: 1, errs: []error{errTransient}, err: errTransient, calls: 1},
+		{attempts: 0, errs: []error{errTransient}, err: errTransient, calls: 1},
+		// non-retryable errors short-circuit
+		{attempts: 5, errs: []error{errTransient, errFatal, errTransient}, err: errFatal, calls: 2, retryable: retryable},
+		// every error is retryable without a predicate
+		{attempts: 5, errs: []error{errTransient, errFatal}, err: nil, calls: 3},
+	} {
+		b := Backoff{Initial: time.Second, Retryable: tt.retryable}
+		delays := recordSleeps(&b)
+		calls := 0
+		err := b.Retry(context.Background(), tt.attempts, func() error {
+			calls++
+			if calls <= len(tt.errs) {
+				return tt.errs[calls-1]
+			}
+			return nil
+		})
+		if err != tt.err {
+			t.Errorf("%d attempts, errors %v: expected error %v, got %v", tt.attempts, tt.errs, tt.err, err)
+		}
+		if calls != tt.calls {
+			t.Errorf("%d attempts, errors %v: expected %d calls, got %d", tt.attempts, tt.errs, tt.calls, calls)
+		}
+		if len(*delays) != calls-1 {
+			t.Errorf("%d attempts, errors %v: expected %d sleeps, got %v", tt.attempts, tt.errs, calls-1, *delays)
+		}
+	}
+}
+
+func TestBackoffRetryTiming(t *testing.T) {
+	var notified []time.Duration
+	b := Backoff{
+		Initial:    10 * time.Millisecond,
+		Max:        30 * time.Millisecond,
+		Multiplier: 2,
+		Notify: func(err error, delay time.Duration) {
+			notified = append(notified, delay)
+		},
+	}
+	delays := recordSleeps(&b)
+	b.Retry(context.Background(), 5, func() error { return errTransient })
+
+	expected := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 30 * time.Millisecond, 30 * time.Millisecond}
+	if !reflect.DeepEqual(*delays, expected) {
+		t.Errorf("expected delays %v, got %v", expected, *delays)
+	}
+	if !reflect.DeepEqual(notified, expected) {
+		t.Errorf("expected notified delays %v, got %v", expected, notified)
+	}
+
+	// the real sleep waits at least the delay
+	b = Backoff{Initial: 20 * time.Millisecond}
+	start := time.Now()
+	b.Retry(context.Background(), 2, func() error { return errTransient })
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("expected to wait at least %v, waited %v", 20*time.Millisecond, elapsed)
+	}
+}
+
+func TestBackoffRetryCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	b := Backoff{Initial: time.Hour}
+	calls := 0
+	err := b.Retry(ctx, 3, func() error {
+		calls++
+		cancel()
+		return errTransient
+	})
+	if err != context.Canceled {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 call, got %d", calls)
+	}
+}
@@ -18,6 +18,8 @@ package cgroup
 
 import (
 	"errors"
+	"fmt"
+	"os"
 	"path/filepath"
 	"syscall"
 
@@ -33,6 +35,37 @@ const (
 	Cgroup2fsMagicNumber = 0x63677270
 )
 
+// Layout describes how the cgroup hierarchies are mounted on the host
+type Layout int
+
+const (
+	// LayoutLegacy means only v1 controller hierarchies are mounted in
+	// /sys/fs/cgroup
+	LayoutLegacy Layout = iota
+	// LayoutHybrid means v1 controller hierarchies are mounted in
+	// /sys/fs/cgroup and a cgroup2 hierarchy is mounted in
+	// /sys/fs/cgroup/unified
+	LayoutHybrid
+	// LayoutUnified means a cgroup2 hierarchy is mounted in /sys/fs/cgroup
+	LayoutUnified
+)
+
+func (l Layout) String() string {
+	switch l {
+	case LayoutLegacy:
+		return "legacy"
+	case LayoutHybrid:
+		return "hybrid"
+	case LayoutUnified:
+		return "unified"
+	}
+	return fmt.Sprintf("Layout(%d)", int(l))
+}
+
+// cgroupIsolators lists the isolators that rkt enforces through a cgroup
+// controller of the same name, sorted
+var cgroupIsolators = []string{"cpu", "memory"}
+
 // IsIsolatorSupported returns whether an isolator is supported in the kernel
 func IsIsolatorSupported(isolator string) (bool, error) {
 	isUnified, err := IsCgroupUnified("/")
@@ -55,6 +88,130 @@ func IsIsolatorSupported(isolator string) (bool, error) {
 	return v1.IsControllerMounted(isolator)
 }
 
+// SupportedIsolators returns the sorted list of isolators that can be
+// enforced with the cgroup layout detected on the host
+func SupportedIsolators() ([]string, error) {
+	layout, err := GetLayout("/")
+	if err != nil {
+		return nil, errwrap.Wrap(errors.New("error determining cgroup layout"), err)
+	}
+
+	var controllers []string
+	switch layout {
+	case LayoutUnified:
+		controllers, err = v2.GetEnabledControllers()
+	case LayoutHybrid:
+		controllers, err = v2.GetEnabledControllersAt("/sys/fs/cgroup/unified")
+	}
+	if err != nil {
+		return nil, errwrap.Wrap(errors.New("error determining enabled controllers"), err)
+	}
+
+	return supportedIsolators(layout, v1.IsControllerMounted, controllers)
+}
+
+// supportedIsolators returns the sorted list of isolators supported by the
+// given layout. isMounted reports whether a v1 controller is mounted and
+// controllers are the controllers enabled in the cgroup2 hierarchy, if any.
+func supportedIsolators(layout Layout, isMounted func(string) (bool, error), controllers []string) ([]string, error) {
+	enabled := make(map[string]struct{})
+	if layout != LayoutUnified {
+		for _, iso := range cgroupIsolators {
+			ok, err := isMounted(iso)
+			if err != nil {
+				return nil, errwrap.Wrap(fmt.Errorf("error checking whether the %s controller is mounted", iso), err)
+			}
+			if ok {
+				enabled[iso] = struct{}{}
+			}
+		}
+	}
+	if layout != LayoutLegacy {
+		for _, c := range controllers {
+			enabled[c] = struct{}{}
+		}
+	}
+
+	supported := []string{}
+	for _, iso := range cgroupIsolators {
+		if _, ok := enabled[iso]; ok {
+			supported = append(supported, iso)
+		}
+	}
+	return supported, nil
+}
+
+// ControllerPath returns the path of the hierarchy the given controller is
+// mounted on for the system mounted under root
+func ControllerPath(root, controller string) (string, error) {
+	layout, err := GetLayout(root)
+	if err != nil {
+		return "", errwrap.Wrap(errors.New("error determining cgroup layout"), err)
+	}
+
+	return controllerPath(layout, root, controller)
+}
+
+// controllerPath returns the path of the hierarchy the given controller is
+// mounted on under root, assuming the given layout. In the hybrid layout v1
+// hierarchies take precedence over the cgroup2 hierarchy in the unified
+// subdir.
+func controllerPath(layout Layout, root, controller string) (string, error) {
+	cgroupPath := filepath.Join(root, "/sys/fs/cgroup")
+
+	if layout != LayoutUnified {
+		v1Path := filepath.Join(cgroupPath, controller)
+		_, err := os.Stat(filepath.Join(v1Path, "cgroup.procs"))
+		if err == nil {
+			return v1Path, nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		if layout == LayoutLegacy {
+			return "", fmt.Errorf("controller %q is not mounted", controller)
+		}
+		cgroupPath = filepath.Join(cgroupPath, "unified")
+	}
+
+	controllers, err := v2.GetEnabledControllersAt(cgroupPath)
+	if err != nil {
+		return "", errwrap.Wrap(errors.New("error determining enabled controllers"), err)
+	}
+	for _, c := range controllers {
+		if c == controller {
+			return cgroupPath, nil
+		}
+	}
+
+	return "", fmt.Errorf("controller %q is not enabled", controller)
+}
+
+// GetLayout returns the cgroup layout of the system mounted under root
+func GetLayout(root string) (Layout, error) {
+	isUnified, err := IsCgroupUnified(root)
+	if err != nil {
+		return LayoutLegacy, err
+	}
+	if isUnified {
+		return LayoutUnified, nil
+	}
+
+	var statfs syscall.Statfs_t
+	unifiedPath := filepath.Join(root, "/sys/fs/cgroup/unified")
+	if err := syscall.Statfs(unifiedPath, &statfs); err != nil {
+		if os.IsNotExist(err) {
+			return LayoutLegacy, nil
+		}
+		return LayoutLegacy, err
+	}
+	if statfs.Type == Cgroup2fsMagicNumber {
+		return LayoutHybrid, nil
+	}
+
+	return LayoutLegacy, nil
+}
+
 // IsCgroupUnified checks if cgroup mounted at /sys/fs/cgroup is
 // the new unified version (cgroup v2)
 func IsCgroupUnified(root string) (bool, error) {
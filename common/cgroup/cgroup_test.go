@@ -0,0 +1,245 @@
+// Copyright 2015 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package cgroup
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSupportedIsolators(t *testing.T) {
+	mounted := func(controllers ...string) func(string) (bool, error) {
+		return func(c string) (bool, error) {
+			for _, m := range controllers {
+				if m == c {
+					return true, nil
+				}
+			}
+			return false, nil
+		}
+	}
+
+	tests := []struct {
+		layout      Layout
+		isMounted   func(string) (bool, error)
+		controllers []string
+
+		expected []string
+		fail     bool
+	}{
+		{
+			layout:    LayoutLegacy,
+			isMounted: mounted("cpu", "memory", "devices"),
+			expected:  []string{"cpu", "memory"},
+		},
+		{
+			layout:    LayoutLegacy,
+			isMounted: mounted("cpu"),
+			// controllers of a cgroup2 hierarchy are ignored in the
+			// legacy layout
+			controllers: []string{"memory"},
+			expected:    []string{"cpu"},
+		},
+		{
+			layout:    LayoutLegacy,
+			isMounted: mounted(),
+			expected:  []string{},
+		},
+		{
+			layout:    LayoutLegacy,
+			isMounted: func(string) (bool, error) { return false, errors.New("permission denied") },
+			fail:      true,
+		},
+		{
+			layout: LayoutUnified,
+			isMounted: func(string) (bool, error) {
+				return false, errors.New("v1 controllers should not be checked")
+			},
+			controllers: []string{"io", "memory", "pids"},
+			expected:    []string{"memory"},
+		},
+		{
+			layout:      LayoutUnified,
+			isMounted:   mounted(),
+			controllers: []string{"cpu", "io", "memory", "pids"},
+			expected:    []string{"cpu", "memory"},
+		},
+		{
+			layout:      LayoutHybrid,
+			isMounted:   mounted("cpu"),
+			controllers: []string{"memory"},
+			expected:    []string{"cpu", "memory"},
+		},
+		{
+			layout:      LayoutHybrid,
+			isMounted:   mounted("cpu", "memory"),
+			controllers: []string{"cpu", "memory"},
+			expected:    []string{"cpu", "memory"},
+		},
+		{
+			layout:    LayoutHybrid,
+			isMounted: mounted("memory"),
+			expected:  []string{"memory"},
+		},
+	}
+
+	for i, tt := range tests {
+		supported, err := supportedIsolators(tt.layout, tt.isMounted, tt.controllers)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("#%d: expected an error, got %v", i, supported)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error `%v`", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(supported, tt.expected) {
+			t.Errorf("#%d: expected `%v` got `%v`", i, tt.expected, supported)
+		}
+	}
+}
+
+// writeCgroupLayout creates a fake cgroup hierarchy under root: a v1
+// hierarchy for each of v1Dirs and, if v2Path is not empty, a cgroup2
+// hierarchy at v2Path with the given controllers enabled
+func writeCgroupLayout(t *testing.T, root string, v1Dirs []string, v2Path string, controllers string) {
+	cgroupPath := filepath.Join(root, "sys/fs/cgroup")
+	for _, d := range v1Dirs {
+		dir := filepath.Join(cgroupPath, d)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("error creating %q: %v", dir, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "cgroup.procs"), nil, 0644); err != nil {
+			t.Fatalf("error writing cgroup.procs: %v", err)
+		}
+	}
+	if v2Path != "" {
+		dir := filepath.Join(cgroupPath, v2Path)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("error creating %q: %v", dir, err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "cgroup.controllers"), []byte(controllers+"\n"), 0644); err != nil {
+			t.Fatalf("error writing cgroup.controllers: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, "cgroup.procs"), nil, 0644); err != nil {
+			t.Fatalf("error writing cgroup.procs: %v", err)
+		}
+	}
+}
+
+func TestControllerPath(t *testing.T) {
+	tests := []struct {
+		layout      Layout
+		v1Dirs      []string
+		v2Path      string
+		controllers string
+
+		controller string
+		expected   string
+		fail       bool
+	}{
+		// legacy
+		{
+			layout:     LayoutLegacy,
+			v1Dirs:     []string{"memory", "cpu,cpuacct", "cpu", "systemd"},
+			controller: "memory",
+			expected:   "sys/fs/cgroup/memory",
+		},
+		{
+			layout:     LayoutLegacy,
+			v1Dirs:     []string{"memory", "cpu,cpuacct", "cpu", "systemd"},
+			controller: "cpu",
+			expected:   "sys/fs/cgroup/cpu",
+		},
+		{
+			layout:     LayoutLegacy,
+			v1Dirs:     []string{"memory"},
+			controller: "pids",
+			fail:       true,
+		},
+		// hybrid
+		{
+			layout:     LayoutHybrid,
+			v1Dirs:     []string{"memory", "systemd"},
+			v2Path:     "unified",
+			controller: "memory",
+			expected:   "sys/fs/cgroup/memory",
+		},
+		{
+			layout:      LayoutHybrid,
+			v1Dirs:      []string{"memory", "systemd"},
+			v2Path:      "unified",
+			controllers: "pids",
+			controller:  "pids",
+			expected:    "sys/fs/cgroup/unified",
+		},
+		{
+			layout:      LayoutHybrid,
+			v1Dirs:      []string{"memory", "systemd"},
+			v2Path:      "unified",
+			controllers: "pids",
+			controller:  "cpu",
+			fail:        true,
+		},
+		// unified
+		{
+			layout:      LayoutUnified,
+			v2Path:      ".",
+			controllers: "cpu io memory pids",
+			controller:  "memory",
+			expected:    "sys/fs/cgroup",
+		},
+		{
+			layout:      LayoutUnified,
+			v1Dirs:      []string{"memory"},
+			v2Path:      ".",
+			controllers: "io pids",
+			controller:  "memory",
+			fail:        true,
+		},
+	}
+
+	for i, tt := range tests {
+		root, err := ioutil.TempDir("", "rkt-cgroup-test")
+		if err != nil {
+			t.Fatalf("error creating tempdir: %v", err)
+		}
+		defer os.RemoveAll(root)
+		writeCgroupLayout(t, root, tt.v1Dirs, tt.v2Path, tt.controllers)
+
+		path, err := controllerPath(tt.layout, root, tt.controller)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("#%d: expected an error, got %q", i, path)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error `%v`", i, err)
+			continue
+		}
+		if expected := filepath.Join(root, tt.expected); path != expected {
+			t.Errorf("#%d: expected %q got %q", i, expected, path)
+		}
+	}
+}
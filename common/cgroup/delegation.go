@@ -0,0 +1,99 @@
+// Copyright 2015 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package cgroup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/rkt/rkt/common/cgroup/v2"
+)
+
+// delegatedFiles are the files of a cgroup2 directory that have to be
+// writable by a user for the cgroup to be delegated to them
+var delegatedFiles = []string{"cgroup.procs", "cgroup.subtree_control"}
+
+// DetectDelegatedCgroup returns the path of the topmost cgroup delegated to
+// the current user that contains the cgroup of this process. ok is false if
+// the cgroup hierarchy is not unified or no cgroup is delegated.
+func DetectDelegatedCgroup() (path string, ok bool, err error) {
+	layout, err := GetLayout("/")
+	if err != nil {
+		return "", false, errwrap.Wrap(errors.New("error determining cgroup layout"), err)
+	}
+	if layout != LayoutUnified {
+		return "", false, nil
+	}
+
+	ownPath, err := v2.GetOwnCgroupPath()
+	if err != nil {
+		return "", false, errwrap.Wrap(errors.New("could not get own v2 cgroup path"), err)
+	}
+
+	return detectDelegatedCgroup("/sys/fs/cgroup", ownPath, os.Getuid())
+}
+
+// detectDelegatedCgroup walks up from the cgroup ownPath of the hierarchy
+// mounted at cgroupRoot and returns the path of the topmost cgroup in the
+// chain that is delegated to uid
+func detectDelegatedCgroup(cgroupRoot, ownPath string, uid int) (string, bool, error) {
+	var delegated string
+	for p := filepath.Clean("/" + ownPath); p != "/"; p = filepath.Dir(p) {
+		dir := filepath.Join(cgroupRoot, p)
+		ok, err := isDelegated(dir, uid)
+		if err != nil {
+			return "", false, err
+		}
+		if !ok {
+			break
+		}
+		delegated = dir
+	}
+
+	return delegated, delegated != "", nil
+}
+
+// isDelegated returns whether the cgroup directory dir and the files needed
+// to manage it are owned by and writable for uid
+func isDelegated(dir string, uid int) (bool, error) {
+	if _, err := os.Stat(filepath.Join(dir, "cgroup.controllers")); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, f := range append([]string{"."}, delegatedFiles...) {
+		path := filepath.Join(dir, f)
+		fi, err := os.Stat(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return false, nil
+			}
+			return false, errwrap.Wrapf("error checking "+path, err)
+		}
+		st, ok := fi.Sys().(*syscall.Stat_t)
+		if !ok || int(st.Uid) != uid || fi.Mode().Perm()&0200 == 0 {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
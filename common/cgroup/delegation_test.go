@@ -0,0 +1,118 @@
+// Copyright 2015 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package cgroup
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectDelegatedCgroup(t *testing.T) {
+	const ownPath = "/user.slice/user-1000.slice/user@1000.service/app.slice"
+
+	tests := []struct {
+		// readOnly lists cgroups that are not writable
+		readOnly []string
+		// missing lists cgroup files which are not created
+		missing []string
+		uid     int
+
+		expected string
+	}{
+		{
+			readOnly: []string{"/user.slice", "/user.slice/user-1000.slice"},
+			uid:      os.Getuid(),
+			expected: "/user.slice/user-1000.slice/user@1000.service",
+		},
+		{
+			readOnly: []string{"/user.slice", "/user.slice/user-1000.slice/user@1000.service"},
+			uid:      os.Getuid(),
+			expected: "/user.slice/user-1000.slice/user@1000.service/app.slice",
+		},
+		{
+			missing:  []string{"/user.slice/user-1000.slice/cgroup.subtree_control"},
+			uid:      os.Getuid(),
+			expected: "/user.slice/user-1000.slice/user@1000.service",
+		},
+		{
+			uid:      os.Getuid(),
+			expected: "/user.slice",
+		},
+		{
+			readOnly: []string{ownPath},
+			uid:      os.Getuid(),
+		},
+		{
+			uid: os.Getuid() + 1,
+		},
+	}
+
+	for i, tt := range tests {
+		root, err := ioutil.TempDir("", "rkt-cgroup-test")
+		if err != nil {
+			t.Fatalf("error creating tempdir: %v", err)
+		}
+		defer os.RemoveAll(root)
+
+		var dirs []string
+		for p := ownPath; p != "/"; p = filepath.Dir(p) {
+			dirs = append(dirs, p)
+		}
+		for _, d := range dirs {
+			dir := filepath.Join(root, d)
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				t.Fatalf("error creating %q: %v", dir, err)
+			}
+			for _, f := range []string{"cgroup.controllers", "cgroup.procs", "cgroup.subtree_control"} {
+				if err := ioutil.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+					t.Fatalf("error writing %q: %v", f, err)
+				}
+			}
+		}
+		for _, m := range tt.missing {
+			if err := os.Remove(filepath.Join(root, m)); err != nil {
+				t.Fatalf("error removing %q: %v", m, err)
+			}
+		}
+		for _, r := range tt.readOnly {
+			if err := os.Chmod(filepath.Join(root, r), 0555); err != nil {
+				t.Fatalf("error making %q read-only: %v", r, err)
+			}
+		}
+
+		path, ok, err := detectDelegatedCgroup(root, ownPath, tt.uid)
+		// make the tree removable again
+		for _, r := range tt.readOnly {
+			os.Chmod(filepath.Join(root, r), 0755)
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error `%v`", i, err)
+			continue
+		}
+		if tt.expected == "" {
+			if ok {
+				t.Errorf("#%d: expected no delegated cgroup, got %q", i, path)
+			}
+			continue
+		}
+		if expected := filepath.Join(root, tt.expected); !ok || path != expected {
+			t.Errorf("#%d: expected %q got %q (ok: %t)", i, expected, path, ok)
+		}
+	}
+}
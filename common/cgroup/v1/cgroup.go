@@ -330,10 +330,11 @@ func CreateCgroups(m fs.Mounter, root string, enabledCgroups map[int][]string, m
 // but leaves needed knobs in the pod's subcgroup read-write,
 // such that systemd inside stage1 can apply isolators to them.
 // It leaves /sys read-write if the given readWrite parameter is true.
+// If rwControllers is not empty, only the knobs of hierarchies with at least
+// one of the listed controllers are left read-write.
 // When this is done, <stage1>/sys/fs/cgroup/<controller> should be RO, and
 // <stage1>/sys/fs/cgroup/<cotroller>/.../machine-rkt/.../system.slice should be RW
-func RemountCgroups(m fs.Mounter, root string, enabledCgroups map[int][]string, subcgroup string, readWrite bool) error {
-	controllers := GetControllerDirs(enabledCgroups)
+func RemountCgroups(m fs.Mounter, root string, enabledCgroups map[int][]string, subcgroup string, rwControllers []string, readWrite bool) error {
 	cgroupTmpfs := filepath.Join(root, "/sys/fs/cgroup")
 	sysPath := filepath.Join(root, "/sys")
 
@@ -342,8 +343,17 @@ func RemountCgroups(m fs.Mounter, root string, enabledCgroups map[int][]string,
 		syscall.MS_NODEV
 
 	// Mount RW the controllers for this pod
-	for _, c := range controllers {
+	for _, cs := range enabledCgroups {
+		c := strings.Join(cs, ",")
 		cPath := filepath.Join(cgroupTmpfs, c)
+
+		if !isRWPermitted(cs, rwControllers) {
+			if err := mountFsRO(m, cPath, flags); err != nil {
+				return err
+			}
+			continue
+		}
+
 		subcgroupPath := filepath.Join(cPath, subcgroup, "system.slice")
 
 		if err := os.MkdirAll(subcgroupPath, 0755); err != nil {
@@ -373,3 +383,19 @@ func RemountCgroups(m fs.Mounter, root string, enabledCgroups map[int][]string,
 	// Bind-mount sys filesystem read-only
 	return mountFsRO(m, sysPath, flags)
 }
+
+// isRWPermitted returns whether the knobs of a hierarchy with the given
+// controllers may be left read-write. An empty rwControllers permits all.
+func isRWPermitted(controllers []string, rwControllers []string) bool {
+	if len(rwControllers) == 0 {
+		return true
+	}
+	for _, c := range controllers {
+		for _, rw := range rwControllers {
+			if c == rw {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -18,9 +18,16 @@ package v1
 
 import (
 	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
+	"syscall"
 	"testing"
+
+	"github.com/rkt/rkt/pkg/fs"
 )
 
 func TestParseCgroups(t *testing.T) {
@@ -110,3 +117,73 @@ net_prio	6	432	1`
 		}
 	}
 }
+
+func TestRemountCgroupsRWControllers(t *testing.T) {
+	enabledCgroups := map[int][]string{
+		2: {"cpuset"},
+		3: {"cpu", "cpuacct"},
+		6: {"memory"},
+	}
+	const subcgroup = "machine.slice/machine-rkt.scope"
+
+	tests := []struct {
+		rwControllers []string
+		expected      []string
+	}{
+		{
+			rwControllers: nil,
+			expected:      []string{"cpu,cpuacct", "cpuset", "memory"},
+		},
+		{
+			rwControllers: []string{"memory"},
+			expected:      []string{"memory"},
+		},
+		{
+			rwControllers: []string{"cpuacct", "memory"},
+			expected:      []string{"cpu,cpuacct", "memory"},
+		},
+		{
+			rwControllers: []string{"pids"},
+			expected:      []string{},
+		},
+	}
+
+	for i, tt := range tests {
+		root, err := ioutil.TempDir("", "rkt-cgroup-test")
+		if err != nil {
+			t.Fatalf("error creating tempdir: %v", err)
+		}
+		defer os.RemoveAll(root)
+
+		cgroupTmpfs := filepath.Join(root, "sys/fs/cgroup")
+		rw := []string{}
+		ro := []string{}
+		m := fs.MounterFunc(func(source string, target string, fstype string, flags uintptr, data string) error {
+			rel, err := filepath.Rel(cgroupTmpfs, target)
+			if err != nil {
+				return err
+			}
+			switch {
+			case flags&syscall.MS_RDONLY != 0:
+				ro = append(ro, rel)
+			case flags == syscall.MS_BIND:
+				rw = append(rw, strings.Split(rel, "/")[0])
+			}
+			return nil
+		})
+
+		if err := RemountCgroups(m, root, enabledCgroups, subcgroup, tt.rwControllers, true); err != nil {
+			t.Errorf("#%d: unexpected error `%v`", i, err)
+			continue
+		}
+
+		sort.Strings(rw)
+		sort.Strings(ro)
+		if !reflect.DeepEqual(rw, tt.expected) {
+			t.Errorf("#%d: expected read-write knobs for `%v` got `%v`", i, tt.expected, rw)
+		}
+		if expectedRO := []string{"cpu,cpuacct", "cpuset", "memory"}; !reflect.DeepEqual(ro, expectedRO) {
+			t.Errorf("#%d: expected read-only hierarchies `%v` got `%v`", i, expectedRO, ro)
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/hashicorp/errwrap"
@@ -28,7 +29,13 @@ import (
 
 // GetEnabledControllers returns a list of enabled cgroup controllers
 func GetEnabledControllers() ([]string, error) {
-	controllersFile, err := os.Open("/sys/fs/cgroup/cgroup.controllers")
+	return GetEnabledControllersAt("/sys/fs/cgroup")
+}
+
+// GetEnabledControllersAt returns a list of the cgroup controllers enabled in
+// the cgroup2 hierarchy mounted at the given path
+func GetEnabledControllersAt(cgroupPath string) ([]string, error) {
+	controllersFile, err := os.Open(filepath.Join(cgroupPath, "cgroup.controllers"))
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +48,7 @@ func GetEnabledControllers() ([]string, error) {
 		return nil, err
 	}
 
-	return strings.Split(sc.Text(), " "), nil
+	return strings.Fields(sc.Text()), nil
 }
 
 func parseProcCgroupInfo(procCgroupInfoPath string) (string, error) {
@@ -47,6 +47,9 @@ const (
 	EnvLockFd                    = "RKT_LOCK_FD"
 	EnvSELinuxContext            = "RKT_SELINUX_CONTEXT"
 	EnvSELinuxMountContext       = "RKT_SELINUX_MOUNT_CONTEXT"
+	EnvDefaultCNIVersion         = "RKT_DEFAULT_CNI_VERSION"
+	EnvCgroupRWControllers       = "RKT_CGROUP_RW_CONTROLLERS"
+	EnvStrictSubnets             = "RKT_STRICT_SUBNETS"
 	Stage1TreeStoreIDFilename    = "stage1TreeStoreID"
 	AppTreeStoreIDFilename       = "treeStoreID"
 	OverlayPreparedFilename      = "overlay-prepared"
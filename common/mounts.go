@@ -0,0 +1,53 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/appc/spec/schema"
+	"github.com/appc/spec/schema/types"
+)
+
+// ValidateMounts checks that no app of the pod manifest mounts two volumes
+// on the same target and that all mounts without an embedded volume refer
+// to a volume of the pod.
+func ValidateMounts(pm *schema.PodManifest) error {
+	vols := make(map[types.ACName]struct{}, len(pm.Volumes))
+	for _, v := range pm.Volumes {
+		vols[v.Name] = struct{}{}
+	}
+
+	for _, ra := range pm.Apps {
+		targets := make(map[string]struct{}, len(ra.Mounts))
+		for _, m := range ra.Mounts {
+			target := path.Clean(m.Path)
+			if _, ok := targets[target]; ok {
+				return fmt.Errorf("app %q has multiple mounts on %q", ra.Name, target)
+			}
+			targets[target] = struct{}{}
+
+			if m.AppVolume != nil {
+				continue
+			}
+			if _, ok := vols[m.Volume]; !ok {
+				return fmt.Errorf("app %q mounts volume %q on %q, but the pod has no such volume", ra.Name, m.Volume, m.Path)
+			}
+		}
+	}
+
+	return nil
+}
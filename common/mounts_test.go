@@ -0,0 +1,89 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+
+	"github.com/appc/spec/schema"
+	"github.com/appc/spec/schema/types"
+)
+
+func TestValidateMounts(t *testing.T) {
+	newApp := func(name string, mounts ...schema.Mount) schema.RuntimeApp {
+		return schema.RuntimeApp{
+			Name:   *types.MustACName(name),
+			Mounts: mounts,
+		}
+	}
+	mount := func(volume, target string) schema.Mount {
+		return schema.Mount{Volume: *types.MustACName(volume), Path: target}
+	}
+	volumes := []types.Volume{
+		{Name: *types.MustACName("data"), Kind: "empty"},
+		{Name: *types.MustACName("logs"), Kind: "empty"},
+	}
+
+	tests := []struct {
+		apps  schema.AppList
+		valid bool
+	}{
+		{
+			apps:  schema.AppList{newApp("app", mount("data", "/data"), mount("logs", "/var/log"))},
+			valid: true,
+		},
+		// the same target in different apps
+		{
+			apps: schema.AppList{
+				newApp("app1", mount("data", "/data")),
+				newApp("app2", mount("data", "/data")),
+			},
+			valid: true,
+		},
+		// a mount with an embedded volume
+		{
+			apps: schema.AppList{newApp("app", schema.Mount{
+				Volume:    *types.MustACName("inline"),
+				Path:      "/inline",
+				AppVolume: &types.Volume{Name: *types.MustACName("inline"), Kind: "empty"},
+			})},
+			valid: true,
+		},
+		// duplicate target
+		{
+			apps:  schema.AppList{newApp("app", mount("data", "/data"), mount("logs", "/data/"))},
+			valid: false,
+		},
+		// dangling volume reference
+		{
+			apps:  schema.AppList{newApp("app", mount("data", "/data"), mount("cache", "/cache"))},
+			valid: false,
+		},
+	}
+
+	for i, tt := range tests {
+		pm := schema.BlankPodManifest()
+		pm.Apps = tt.apps
+		pm.Volumes = volumes
+
+		err := ValidateMounts(pm)
+		if tt.valid && err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("#%d: expected an error", i)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/appc/spec/schema/types"
+	"github.com/hashicorp/errwrap"
+)
+
+// podUUIDTemplate shows where the dashes are in a canonical pod UUID
+const podUUIDTemplate = "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx"
+
+// ParsePodUUID parses a full pod UUID, with or without dashes. The
+// returned error has the same message for all invalid inputs.
+func ParsePodUUID(s string) (*types.UUID, error) {
+	u, err := types.NewUUID(s)
+	if err != nil {
+		return nil, errwrap.Wrap(fmt.Errorf("not a valid pod UUID: %q", s), err)
+	}
+	return u, nil
+}
+
+// ValidatePodUUIDPrefix checks if s is a full pod UUID or a prefix of
+// one in its canonical form (e.g. "5cb09d08" or "5cb09d08-e5e0"), as
+// accepted by the commands taking a pod UUID. Uppercase letters are
+// allowed.
+func ValidatePodUUIDPrefix(s string) error {
+	if s == "" {
+		return fmt.Errorf("not a valid pod UUID: %q", s)
+	}
+	if len(s) == len(podUUIDTemplate)-4 {
+		// full UUID without dashes
+		_, err := ParsePodUUID(s)
+		return err
+	}
+	if len(s) > len(podUUIDTemplate) {
+		return fmt.Errorf("not a valid pod UUID: %q is too long", s)
+	}
+	for i, c := range strings.ToLower(s) {
+		switch {
+		case podUUIDTemplate[i] == '-':
+			if c != '-' {
+				return fmt.Errorf("not a valid pod UUID: %q, expected a dash at position %d", s, i+1)
+			}
+		case !strings.ContainsRune("0123456789abcdef", c):
+			return fmt.Errorf("not a valid pod UUID: %q, unexpected character %q", s, c)
+		}
+	}
+	return nil
+}
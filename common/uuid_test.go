@@ -0,0 +1,77 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"testing"
+)
+
+func TestParsePodUUID(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected string
+		fail     bool
+	}{
+		{"6733c088-a507-4694-aabf-edbe4fc5266f", "6733c088-a507-4694-aabf-edbe4fc5266f", false},
+		{"6733C088-A507-4694-AABF-EDBE4FC5266F", "6733c088-a507-4694-aabf-edbe4fc5266f", false},
+		{"6733c088a5074694aabfedbe4fc5266f", "6733c088-a507-4694-aabf-edbe4fc5266f", false},
+		{"6733c088", "", true},
+		{"6733c088-a507-4694-aabf-edbe4fc5266g", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		u, err := ParsePodUUID(tt.in)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("%q: expected an error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.in, err)
+			continue
+		}
+		if u.String() != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.in, tt.expected, u.String())
+		}
+	}
+}
+
+func TestValidatePodUUIDPrefix(t *testing.T) {
+	tests := []struct {
+		in   string
+		fail bool
+	}{
+		{"6733c088-a507-4694-aabf-edbe4fc5266f", false},
+		{"6733c088a5074694aabfedbe4fc5266f", false},
+		{"6733c088", false},
+		{"6733C088-a5", false},
+		{"6", false},
+		{"6733c088-", false},
+		{"", true},
+		{"6733c088a5", true},
+		{"6733c088-a50g", true},
+		{"not-a-uuid", true},
+		{"6733c088-a507-4694-aabf-edbe4fc5266f0", true},
+	}
+	for _, tt := range tests {
+		err := ValidatePodUUIDPrefix(tt.in)
+		if tt.fail && err == nil {
+			t.Errorf("%q: expected an error", tt.in)
+		} else if !tt.fail && err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.in, err)
+		}
+	}
+}
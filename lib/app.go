@@ -22,7 +22,9 @@ import (
 	"github.com/appc/spec/schema/types"
 	"github.com/rkt/rkt/api/v1"
 	"github.com/rkt/rkt/common"
+	"github.com/rkt/rkt/common/apps"
 	pkgPod "github.com/rkt/rkt/pkg/pod"
+	stage1types "github.com/rkt/rkt/stage1/common/types"
 )
 
 // appStateFunc fills in known state information:
@@ -69,6 +71,53 @@ func appsForPod(p *pkgPod.Pod, appName string, appState appStateFunc) ([]*v1.App
 	return apps, nil
 }
 
+// AppRestartPolicy returns the restart policy of the app with the given name
+// in the pod with the given uuid in the given data directory. Apps added
+// without a restart policy are never restarted.
+func AppRestartPolicy(uuid, dataDir string, appName string) (apps.RestartPolicy, error) {
+	p, err := pkgPod.PodFromUUIDString(dataDir, uuid)
+	if err != nil {
+		return "", err
+	}
+	defer p.Close()
+
+	_, podManifest, err := p.PodManifest()
+	if err != nil {
+		return "", err
+	}
+
+	return appRestartPolicy(podManifest, appName)
+}
+
+func appRestartPolicy(podManifest *schema.PodManifest, appName string) (apps.RestartPolicy, error) {
+	ra, err := runtimeApp(podManifest, appName)
+	if err != nil {
+		return "", err
+	}
+
+	policy, ok := ra.Annotations.Get(stage1types.AppRestartPolicy)
+	if !ok {
+		return apps.RestartPolicyNever, nil
+	}
+
+	return apps.NewRestartPolicy(policy)
+}
+
+// runtimeApp returns the runtime app with the given name in the pod manifest.
+func runtimeApp(podManifest *schema.PodManifest, appName string) (*schema.RuntimeApp, error) {
+	an, err := types.NewACName(appName)
+	if err != nil {
+		return nil, err
+	}
+
+	ra := podManifest.Apps.Get(*an)
+	if ra == nil {
+		return nil, fmt.Errorf("app %q not found in the pod", appName)
+	}
+
+	return ra, nil
+}
+
 // newApp constructs the App object with the runtime app and pod manifest.
 func newApp(ra *schema.RuntimeApp, podManifest *schema.PodManifest, pod *pkgPod.Pod, appState appStateFunc) (*v1.App, error) {
 	app := &v1.App{
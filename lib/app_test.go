@@ -0,0 +1,69 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rkt
+
+import (
+	"testing"
+
+	"github.com/appc/spec/schema"
+	"github.com/appc/spec/schema/types"
+	"github.com/rkt/rkt/common/apps"
+	stage1types "github.com/rkt/rkt/stage1/common/types"
+)
+
+func TestAppRestartPolicy(t *testing.T) {
+	newApp := func(name, policy string) schema.RuntimeApp {
+		ra := schema.RuntimeApp{Name: *types.MustACName(name)}
+		if policy != "" {
+			ra.Annotations.Set(stage1types.AppRestartPolicy, policy)
+		}
+		return ra
+	}
+
+	pm := schema.BlankPodManifest()
+	pm.Apps = schema.AppList{
+		newApp("on-failure", "OnFailure"),
+		newApp("always", "Always"),
+		newApp("default", ""),
+		newApp("invalid", "Sometimes"),
+	}
+
+	tests := []struct {
+		app      string
+		expected apps.RestartPolicy
+	}{
+		{"on-failure", apps.RestartPolicyOnFailure},
+		{"always", apps.RestartPolicyAlways},
+		{"default", apps.RestartPolicyNever},
+		{"invalid", ""},
+		{"missing", ""},
+	}
+	for _, tt := range tests {
+		policy, err := appRestartPolicy(pm, tt.app)
+		if tt.expected == "" {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %q", tt.app, policy)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.app, err)
+			continue
+		}
+		if policy != tt.expected {
+			t.Errorf("%q: expected %q, got %q", tt.app, tt.expected, policy)
+		}
+	}
+}
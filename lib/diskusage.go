@@ -0,0 +1,130 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rkt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/appc/spec/schema"
+	"github.com/hashicorp/errwrap"
+	pkgPod "github.com/rkt/rkt/pkg/pod"
+)
+
+// fileID identifies a file on the host.
+type fileID struct {
+	dev uint64
+	ino uint64
+}
+
+// PodDiskUsage returns the disk space, in bytes, used by the pod with the
+// given uuid in the given data directory. It sums the sizes of the files
+// stored in the pod directory: the rendered rootfses or, with overlay, their
+// upper directories, the logs and rkt's own bookkeeping files. Mounted
+// filesystems, like the overlay rootfses themselves, and the sources of
+// host volumes are not counted.
+func PodDiskUsage(uuid, dataDir string) (int64, error) {
+	p, err := pkgPod.PodFromUUIDString(dataDir, uuid)
+	if err != nil {
+		return 0, err
+	}
+	defer p.Close()
+
+	var hostVolumes []string
+	if p.PodManifestAvailable() {
+		_, pm, err := p.PodManifest()
+		if err != nil {
+			return 0, err
+		}
+		hostVolumes = hostVolumeSources(pm)
+	}
+
+	return diskUsage(p.Path(), hostVolumes)
+}
+
+// hostVolumeSources returns the sources of the host volumes of the pod.
+func hostVolumeSources(pm *schema.PodManifest) []string {
+	var sources []string
+	for _, v := range pm.Volumes {
+		if v.Kind == "host" {
+			sources = append(sources, v.Source)
+		}
+	}
+	return sources
+}
+
+// diskUsage sums the sizes of the regular files under root, without
+// crossing mount points and skipping the directories in exclude. Hard
+// links are only counted once.
+func diskUsage(root string, exclude []string) (int64, error) {
+	rootInfo, err := os.Lstat(root)
+	if err != nil {
+		return 0, err
+	}
+	rootDev := rootInfo.Sys().(*syscall.Stat_t).Dev
+
+	excluded := make(map[fileID]struct{})
+	for _, e := range exclude {
+		fi, err := os.Stat(e)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return 0, errwrap.Wrap(errors.New("cannot stat host volume "+e), err)
+		}
+		st := fi.Sys().(*syscall.Stat_t)
+		excluded[fileID{uint64(st.Dev), uint64(st.Ino)}] = struct{}{}
+	}
+
+	var size int64
+	seen := make(map[fileID]struct{})
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// the pod's files may go away while walking
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		st := info.Sys().(*syscall.Stat_t)
+		id := fileID{uint64(st.Dev), uint64(st.Ino)}
+
+		if info.IsDir() {
+			if st.Dev != rootDev {
+				return filepath.SkipDir
+			}
+			if _, ok := excluded[id]; ok {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		if st.Nlink > 1 {
+			if _, ok := seen[id]; ok {
+				return nil
+			}
+			seen[id] = struct{}{}
+		}
+		size += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rkt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/appc/spec/schema"
+	"github.com/appc/spec/schema/types"
+)
+
+func TestDiskUsage(t *testing.T) {
+	podPath, err := ioutil.TempDir("", "rkt-lib-diskusage-test")
+	if err != nil {
+		t.Fatalf("error creating a temporary directory: %v", err)
+	}
+	defer os.RemoveAll(podPath)
+
+	files := map[string]int{
+		"pod":                100,
+		"stage1/rootfs/init": 1000,
+		"stage1/rootfs/opt/stage2/app/rootfs/bin": 2000,
+		"overlay/deadbeef/upper/etc/hosts":        30,
+		"appsinfo/app/manifest":                   400,
+		// the source of a host volume, not counted
+		"volume/data": 50000,
+	}
+	for f, size := range files {
+		p := filepath.Join(podPath, f)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("error creating the directory of %q: %v", f, err)
+		}
+		if err := ioutil.WriteFile(p, []byte(strings.Repeat("x", size)), 0644); err != nil {
+			t.Fatalf("error writing %q: %v", f, err)
+		}
+	}
+	// hard links are counted once
+	if err := os.Link(filepath.Join(podPath, "stage1/rootfs/init"), filepath.Join(podPath, "stage1/rootfs/init-link")); err != nil {
+		t.Fatalf("error creating a hard link: %v", err)
+	}
+	// symlinks are not followed
+	if err := os.Symlink(filepath.Join(podPath, "volume"), filepath.Join(podPath, "stage1/rootfs/volume")); err != nil {
+		t.Fatalf("error creating a symlink: %v", err)
+	}
+
+	pm := schema.BlankPodManifest()
+	pm.Volumes = []types.Volume{
+		{Name: *types.MustACName("host"), Kind: "host", Source: filepath.Join(podPath, "volume")},
+		{Name: *types.MustACName("empty"), Kind: "empty"},
+	}
+
+	size, err := diskUsage(podPath, hostVolumeSources(pm))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := int64(100 + 1000 + 2000 + 30 + 400); size != expected {
+		t.Errorf("expected a disk usage of %d bytes, got %d", expected, size)
+	}
+
+	size, err = diskUsage(podPath, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := int64(100 + 1000 + 2000 + 30 + 400 + 50000); size != expected {
+		t.Errorf("expected a disk usage of %d bytes without exclusions, got %d", expected, size)
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rkt
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rkt/rkt/pkg/mountinfo"
+)
+
+// deletedSuffix is appended by the kernel to the mount points in mountinfo
+// whose directory was removed.
+const deletedSuffix = "//deleted"
+
+// FindOrphanedOverlayMounts returns the mount points under the pods tree of
+// the given data directory that do not belong to an existing pod directory,
+// like the overlay mounts left behind by a crashed run. The mount points
+// are returned in the order they should be unmounted, children first.
+func FindOrphanedOverlayMounts(dataDir string) ([]string, error) {
+	mnts, err := mountinfo.ParseMounts(0)
+	if err != nil {
+		return nil, err
+	}
+
+	return orphanedMounts(filepath.Join(dataDir, "pods"), mnts, podDirExists), nil
+}
+
+// podDirExists tells whether the pod directory at path exists.
+func podDirExists(path string) bool {
+	fi, err := os.Stat(path)
+	return err == nil && fi.IsDir()
+}
+
+// orphanedMounts returns the mount points of mnts under podsDir whose pod
+// directory, podsDir/<state>/<uuid>, does not exist according to exists.
+func orphanedMounts(podsDir string, mnts mountinfo.Mounts, exists func(string) bool) []string {
+	prefix := filepath.Clean(podsDir) + "/"
+	orphaned := mnts.Filter(func(m *mountinfo.Mount) bool {
+		if !strings.HasPrefix(m.MountPoint, prefix) {
+			return false
+		}
+		if strings.HasSuffix(m.MountPoint, deletedSuffix) {
+			return true
+		}
+		parts := strings.SplitN(strings.TrimPrefix(m.MountPoint, prefix), "/", 3)
+		if len(parts) < 2 {
+			// the pods tree itself or a state directory
+			return false
+		}
+		return !exists(filepath.Join(prefix, parts[0], parts[1]))
+	})
+	sort.Sort(orphaned)
+
+	var mountPoints []string
+	for _, m := range orphaned {
+		mountPoints = append(mountPoints, strings.TrimSuffix(m.MountPoint, deletedSuffix))
+	}
+	return mountPoints
+}
@@ -0,0 +1,67 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rkt
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/rkt/rkt/pkg/mountinfo"
+)
+
+func TestOrphanedMounts(t *testing.T) {
+	const podsDir = "/var/lib/rkt/pods"
+	live := podsDir + "/run/11111111-1111-1111-1111-111111111111"
+	gone := podsDir + "/exited-garbage/22222222-2222-2222-2222-222222222222"
+	deleted := podsDir + "/garbage/33333333-3333-3333-3333-333333333333"
+
+	mnts := mountinfo.Mounts{
+		{ID: 1, Parent: 0, MountPoint: "/"},
+		{ID: 2, Parent: 1, MountPoint: "/var/lib/rkt"},
+		{ID: 3, Parent: 2, MountPoint: live + "/stage1/rootfs"},
+		{ID: 4, Parent: 3, MountPoint: live + "/stage1/rootfs/opt/stage2/app/rootfs"},
+		{ID: 5, Parent: 2, MountPoint: gone + "/stage1/rootfs"},
+		{ID: 6, Parent: 5, MountPoint: gone + "/stage1/rootfs/opt/stage2/app/rootfs"},
+		{ID: 7, Parent: 2, MountPoint: deleted + "/stage1/rootfs//deleted"},
+		{ID: 8, Parent: 2, MountPoint: podsDir + "/run"},
+		{ID: 9, Parent: 1, MountPoint: "/var/lib/rkt-other/pods/run/44444444-4444-4444-4444-444444444444/stage1/rootfs"},
+	}
+	exists := func(path string) bool {
+		return path == live || path == deleted
+	}
+
+	expected := []string{
+		gone + "/stage1/rootfs",
+		gone + "/stage1/rootfs/opt/stage2/app/rootfs",
+		deleted + "/stage1/rootfs",
+	}
+	orphaned := orphanedMounts(podsDir, mnts, exists)
+
+	// children must be unmounted before their parents
+	index := make(map[string]int)
+	for i, m := range orphaned {
+		index[m] = i
+	}
+	if index[gone+"/stage1/rootfs/opt/stage2/app/rootfs"] > index[gone+"/stage1/rootfs"] {
+		t.Errorf("expected the nested mount to come first, got %v", orphaned)
+	}
+
+	sorted := append([]string(nil), orphaned...)
+	sort.Strings(sorted)
+	if !reflect.DeepEqual(sorted, expected) {
+		t.Errorf("expected orphaned mounts %v, got %v", expected, orphaned)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rkt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"syscall"
+
+	"github.com/appc/spec/schema"
+	"github.com/appc/spec/schema/types"
+	"github.com/hashicorp/errwrap"
+	"github.com/rkt/rkt/common"
+	"github.com/rkt/rkt/common/apps"
+	pkgPod "github.com/rkt/rkt/pkg/pod"
+	"github.com/rkt/rkt/stage0"
+	stage1types "github.com/rkt/rkt/stage1/common/types"
+)
+
+// RunHealthCheck runs the health check command of the app with the given name
+// inside the running pod with the given uuid in the given data directory and
+// returns its exit code.
+func RunHealthCheck(uuid, dataDir string, appName string) (int, error) {
+	p, err := pkgPod.PodFromUUIDString(dataDir, uuid)
+	if err != nil {
+		return -1, err
+	}
+	defer p.Close()
+
+	if p.State() != pkgPod.Running {
+		return -1, fmt.Errorf("pod %q isn't currently running", p.UUID)
+	}
+
+	_, podManifest, err := p.PodManifest()
+	if err != nil {
+		return -1, err
+	}
+
+	cmdline, err := healthCheckCmdline(podManifest, appName)
+	if err != nil {
+		return -1, err
+	}
+
+	podPID, err := p.ContainerPid1()
+	if err != nil {
+		return -1, errwrap.Wrap(fmt.Errorf("unable to determine the pid for pod %q", p.UUID), err)
+	}
+
+	an, err := types.NewACName(appName)
+	if err != nil {
+		return -1, err
+	}
+
+	cmd, err := stage0.EnterCmd(p.Path(), podPID, *an, common.Stage1RootfsPath(p.Path()), cmdline)
+	if err != nil {
+		return -1, err
+	}
+
+	if err := cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
+				return status.ExitStatus(), nil
+			}
+		}
+		return -1, errwrap.Wrap(errors.New("error running health check"), err)
+	}
+
+	return 0, nil
+}
+
+// healthCheckCmdline returns the health check command of the app with the
+// given name in the pod manifest
+func healthCheckCmdline(podManifest *schema.PodManifest, appName string) ([]string, error) {
+	ra, err := runtimeApp(podManifest, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	hc, ok := ra.Annotations.Get(stage1types.AppHealthCheck)
+	if !ok {
+		return nil, fmt.Errorf("app %q has no health check", appName)
+	}
+
+	var cmdline []string
+	if err := json.Unmarshal([]byte(hc), &cmdline); err != nil {
+		return nil, errwrap.Wrap(errors.New("error parsing health check"), err)
+	}
+	if err := apps.ValidateHealthCheck(cmdline); err != nil {
+		return nil, err
+	}
+
+	return cmdline, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rkt
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/appc/spec/schema"
+	"github.com/appc/spec/schema/types"
+	stage1types "github.com/rkt/rkt/stage1/common/types"
+)
+
+func TestHealthCheckCmdline(t *testing.T) {
+	newApp := func(name, healthCheck string) schema.RuntimeApp {
+		ra := schema.RuntimeApp{Name: *types.MustACName(name)}
+		if healthCheck != "" {
+			ra.Annotations.Set(stage1types.AppHealthCheck, healthCheck)
+		}
+		return ra
+	}
+
+	pm := schema.BlankPodManifest()
+	pm.Apps = schema.AppList{
+		newApp("checked", `["/bin/check","--verbose"]`),
+		newApp("unchecked", ""),
+		newApp("relative", `["check"]`),
+		newApp("empty", `[]`),
+		newApp("invalid", `/bin/check`),
+	}
+
+	tests := []struct {
+		app      string
+		expected []string
+	}{
+		{"checked", []string{"/bin/check", "--verbose"}},
+		{"unchecked", nil},
+		{"relative", nil},
+		{"empty", nil},
+		{"invalid", nil},
+		{"missing", nil},
+	}
+	for _, tt := range tests {
+		cmdline, err := healthCheckCmdline(pm, tt.app)
+		if tt.expected == nil {
+			if err == nil {
+				t.Errorf("%q: expected an error, got %v", tt.app, cmdline)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.app, err)
+			continue
+		}
+		if !reflect.DeepEqual(cmdline, tt.expected) {
+			t.Errorf("%q: expected %v, got %v", tt.app, tt.expected, cmdline)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rkt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/appc/spec/schema/types"
+	"github.com/hashicorp/errwrap"
+	"github.com/rkt/rkt/common"
+	pkgPod "github.com/rkt/rkt/pkg/pod"
+	"github.com/rkt/rkt/stage0"
+)
+
+// journalctlPath is the path of journalctl in systemd-based stage1s
+const journalctlPath = "/usr/bin/journalctl"
+
+// ErrLogsUnsupported is returned by AppLogs for pods whose stage1 does not
+// run systemd-journald.
+var ErrLogsUnsupported = errors.New("app logs are only supported for systemd-based stage1s")
+
+// AppLogs returns a stream of the journal entries of the app with the given
+// name in the running pod with the given uuid in the given data directory.
+// If follow is true, the stream stays open and receives new entries until it
+// is closed.
+func AppLogs(uuid, dataDir string, appName types.ACName, follow bool) (io.ReadCloser, error) {
+	p, err := pkgPod.PodFromUUIDString(dataDir, uuid)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	if p.State() != pkgPod.Running {
+		return nil, fmt.Errorf("pod %q isn't currently running", p.UUID)
+	}
+
+	_, podManifest, err := p.PodManifest()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := runtimeApp(podManifest, appName.String()); err != nil {
+		return nil, err
+	}
+
+	stage1RootFS := common.Stage1RootfsPath(p.Path())
+	if _, err := os.Stat(filepath.Join(stage1RootFS, journalctlPath)); err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrLogsUnsupported
+		}
+		return nil, err
+	}
+
+	podPID, err := p.ContainerPid1()
+	if err != nil {
+		return nil, errwrap.Wrap(fmt.Errorf("unable to determine the pid for pod %q", p.UUID), err)
+	}
+
+	cmd, err := stage0.EnterStage1Cmd(p.Path(), podPID, stage1RootFS, journalctlCmdline(appName, follow))
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, errwrap.Wrap(errors.New("error running journalctl"), err)
+	}
+
+	return &cmdReadCloser{ReadCloser: stdout, cmd: cmd}, nil
+}
+
+// journalctlCmdline returns the journalctl command line printing the entries
+// of the app's unit
+func journalctlCmdline(appName types.ACName, follow bool) []string {
+	cmdline := []string{
+		journalctlPath,
+		"--no-pager",
+		"--output=cat",
+		"--unit=" + appName.String() + ".service",
+	}
+	if follow {
+		cmdline = append(cmdline, "--follow")
+	}
+	return cmdline
+}
+
+// cmdReadCloser reads the output of a command, which is stopped on Close.
+type cmdReadCloser struct {
+	io.ReadCloser
+	cmd *exec.Cmd
+}
+
+func (c *cmdReadCloser) Close() error {
+	// the command may have exited already
+	_ = c.cmd.Process.Kill()
+	err := c.ReadCloser.Close()
+	_ = c.cmd.Wait()
+	return err
+}
@@ -0,0 +1,37 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rkt
+
+import (
+	"github.com/rkt/rkt/networking"
+	pkgPod "github.com/rkt/rkt/pkg/pod"
+)
+
+// ActiveNetworks returns the networks the running pod with the given uuid
+// in the given data directory is on, with their configuration and runtime
+// information. It is empty for pods run with --net=host or --net=none.
+func ActiveNetworks(uuid, dataDir string) ([]networking.ActiveNet, error) {
+	p, err := pkgPod.PodFromUUIDString(dataDir, uuid)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	if p.State() != pkgPod.Running {
+		return nil, ErrPodNotRunning
+	}
+
+	return networking.LoadActiveNets(p.Path())
+}
@@ -16,8 +16,11 @@ package rkt
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 
 	"github.com/rkt/rkt/api/v1"
+	"github.com/rkt/rkt/common"
 	pkgPod "github.com/rkt/rkt/pkg/pod"
 )
 
@@ -91,3 +94,43 @@ func NewPodFromInternalPod(p *pkgPod.Pod) (*v1.Pod, error) {
 
 	return pod, nil
 }
+
+// GetPodByPrefix returns the pod in the given data directory whose UUID
+// starts with the given prefix, like the UUIDs passed to rkt commands.
+// It fails if no pod or more than one pod matches the prefix.
+func GetPodByPrefix(dataDir, prefix string) (*v1.Pod, error) {
+	prefix = strings.ToLower(prefix)
+	if err := common.ValidatePodUUIDPrefix(prefix); err != nil {
+		return nil, err
+	}
+	if u, err := common.ParsePodUUID(prefix); err == nil {
+		prefix = u.String()
+	}
+
+	var (
+		pod     *v1.Pod
+		podErr  error
+		matches []string
+	)
+	if err := pkgPod.WalkPods(dataDir, pkgPod.IncludeMostDirs, func(p *pkgPod.Pod) {
+		uuid := p.UUID.String()
+		if !strings.HasPrefix(uuid, prefix) {
+			return
+		}
+		matches = append(matches, uuid)
+		if len(matches) == 1 {
+			pod, podErr = NewPodFromInternalPod(p)
+		}
+	}); err != nil {
+		return nil, err
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no pod found with UUID prefix %q", prefix)
+	case 1:
+		return pod, podErr
+	default:
+		return nil, fmt.Errorf("UUID prefix %q is ambiguous, matching pods: %s", prefix, strings.Join(matches, ", "))
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rkt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testPodManifest = `{"acKind": "PodManifest", "acVersion": "0.8.11", "apps": []}`
+
+// createPreparedPod creates a minimal prepared pod in dataDir.
+func createPreparedPod(t *testing.T, dataDir, uuid string) {
+	podDir := filepath.Join(dataDir, "pods", "prepared", uuid)
+	if err := os.MkdirAll(podDir, 0700); err != nil {
+		t.Fatalf("error creating pod directory: %v", err)
+	}
+	for _, f := range []string{"pod", "pod-created"} {
+		if err := ioutil.WriteFile(filepath.Join(podDir, f), []byte(testPodManifest), 0600); err != nil {
+			t.Fatalf("error writing %q: %v", f, err)
+		}
+	}
+}
+
+func TestGetPodByPrefix(t *testing.T) {
+	dataDir, err := ioutil.TempDir("", "rkt-lib-test")
+	if err != nil {
+		t.Fatalf("error creating tmpdir: %v", err)
+	}
+	defer os.RemoveAll(dataDir)
+
+	for _, uuid := range []string{
+		"aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa",
+		"abcdabcd-aaaa-aaaa-aaaa-aaaaaaaaaaaa",
+		"abcdabcd-bbbb-bbbb-bbbb-bbbbbbbbbbbb",
+	} {
+		createPreparedPod(t, dataDir, uuid)
+	}
+
+	tests := []struct {
+		prefix   string
+		expected string
+	}{
+		{"aa", "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa"},
+		{"ABCDABCD-B", "abcdabcd-bbbb-bbbb-bbbb-bbbbbbbbbbbb"},
+		{"abcdabcdaaaaaaaaaaaaaaaaaaaaaaaa", "abcdabcd-aaaa-aaaa-aaaa-aaaaaaaaaaaa"},
+		// ambiguous
+		{"abcd", ""},
+		// no match
+		{"ff", ""},
+		// invalid
+		{"not-a-uuid", ""},
+	}
+	for _, tt := range tests {
+		pod, err := GetPodByPrefix(dataDir, tt.prefix)
+		if tt.expected == "" {
+			if err == nil {
+				t.Errorf("%q: expected an error, got pod %q", tt.prefix, pod.UUID)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tt.prefix, err)
+			continue
+		}
+		if pod.UUID != tt.expected {
+			t.Errorf("%q: expected pod %q, got %q", tt.prefix, tt.expected, pod.UUID)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rkt
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/appc/spec/schema/types"
+	"github.com/rkt/rkt/common"
+	pkgPod "github.com/rkt/rkt/pkg/pod"
+)
+
+// unitsDir is the directory of the systemd units in systemd-based stage1s,
+// where the app's service unit is written when the app is added.
+const unitsDir = "/usr/lib/systemd/system"
+
+// ErrUnitNotFound is returned by AppServiceUnit when the app has no service
+// unit, for example because the pod's stage1 is not systemd-based.
+var ErrUnitNotFound = errors.New("no service unit found for the app")
+
+// AppServiceUnit returns the content of the systemd service unit generated
+// for the app with the given name in the pod with the given uuid in the
+// given data directory.
+func AppServiceUnit(uuid, dataDir string, appName types.ACName) ([]byte, error) {
+	p, err := pkgPod.PodFromUUIDString(dataDir, uuid)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	_, podManifest, err := p.PodManifest()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := runtimeApp(podManifest, appName.String()); err != nil {
+		return nil, err
+	}
+
+	return readServiceUnit(p.Path(), appName)
+}
+
+// readServiceUnit reads the service unit of the given app from the stage1
+// rootfs of the pod at podPath.
+func readServiceUnit(podPath string, appName types.ACName) ([]byte, error) {
+	unitPath := filepath.Join(common.Stage1RootfsPath(podPath), unitsDir, appName.String()+".service")
+	unit, err := ioutil.ReadFile(unitPath)
+	if os.IsNotExist(err) {
+		return nil, ErrUnitNotFound
+	}
+	return unit, err
+}
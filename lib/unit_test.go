@@ -0,0 +1,54 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rkt
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/appc/spec/schema/types"
+	"github.com/rkt/rkt/common"
+)
+
+func TestReadServiceUnit(t *testing.T) {
+	podPath, err := ioutil.TempDir("", "rkt-lib-unit-test")
+	if err != nil {
+		t.Fatalf("error creating a temporary directory: %v", err)
+	}
+	defer os.RemoveAll(podPath)
+
+	dir := filepath.Join(common.Stage1RootfsPath(podPath), unitsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("error creating the units directory: %v", err)
+	}
+	content := "[Service]\nExecStart=/inspect\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "app.service"), []byte(content), 0644); err != nil {
+		t.Fatalf("error writing the unit: %v", err)
+	}
+
+	unit, err := readServiceUnit(podPath, *types.MustACName("app"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(unit) != content {
+		t.Errorf("expected unit %q, got %q", content, unit)
+	}
+
+	if _, err := readServiceUnit(podPath, *types.MustACName("missing")); err != ErrUnitNotFound {
+		t.Errorf("expected ErrUnitNotFound for a missing unit, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rkt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/rkt/rkt/common/cgroup"
+	pkgPod "github.com/rkt/rkt/pkg/pod"
+)
+
+// ErrPodNotRunning is returned when querying the resource usage of a pod
+// that is not running.
+var ErrPodNotRunning = errors.New("pod is not running")
+
+// PodUsage is the current resource usage of a pod.
+type PodUsage struct {
+	MemoryBytes uint64        // memory used by the pod
+	CPUTime     time.Duration // CPU time consumed by the pod
+}
+
+// statFile describes a cgroup stat file holding a resource usage.
+type statFile struct {
+	path string
+	v2   bool // whether the file is in the cgroup2 format
+}
+
+// ResourceUsage returns the current memory and CPU usage of the running pod
+// with the given uuid in the given data directory, read from the pod's
+// cgroup.
+func ResourceUsage(uuid, dataDir string) (*PodUsage, error) {
+	p, err := pkgPod.PodFromUUIDString(dataDir, uuid)
+	if err != nil {
+		return nil, err
+	}
+	defer p.Close()
+
+	if p.State() != pkgPod.Running {
+		return nil, ErrPodNotRunning
+	}
+
+	// stage1s which move the pod to a different cgroup write it to the
+	// subcgroup file
+	subcgroup, err := ioutil.ReadFile(filepath.Join(p.Path(), "subcgroup"))
+	if err != nil {
+		return nil, errwrap.Wrap(errors.New("unable to determine the pod's cgroup"), err)
+	}
+
+	mem, cpu, err := usageStatFiles(strings.TrimSpace(string(subcgroup)))
+	if err != nil {
+		return nil, err
+	}
+
+	return podUsage(openStatFile, mem, cpu)
+}
+
+// usageStatFiles returns the memory and CPU stat files of the given cgroup
+// for the host's cgroup layout
+func usageStatFiles(subcgroup string) (mem statFile, cpu statFile, err error) {
+	layout, err := cgroup.GetLayout("/")
+	if err != nil {
+		return mem, cpu, errwrap.Wrap(errors.New("error determining cgroup layout"), err)
+	}
+
+	memPath, err := cgroup.ControllerPath("/", "memory")
+	if err != nil {
+		return mem, cpu, err
+	}
+	mem.v2 = layout == cgroup.LayoutUnified || memPath == "/sys/fs/cgroup/unified"
+	if mem.v2 {
+		mem.path = filepath.Join(memPath, subcgroup, "memory.current")
+	} else {
+		mem.path = filepath.Join(memPath, subcgroup, "memory.usage_in_bytes")
+	}
+
+	if layout == cgroup.LayoutUnified {
+		// cpu.stat is available without the cpu controller
+		cpu = statFile{filepath.Join("/sys/fs/cgroup", subcgroup, "cpu.stat"), true}
+		return mem, cpu, nil
+	}
+
+	cpuPath, err := cgroup.ControllerPath("/", "cpuacct")
+	if err != nil {
+		return mem, cpu, err
+	}
+	cpu = statFile{filepath.Join(cpuPath, subcgroup, "cpuacct.usage"), false}
+
+	return mem, cpu, nil
+}
+
+func openStatFile(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+// podUsage reads the pod usage from the given stat files, opened with open
+func podUsage(open func(string) (io.ReadCloser, error), mem statFile, cpu statFile) (*PodUsage, error) {
+	var usage PodUsage
+
+	f, err := open(mem.path)
+	if err != nil {
+		return nil, errwrap.Wrap(errors.New("error reading memory usage"), err)
+	}
+	defer f.Close()
+	if usage.MemoryBytes, err = parseUint(f); err != nil {
+		return nil, errwrap.Wrap(fmt.Errorf("error parsing %q", mem.path), err)
+	}
+
+	f, err = open(cpu.path)
+	if err != nil {
+		return nil, errwrap.Wrap(errors.New("error reading CPU usage"), err)
+	}
+	defer f.Close()
+	if cpu.v2 {
+		usage.CPUTime, err = parseCPUStat(f)
+	} else {
+		var ns uint64
+		ns, err = parseUint(f)
+		usage.CPUTime = time.Duration(ns)
+	}
+	if err != nil {
+		return nil, errwrap.Wrap(fmt.Errorf("error parsing %q", cpu.path), err)
+	}
+
+	return &usage, nil
+}
+
+// parseUint parses a stat file holding a single integer, like
+// memory.usage_in_bytes, memory.current or cpuacct.usage.
+func parseUint(r io.Reader) (uint64, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+}
+
+// parseCPUStat parses the usage_usec entry of a cgroup2 cpu.stat file.
+func parseCPUStat(r io.Reader) (time.Duration, error) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) != 2 || fields[0] != "usage_usec" {
+			continue
+		}
+		usec, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(usec) * time.Microsecond, nil
+	}
+	if err := sc.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.New("usage_usec not found")
+}
@@ -0,0 +1,94 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rkt
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPodUsage(t *testing.T) {
+	files := map[string]string{
+		"v1/memory.usage_in_bytes": "4194304\n",
+		"v1/cpuacct.usage":         "1500000000\n",
+		"v2/memory.current":        "8388608\n",
+		"v2/cpu.stat":              "usage_usec 2500000\nuser_usec 2000000\nsystem_usec 500000\n",
+		"v2/cpu.stat.nousage":      "user_usec 2000000\nsystem_usec 500000\n",
+		"invalid":                  "max\n",
+	}
+	open := func(path string) (io.ReadCloser, error) {
+		content, ok := files[path]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return ioutil.NopCloser(strings.NewReader(content)), nil
+	}
+
+	tests := []struct {
+		mem statFile
+		cpu statFile
+
+		expected *PodUsage
+	}{
+		{
+			mem:      statFile{"v1/memory.usage_in_bytes", false},
+			cpu:      statFile{"v1/cpuacct.usage", false},
+			expected: &PodUsage{MemoryBytes: 4194304, CPUTime: 1500 * time.Millisecond},
+		},
+		{
+			mem:      statFile{"v2/memory.current", true},
+			cpu:      statFile{"v2/cpu.stat", true},
+			expected: &PodUsage{MemoryBytes: 8388608, CPUTime: 2500 * time.Millisecond},
+		},
+		// hybrid, with memory in the unified hierarchy
+		{
+			mem:      statFile{"v2/memory.current", true},
+			cpu:      statFile{"v1/cpuacct.usage", false},
+			expected: &PodUsage{MemoryBytes: 8388608, CPUTime: 1500 * time.Millisecond},
+		},
+		{
+			mem: statFile{"v2/memory.current", true},
+			cpu: statFile{"v2/cpu.stat.nousage", true},
+		},
+		{
+			mem: statFile{"invalid", false},
+			cpu: statFile{"v1/cpuacct.usage", false},
+		},
+		{
+			mem: statFile{"missing", false},
+			cpu: statFile{"v1/cpuacct.usage", false},
+		},
+	}
+	for i, tt := range tests {
+		usage, err := podUsage(open, tt.mem, tt.cpu)
+		if tt.expected == nil {
+			if err == nil {
+				t.Errorf("#%d: expected an error, got %+v", i, usage)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if *usage != *tt.expected {
+			t.Errorf("#%d: expected %+v, got %+v", i, tt.expected, usage)
+		}
+	}
+}
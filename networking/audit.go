@@ -0,0 +1,100 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// auditLogFilename is the file in the pod directory the plugin
+// invocations are appended to, one JSON record per line.
+const auditLogFilename = "net-audit.log"
+
+// InvocationRecord describes an execution of a network plugin.
+type InvocationRecord struct {
+	Time       time.Time `json:"time"`
+	NetName    string    `json:"netName"`
+	PluginPath string    `json:"pluginPath"`
+	Command    string    `json:"command"`
+	Netns      string    `json:"netns"`
+	IfName     string    `json:"ifName"`
+	Args       string    `json:"args"`
+	// Duration is the wall-clock time the plugin took, in nanoseconds
+	Duration time.Duration `json:"duration"`
+	// Result is what the plugin wrote on its standard output
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// InvocationAuditor records the executions of the network plugins of a pod.
+type InvocationAuditor interface {
+	Audit(InvocationRecord) error
+}
+
+// auditLog appends the invocation records to a file as JSON lines.
+type auditLog string
+
+func (l auditLog) Audit(r InvocationRecord) error {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(string(l), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0640)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// a single write so concurrent records do not interleave
+	_, err = f.Write(append(b, '\n'))
+	return err
+}
+
+func (e *podEnv) auditLogPath() string {
+	return filepath.Join(e.podRoot, auditLogFilename)
+}
+
+// audit records an execution of the plugin of the network n.
+// Failing to do so does not fail the network operation.
+func (e *podEnv) audit(cmd string, n *activeNet, netns string, output []byte, duration time.Duration, err error) {
+	auditor := e.auditor
+	if auditor == nil {
+		auditor = auditLog(e.auditLogPath())
+	}
+
+	r := InvocationRecord{
+		Time:       time.Now().UTC(),
+		NetName:    n.conf.Name,
+		PluginPath: n.runtime.PluginPath,
+		Command:    cmd,
+		Netns:      netns,
+		IfName:     n.runtime.IfName,
+		Args:       cniArgs(n),
+		Duration:   duration,
+		Result:     string(output),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	if aerr := auditor.Audit(r); aerr != nil {
+		stderr.PrintE(fmt.Sprintf("error auditing the %s of network %q", cmd, n.conf.Name), aerr)
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/rkt/rkt/common"
+)
+
+// builtinCNIVersion is the CNI spec version passed to the plugins
+// of the networks not declaring one when it is not overridden with
+// the RKT_DEFAULT_CNI_VERSION environment variable.
+const builtinCNIVersion = "0.1.0"
+
+// supportedCNIVersions are the CNI spec versions of the plugin
+// results rkt understands.
+var supportedCNIVersions = []string{"0.1.0", "0.2.0"}
+
+func checkCNIVersion(version string) error {
+	for _, v := range supportedCNIVersions {
+		if v == version {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported CNI version %q, supported versions are %s", version, strings.Join(supportedCNIVersions, ", "))
+}
+
+// defaultCNIVersion returns the CNI spec version for the networks
+// without a cniVersion.
+func defaultCNIVersion() (string, error) {
+	version := os.Getenv(common.EnvDefaultCNIVersion)
+	if version == "" {
+		return builtinCNIVersion, nil
+	}
+	if err := checkCNIVersion(version); err != nil {
+		return "", errwrap.Wrap(fmt.Errorf("invalid %s", common.EnvDefaultCNIVersion), err)
+	}
+	return version, nil
+}
+
+// setCNIVersion validates the cniVersion of the network. If the
+// network does not declare one, defaultVersion is set in its
+// configuration, so the plugins always get the spec version.
+func (n *activeNet) setCNIVersion(defaultVersion string) error {
+	if n.conf.CNIVersion != "" {
+		if err := checkCNIVersion(n.conf.CNIVersion); err != nil {
+			return errwrap.Wrap(fmt.Errorf("invalid configuration of network %q", n.conf.Name), err)
+		}
+		return nil
+	}
+
+	var conf map[string]json.RawMessage
+	if err := json.Unmarshal(n.confBytes, &conf); err != nil {
+		return errwrap.Wrap(fmt.Errorf("error parsing the configuration of network %q", n.conf.Name), err)
+	}
+	version, err := json.Marshal(defaultVersion)
+	if err != nil {
+		return err
+	}
+	conf["cniVersion"] = version
+	confBytes, err := json.Marshal(conf)
+	if err != nil {
+		return errwrap.Wrap(fmt.Errorf("error serializing the configuration of network %q", n.conf.Name), err)
+	}
+	n.confBytes = confBytes
+	n.conf.CNIVersion = defaultVersion
+	return nil
+}
@@ -0,0 +1,35 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import "time"
+
+// MetricsSink receives the wall-clock duration of every network plugin
+// invocation, so slow plugins can be spotted.
+type MetricsSink interface {
+	PluginInvoked(plugin, command string, duration time.Duration, err error)
+}
+
+// nopMetricsSink is the default sink, it discards the metrics.
+type nopMetricsSink struct{}
+
+func (nopMetricsSink) PluginInvoked(string, string, time.Duration, error) {}
+
+func (e *podEnv) metricsSink() MetricsSink {
+	if e.metrics == nil {
+		return nopMetricsSink{}
+	}
+	return e.metrics
+}
@@ -17,11 +17,14 @@ package networking
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strings"
+	"time"
 
 	cnitypes "github.com/containernetworking/cni/pkg/types"
 	"github.com/hashicorp/errwrap"
@@ -89,8 +92,16 @@ func (e *podEnv) pluginPaths() []string {
 	}
 }
 
-func (e *podEnv) findNetPlugin(plugin string) string {
-	for _, p := range e.pluginPaths() {
+// netPluginPaths returns the plugin paths for the network, its own
+// plugin directories go first.
+func (e *podEnv) netPluginPaths(n *activeNet) []string {
+	paths := make([]string, 0, len(n.conf.PluginDirs)+3)
+	paths = append(paths, n.conf.PluginDirs...)
+	return append(paths, e.pluginPaths()...)
+}
+
+func (e *podEnv) findNetPlugin(plugin string, paths []string) string {
+	for _, p := range paths {
 		fullname := filepath.Join(p, plugin)
 		if fi, err := os.Stat(fullname); err == nil && fi.Mode().IsRegular() {
 			return fullname
@@ -110,9 +121,68 @@ func envVars(vars [][2]string) []string {
 	return env
 }
 
+// validateLabels checks that the network labels can be passed in CNI_ARGS,
+// which is a list of KEY=VALUE pairs separated by semicolons.
+func validateLabels(labels map[string]string) error {
+	for k, v := range labels {
+		if k == "" {
+			return errors.New("empty label key")
+		}
+		if strings.ContainsAny(k, "=;") {
+			return fmt.Errorf("label key %q contains '=' or ';'", k)
+		}
+		if strings.Contains(v, ";") {
+			return fmt.Errorf("value of label %q contains ';'", k)
+		}
+	}
+	return nil
+}
+
+// cniArgs returns the CNI_ARGS for the network: the labels of the network
+// followed by the runtime args. Labels with the same key as a runtime arg
+// are left out, the runtime args take precedence.
+func cniArgs(n *activeNet) string {
+	runtimeKeys := make(map[string]bool)
+	var args []string
+	if n.runtime.Args != "" {
+		args = strings.Split(n.runtime.Args, ";")
+		for _, arg := range args {
+			runtimeKeys[strings.SplitN(arg, "=", 2)[0]] = true
+		}
+	}
+
+	keys := make([]string, 0, len(n.conf.Labels))
+	for k := range n.conf.Labels {
+		if !runtimeKeys[k] {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	labels := make([]string, 0, len(keys)+len(args))
+	for _, k := range keys {
+		labels = append(labels, k+"="+n.conf.Labels[k])
+	}
+
+	return strings.Join(append(labels, args...), ";")
+}
+
+// execNetPlugin runs the plugin of the network n with the given command
+// and records the invocation and its duration with the pod's auditor
+// and metrics sink.
 func (e *podEnv) execNetPlugin(cmd string, n *activeNet, netns string) ([]byte, error) {
+	start := time.Now()
+	output, err := e.runNetPlugin(cmd, n, netns)
+	duration := time.Since(start)
+	e.audit(cmd, n, netns, output, duration, err)
+	e.metricsSink().PluginInvoked(n.conf.Type, cmd, duration, err)
+	return output, err
+}
+
+func (e *podEnv) runNetPlugin(cmd string, n *activeNet, netns string) ([]byte, error) {
+	paths := e.netPluginPaths(n)
 	if n.runtime.PluginPath == "" {
-		n.runtime.PluginPath = e.findNetPlugin(n.conf.Type)
+		n.runtime.PluginPath = e.findNetPlugin(n.conf.Type, paths)
 	}
 	if n.runtime.PluginPath == "" {
 		return nil, fmt.Errorf("Could not find plugin %q", n.conf.Type)
@@ -123,9 +193,9 @@ func (e *podEnv) execNetPlugin(cmd string, n *activeNet, netns string) ([]byte,
 		{"CNI_COMMAND", cmd},
 		{"CNI_CONTAINERID", e.podID.String()},
 		{"CNI_NETNS", netns},
-		{"CNI_ARGS", n.runtime.Args},
+		{"CNI_ARGS", cniArgs(n)},
 		{"CNI_IFNAME", n.runtime.IfName},
-		{"CNI_PATH", strings.Join(e.pluginPaths(), ":")},
+		{"CNI_PATH", strings.Join(paths, ":")},
 	}
 
 	stdin := bytes.NewBuffer(n.confBytes)
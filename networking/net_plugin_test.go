@@ -0,0 +1,178 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/appc/spec/schema/types"
+	"github.com/rkt/rkt/networking/netinfo"
+	"github.com/rkt/rkt/pkg/log"
+)
+
+func TestNetPluginDirs(t *testing.T) {
+	stderr = log.New(ioutil.Discard, "networking", false)
+
+	dir, err := ioutil.TempDir("", "rkt-networking-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	localConfig := filepath.Join(dir, "local")
+	customDir := filepath.Join(dir, "custom")
+	// the stub plugin prints where it lives and the CNI_PATH
+	for _, d := range []string{filepath.Join(localConfig, UserNetPathSuffix), customDir} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatalf("failed to create %v: %v", d, err)
+		}
+		plugin := fmt.Sprintf("#!/bin/sh\necho \"%s ${CNI_PATH}\"\n", d)
+		if err := ioutil.WriteFile(filepath.Join(d, "stub"), []byte(plugin), 0755); err != nil {
+			t.Fatalf("failed to write the stub plugin: %v", err)
+		}
+	}
+
+	podID, err := types.NewUUID(testPodUUID)
+	if err != nil {
+		t.Fatalf("failed to parse the pod UUID: %v", err)
+	}
+	e := &podEnv{
+		podRoot:     filepath.Join(dir, "pod"),
+		podID:       *podID,
+		localConfig: localConfig,
+	}
+	defaultPaths := strings.Join(e.pluginPaths(), ":")
+
+	tests := []struct {
+		conf     NetConf
+		expected string
+	}{
+		{
+			NetConf{},
+			filepath.Join(localConfig, UserNetPathSuffix) + " " + defaultPaths,
+		},
+		{
+			NetConf{PluginDirs: []string{customDir}},
+			customDir + " " + customDir + ":" + defaultPaths,
+		},
+	}
+	for i, tt := range tests {
+		tt.conf.Type = "stub"
+		n := &activeNet{
+			conf:    &tt.conf,
+			runtime: &netinfo.NetInfo{},
+		}
+		output, err := e.execNetPlugin("DEL", n, "")
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if result := strings.TrimSpace(string(output)); result != tt.expected {
+			t.Errorf("#%d: expected %q, got %q", i, tt.expected, result)
+		}
+		if expectedPlugin := filepath.Join(strings.Fields(tt.expected)[0], "stub"); n.runtime.PluginPath != expectedPlugin {
+			t.Errorf("#%d: expected the plugin %q, got %q", i, expectedPlugin, n.runtime.PluginPath)
+		}
+	}
+}
+
+func TestNetPluginLabels(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rkt-networking-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the stub plugin prints the CNI_ARGS
+	plugin := "#!/bin/sh\necho \"${CNI_ARGS}\"\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "stub"), []byte(plugin), 0755); err != nil {
+		t.Fatalf("failed to write the stub plugin: %v", err)
+	}
+
+	podID, err := types.NewUUID(testPodUUID)
+	if err != nil {
+		t.Fatalf("failed to parse the pod UUID: %v", err)
+	}
+	e := &podEnv{
+		podRoot: filepath.Join(dir, "pod"),
+		podID:   *podID,
+	}
+
+	tests := []struct {
+		labels   map[string]string
+		args     string
+		expected string
+	}{
+		{
+			nil,
+			"IP=10.1.2.3",
+			"IP=10.1.2.3",
+		},
+		{
+			map[string]string{"k8s.pod.namespace": "default", "env": "prod"},
+			"",
+			"env=prod;k8s.pod.namespace=default",
+		},
+		{
+			map[string]string{"k8s.pod.namespace": "default", "IP": "10.9.9.9"},
+			"IP=10.1.2.3",
+			"k8s.pod.namespace=default;IP=10.1.2.3",
+		},
+	}
+	for i, tt := range tests {
+		conf := NetConf{PluginDirs: []string{dir}, Labels: tt.labels}
+		conf.Type = "stub"
+		n := &activeNet{
+			conf:    &conf,
+			runtime: &netinfo.NetInfo{Args: tt.args},
+		}
+		output, err := e.execNetPlugin("ADD", n, "")
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if result := strings.TrimSpace(string(output)); result != tt.expected {
+			t.Errorf("#%d: expected %q, got %q", i, tt.expected, result)
+		}
+	}
+}
+
+func TestValidateLabels(t *testing.T) {
+	tests := []struct {
+		labels map[string]string
+		valid  bool
+	}{
+		{nil, true},
+		{map[string]string{"k8s.pod.namespace": "default", "empty": ""}, true},
+		{map[string]string{"": "value"}, false},
+		{map[string]string{"a=b": "value"}, false},
+		{map[string]string{"a;b": "value"}, false},
+		{map[string]string{"key": "a;b"}, false},
+	}
+	for i, tt := range tests {
+		err := validateLabels(tt.labels)
+		if tt.valid && err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		}
+		if !tt.valid && err == nil {
+			t.Errorf("#%d: expected an error", i)
+		}
+	}
+}
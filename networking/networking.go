@@ -21,6 +21,7 @@ import (
 	"io/ioutil"
 	"net"
 	"os"
+	"path/filepath"
 	"strings"
 	"syscall"
 
@@ -39,8 +40,17 @@ const (
 	IfNamePattern       = "eth%d"
 	selfNetNS           = "/proc/self/ns/net"
 	mountNetnsDirectory = "/var/run/netns"
+
+	// flavorFly is the stage1 flavor whose pods share the host
+	// network namespace
+	flavorFly = "fly"
 )
 
+// ErrNotApplicable is returned when loading the networks of a pod whose
+// stage1 flavor shares the host network namespace, hence there are no
+// networks to set up.
+var ErrNotApplicable = errors.New("networking is not applicable to the fly flavor")
+
 // Networking describes the networking details of a pod.
 type Networking struct {
 	podEnv
@@ -55,6 +65,18 @@ type NetConf struct {
 	IPMasq           bool `json:"ipMasq"`
 	MTU              int  `json:"mtu"`
 	IsDefaultGateway bool `json:"isDefaultGateway"`
+	// PluginDirs are searched for the network plugins before the
+	// default plugin paths
+	PluginDirs []string `json:"pluginDirs"`
+	// CNIVersion is the CNI spec version of the network
+	// configuration
+	CNIVersion string `json:"cniVersion"`
+	// Labels are passed to the network plugins in CNI_ARGS
+	Labels map[string]string `json:"labels"`
+	// TeardownPriority orders the teardown of the networks: the
+	// ones with a higher priority are torn down first, the ones
+	// with the same priority in reverse setup order
+	TeardownPriority int `json:"teardownPriority"`
 }
 
 var (
@@ -81,9 +103,15 @@ func Setup(podRoot string, podID types.UUID, fps []commonnet.ForwardedPort, netL
 			podID:        podID,
 			netsLoadList: netList,
 			localConfig:  localConfig,
+			flavor:       flavor,
 		},
 	}
 
+	if flavor == flavorFly {
+		stderr.Printf("the %s flavor uses the host network, not setting up networking", flavor)
+		return &n, nil
+	}
+
 	err := n.mountNetnsDirectory()
 	if err != nil {
 		return nil, err
@@ -229,6 +257,56 @@ func Load(podRoot string, podID *types.UUID, localConfig string) (*Networking, e
 	}, nil
 }
 
+// ActiveNet is a network a pod is on, as recorded in the pod directory when
+// the network was set up.
+type ActiveNet struct {
+	// Conf is the configuration of the network, read from the copy
+	// saved in the pod directory.
+	Conf NetConf
+	// Runtime is the runtime information returned by the plugin.
+	Runtime netinfo.NetInfo
+}
+
+// LoadActiveNets returns the networks of the pod in podRoot, from the
+// network configurations and runtime information saved in the pod
+// directory. Unlike Load, it does not need the pod's network namespace nor
+// the host's network configuration. Pods without networks of their own,
+// like the ones run with --net=host or --net=none, have no active networks.
+func LoadActiveNets(podRoot string) ([]ActiveNet, error) {
+	pdirfd, err := syscall.Open(podRoot, syscall.O_RDONLY|syscall.O_DIRECTORY, 0)
+	if err != nil {
+		return nil, errwrap.Wrap(fmt.Errorf("failed to open pod root directory (%v)", podRoot), err)
+	}
+	defer syscall.Close(pdirfd)
+
+	nis, err := netinfo.LoadAt(pdirfd)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errwrap.Wrap(errors.New("failed to load the pod's network information"), err)
+	}
+
+	var nets []ActiveNet
+	for _, ni := range nis {
+		// the configuration path is relative to the pod root when
+		// the networks are set up by stage1
+		confPath := ni.ConfPath
+		if !filepath.IsAbs(confPath) {
+			confPath = filepath.Join(podRoot, confPath)
+		}
+		n, err := loadNet(confPath)
+		if err != nil {
+			return nil, errwrap.Wrap(fmt.Errorf("failed to load the configuration of network %q", ni.NetName), err)
+		}
+		nets = append(nets, ActiveNet{
+			Conf:    *n.conf,
+			Runtime: ni,
+		})
+	}
+
+	return nets, nil
+}
+
 // GetIfacesByIP searches for and returns the interfaces with the given IP
 // Disregards the subnet mask since not every net.IP object contains
 // On success it will return the list of found interfaces
@@ -281,6 +359,10 @@ func (n *Networking) Teardown(flavor string, debug bool) {
 		return
 	}
 
+	if flavor == flavorFly {
+		return
+	}
+
 	if err := n.teardownForwarding(); err != nil {
 		stderr.PrintE("error removing forwarded ports", err)
 	}
@@ -327,6 +409,42 @@ func CleanUpGarbage(podRoot string, podID *types.UUID) error {
 	return p.podNSDestroy()
 }
 
+// TeardownPodNetworks tears down the networks of the pod in podRoot
+// without going through the stage1, e.g. when a garbage collector
+// finds a leaked pod. The pod UUID is taken from the name of podRoot.
+// The networks are read from the saved net-info, or, if it is
+// missing, from the configuration files in the pod's net directory;
+// the interface names are then guessed from the order of the files.
+func TeardownPodNetworks(podRoot, localConfig string) error {
+	stderr = log.New(os.Stderr, "networking", debuglog)
+
+	podID, err := types.NewUUID(filepath.Base(podRoot))
+	if err != nil {
+		return errwrap.Wrap(fmt.Errorf("failed to get the pod UUID from %q", podRoot), err)
+	}
+
+	n, err := Load(podRoot, podID, localConfig)
+	if err != nil {
+		stderr.PrintE("error loading the pod networking, using the saved network configurations", err)
+		p := podEnv{
+			podRoot:     podRoot,
+			podID:       *podID,
+			localConfig: localConfig,
+		}
+		nets, err := p.loadSavedNets()
+		if err != nil {
+			return err
+		}
+		n = &Networking{
+			podEnv: p,
+			nets:   nets,
+		}
+	}
+
+	n.Teardown("", debuglog)
+	return nil
+}
+
 func loUp() error {
 	lo, err := netlink.LinkByName("lo")
 	if err != nil {
@@ -0,0 +1,157 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rkt/rkt/networking/netinfo"
+)
+
+const testPodUUID = "6d0d9608-a2d0-4b2a-bc35-1e3a2fd2ba1e"
+
+// setupStubNetwork creates a pod directory with a stub network as if
+// it was set up by rkt, a local config directory with the stub
+// plugin, and returns the paths to them and to the file where the
+// plugin records its invocations.
+func setupStubNetwork(t *testing.T, dir string, saveNetInfo bool) (string, string, string) {
+	podRoot := filepath.Join(dir, "pods", testPodUUID)
+	localConfig := filepath.Join(dir, "local")
+	record := filepath.Join(dir, "record")
+	for _, d := range []string{filepath.Join(podRoot, "net"), filepath.Join(localConfig, UserNetPathSuffix)} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatalf("failed to create %v: %v", d, err)
+		}
+	}
+
+	plugin := fmt.Sprintf("#!/bin/sh\necho \"${CNI_COMMAND} ${CNI_IFNAME} ${CNI_CONTAINERID}\" >>%s\n", record)
+	if err := ioutil.WriteFile(filepath.Join(localConfig, UserNetPathSuffix, "stub"), []byte(plugin), 0755); err != nil {
+		t.Fatalf("failed to write the stub plugin: %v", err)
+	}
+	confPath := filepath.Join(podRoot, "net", "10-stub.conf")
+	if err := ioutil.WriteFile(confPath, []byte(`{"name": "stub", "type": "stub"}`), 0644); err != nil {
+		t.Fatalf("failed to write the network configuration: %v", err)
+	}
+	if saveNetInfo {
+		nis := []netinfo.NetInfo{
+			{
+				NetName:  "stub",
+				ConfPath: confPath,
+				IfName:   "eth0",
+			},
+		}
+		if err := netinfo.Save(podRoot, nis); err != nil {
+			t.Fatalf("failed to save the net info: %v", err)
+		}
+	}
+	return podRoot, localConfig, record
+}
+
+func TestTeardownPodNetworks(t *testing.T) {
+	for _, saveNetInfo := range []bool{true, false} {
+		dir, err := ioutil.TempDir("", "rkt-networking-test")
+		if err != nil {
+			t.Fatalf("failed to create temporary directory: %v", err)
+		}
+		defer os.RemoveAll(dir)
+
+		podRoot, localConfig, record := setupStubNetwork(t, dir, saveNetInfo)
+		if err := TeardownPodNetworks(podRoot, localConfig); err != nil {
+			t.Fatalf("unexpected error (net info saved: %v): %v", saveNetInfo, err)
+		}
+
+		invocations, err := ioutil.ReadFile(record)
+		if err != nil {
+			t.Fatalf("the stub plugin was not run (net info saved: %v): %v", saveNetInfo, err)
+		}
+		expected := fmt.Sprintf("DEL eth0 %s", testPodUUID)
+		if strings.TrimSpace(string(invocations)) != expected {
+			t.Errorf("expected the stub plugin to be invoked with %q, got %q", expected, invocations)
+		}
+		if _, err := os.Stat(filepath.Join(podRoot, "net", "10-stub.conf")); !os.IsNotExist(err) {
+			t.Errorf("expected the network configuration to be removed after teardown, got %v", err)
+		}
+	}
+}
+
+func TestTeardownPodNetworksInvalidPodRoot(t *testing.T) {
+	if err := TeardownPodNetworks("/tmp/not-a-pod", "/tmp/local"); err == nil {
+		t.Errorf("expected an error for a pod root without a UUID")
+	}
+}
+
+func TestLoadActiveNets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rkt-networking-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	podRoot, _, _ := setupStubNetwork(t, dir, true)
+	nets, err := LoadActiveNets(podRoot)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nets) != 1 {
+		t.Fatalf("expected one active network, got %d", len(nets))
+	}
+	if nets[0].Conf.Name != "stub" || nets[0].Conf.Type != "stub" {
+		t.Errorf("unexpected network configuration %+v", nets[0].Conf)
+	}
+	if nets[0].Runtime.NetName != "stub" || nets[0].Runtime.IfName != "eth0" {
+		t.Errorf("unexpected network runtime information %+v", nets[0].Runtime)
+	}
+
+	// stage1 saves configuration paths relative to the pod root
+	nis := []netinfo.NetInfo{
+		{
+			NetName:  "stub",
+			ConfPath: filepath.Join("net", "10-stub.conf"),
+			IfName:   "eth0",
+		},
+	}
+	if err := netinfo.Save(podRoot, nis); err != nil {
+		t.Fatalf("failed to save the net info: %v", err)
+	}
+	nets, err = LoadActiveNets(podRoot)
+	if err != nil {
+		t.Fatalf("unexpected error with a relative configuration path: %v", err)
+	}
+	if len(nets) != 1 || nets[0].Conf.Name != "stub" {
+		t.Errorf("unexpected networks with a relative configuration path: %+v", nets)
+	}
+}
+
+func TestLoadActiveNetsNoNetworks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rkt-networking-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	podRoot, _, _ := setupStubNetwork(t, dir, false)
+	nets, err := LoadActiveNets(podRoot)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(nets) != 0 {
+		t.Errorf("expected no active networks, got %+v", nets)
+	}
+}
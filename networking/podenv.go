@@ -16,6 +16,7 @@ package networking
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -40,8 +41,19 @@ const (
 
 	// Default net path relative to stage1 root
 	BuiltinNetPath = "etc/rkt/" + UserNetPathSuffix
+
+	// NetConfOverrideDir is the directory, relative to the pod root,
+	// holding the configurations overriding the ones of the networks
+	// for a single run, in files named after the networks
+	NetConfOverrideDir = "net-conf-override"
 )
 
+// NetnsProvider provides the path of the network namespace which is passed
+// to the network plugins of a pod in CNI_NETNS.
+type NetnsProvider interface {
+	NetnsPath() (string, error)
+}
+
 // "base" struct that's populated from the beginning
 // describing the environment in which the pod
 // is running in
@@ -51,6 +63,18 @@ type podEnv struct {
 	netsLoadList common.NetList
 	localConfig  string
 	podNS        ns.NetNS
+	// netnsProvider overrides the network namespace passed to the
+	// plugins, podNS is used if it is nil
+	netnsProvider NetnsProvider
+	// auditor records the plugin invocations, they are appended to
+	// the audit log in the pod directory if it is nil
+	auditor InvocationAuditor
+	// metrics receives the duration of the plugin invocations, they
+	// are discarded if it is nil
+	metrics MetricsSink
+	// flavor is the stage1 flavor of the pod, there is no networking
+	// to set up for the fly flavor
+	flavor string
 }
 
 type activeNet struct {
@@ -73,6 +97,10 @@ func (s byFilename) Less(i, j int) bool {
 // configs override what is built into stage1.
 // The order in which networks are applied to pods will be defined by their filenames.
 func (e *podEnv) loadNets() ([]activeNet, error) {
+	if e.flavor == flavorFly {
+		return nil, ErrNotApplicable
+	}
+
 	if e.netsLoadList.None() {
 		stderr.Printf("networking namespace with loopback only")
 		return nil, nil
@@ -82,12 +110,25 @@ func (e *podEnv) loadNets() ([]activeNet, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := e.overrideNetConfs(nets); err != nil {
+		return nil, err
+	}
 	netSlice := make([]activeNet, 0, len(nets))
 	for _, net := range nets {
 		netSlice = append(netSlice, net)
 	}
 	sort.Sort(byFilename(netSlice))
 
+	defaultVersion, err := defaultCNIVersion()
+	if err != nil {
+		return nil, err
+	}
+	for i := range netSlice {
+		if err := netSlice[i].setCNIVersion(defaultVersion); err != nil {
+			return nil, err
+		}
+	}
+
 	missing := missingNets(e.netsLoadList, netSlice)
 	if len(missing) > 0 {
 		return nil, fmt.Errorf("networks not found: %v", strings.Join(missing, ", "))
@@ -233,11 +274,28 @@ func (e *podEnv) podNSDestroy() error {
 	return nil
 }
 
+// netnsPath returns the path of the network namespace to pass to the
+// plugins: the one from the netns provider if set, otherwise the path of
+// podNS, which is created by or loaded from the pod's netns file.
+func (e *podEnv) netnsPath() (string, error) {
+	if e.netnsProvider != nil {
+		return e.netnsProvider.NetnsPath()
+	}
+	if e.podNS == nil {
+		return "", nil
+	}
+	return e.podNS.Path(), nil
+}
+
 func (e *podEnv) netDir() string {
 	return filepath.Join(e.podRoot, "net")
 }
 
 func (e *podEnv) setupNets(nets []activeNet, noDNS bool) error {
+	if e.flavor == flavorFly {
+		return nil
+	}
+
 	err := os.MkdirAll(e.netDir(), 0755)
 	if err != nil {
 		return err
@@ -260,18 +318,23 @@ func (e *podEnv) setupNets(nets []activeNet, noDNS bool) error {
 	}
 	podHasResolvConf := err == nil
 
+	podNSPath, err := e.netnsPath()
+	if err != nil {
+		return errwrap.Wrap(errors.New("error getting the pod's network namespace"), err)
+	}
+
 	for i, n = range nets {
 		if debuglog {
 			stderr.Printf("loading network %v with type %v", n.conf.Name, n.conf.Type)
 		}
 
 		n.runtime.IfName = fmt.Sprintf(IfNamePattern, i)
-		if n.runtime.ConfPath, err = copyFileToDir(n.runtime.ConfPath, e.netDir()); err != nil {
+		if n.runtime.ConfPath, err = saveNetConfToDir(&n, e.netDir()); err != nil {
 			return errwrap.Wrap(fmt.Errorf("error copying %q to %q", n.runtime.ConfPath, e.netDir()), err)
 		}
 
 		// Actually shell out to the plugin
-		err = e.netPluginAdd(&n, e.podNS.Path())
+		err = e.netPluginAdd(&n, podNSPath)
 		if err != nil {
 			return errwrap.Wrap(fmt.Errorf("error adding network %q", n.conf.Name), err)
 		}
@@ -294,21 +357,29 @@ func (e *podEnv) setupNets(nets []activeNet, noDNS bool) error {
 			}
 		}
 	}
-	return nil
+
+	// all the networks are set up, tear them all down on conflicts
+	i = len(nets)
+	err = checkSubnetConflicts(nets)
+	return err
 }
 
 func (e *podEnv) teardownNets(nets []activeNet) {
-	for i := len(nets) - 1; i >= 0; i-- {
+	if e.flavor == flavorFly {
+		return
+	}
+
+	for _, i := range teardownOrder(nets) {
 		if debuglog {
 			stderr.Printf("teardown - executing net-plugin %v", nets[i].conf.Type)
 		}
 
-		podNSpath := ""
-		if e.podNS != nil {
-			podNSpath = e.podNS.Path()
+		podNSPath, err := e.netnsPath()
+		if err != nil {
+			stderr.PrintE("error getting the pod's network namespace", err)
 		}
 
-		err := e.netPluginDel(&nets[i], podNSpath)
+		err = e.netPluginDel(&nets[i], podNSPath)
 		if err != nil {
 			stderr.PrintE(fmt.Sprintf("error deleting %q", nets[i].conf.Name), err)
 		}
@@ -321,6 +392,57 @@ func (e *podEnv) teardownNets(nets []activeNet) {
 	}
 }
 
+// byTeardownPriority sorts network indexes by decreasing teardown
+// priority of the networks.
+type byTeardownPriority struct {
+	nets    []activeNet
+	indexes []int
+}
+
+func (s byTeardownPriority) Len() int      { return len(s.indexes) }
+func (s byTeardownPriority) Swap(i, j int) { s.indexes[i], s.indexes[j] = s.indexes[j], s.indexes[i] }
+func (s byTeardownPriority) Less(i, j int) bool {
+	return s.nets[s.indexes[i]].conf.TeardownPriority > s.nets[s.indexes[j]].conf.TeardownPriority
+}
+
+// teardownOrder returns the indexes of the networks in the order they
+// should be torn down: by decreasing teardown priority, and in reverse
+// setup order for the networks with the same priority.
+func teardownOrder(nets []activeNet) []int {
+	indexes := make([]int, 0, len(nets))
+	for i := len(nets) - 1; i >= 0; i-- {
+		indexes = append(indexes, i)
+	}
+	sort.Stable(byTeardownPriority{nets: nets, indexes: indexes})
+	return indexes
+}
+
+// loadSavedNets loads the networks from the configuration files
+// saved in the pod's net directory by setupNets, in the order they
+// were set up.
+func (e *podEnv) loadSavedNets() ([]activeNet, error) {
+	files, err := listFiles(e.netDir())
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	var nets []activeNet
+	for _, filename := range files {
+		if !strings.HasSuffix(filename, ".conf") {
+			continue
+		}
+		n, err := loadNet(filepath.Join(e.netDir(), filename))
+		if err != nil {
+			stderr.PrintE(fmt.Sprintf("error loading %q; ignoring", filename), err)
+			continue
+		}
+		n.runtime.IfName = fmt.Sprintf(IfNamePattern, len(nets))
+		nets = append(nets, *n)
+	}
+	return nets, nil
+}
+
 // netLoader loads network definitions hierarchically. Child loaders can override definitions from parents.
 type netLoader struct {
 	parent     *netLoader
@@ -440,10 +562,26 @@ func loadNet(filepath string) (*activeNet, error) {
 		return nil, err
 	}
 
+	rn := &remoteNetConf{}
+	if err = json.Unmarshal(bytes, rn); err != nil {
+		return nil, errwrap.Wrap(fmt.Errorf("error loading %v", filepath), err)
+	}
+	if rn.CNIConfURL != "" {
+		if bytes, err = fetchRemoteNetConf(filepath, rn); err != nil {
+			return nil, err
+		}
+	}
+
 	n := &NetConf{}
 	if err = json.Unmarshal(bytes, n); err != nil {
 		return nil, errwrap.Wrap(fmt.Errorf("error loading %v", filepath), err)
 	}
+	if rn.CNIConfURL != "" && rn.Name != "" && rn.Name != n.Name {
+		return nil, fmt.Errorf("network configuration from %q is named %q, expected %q", rn.CNIConfURL, n.Name, rn.Name)
+	}
+	if err := validateLabels(n.Labels); err != nil {
+		return nil, errwrap.Wrap(fmt.Errorf("invalid labels in %v", filepath), err)
+	}
 
 	return &activeNet{
 		confBytes: bytes,
@@ -455,21 +593,65 @@ func loadNet(filepath string) (*activeNet, error) {
 	}, nil
 }
 
-func copyFileToDir(src, dstdir string) (string, error) {
-	dst := filepath.Join(dstdir, filepath.Base(src))
-
-	s, err := os.Open(src)
-	if err != nil {
-		return "", err
+// ParseNetConfOverride parses the configuration overriding the one of
+// the named network, and checks it is a valid configuration for it.
+func ParseNetConfOverride(name string, confBytes []byte) (*NetConf, error) {
+	n := &NetConf{}
+	if err := json.Unmarshal(confBytes, n); err != nil {
+		return nil, errwrap.Wrap(fmt.Errorf("error parsing the configuration overriding network %q", name), err)
+	}
+	if n.Name != name {
+		return nil, fmt.Errorf("configuration overriding network %q is named %q", name, n.Name)
 	}
-	defer s.Close()
+	if n.Type == "" {
+		return nil, fmt.Errorf("configuration overriding network %q has no type", name)
+	}
+	if err := validateLabels(n.Labels); err != nil {
+		return nil, errwrap.Wrap(fmt.Errorf("invalid labels in the configuration overriding network %q", name), err)
+	}
+	return n, nil
+}
 
-	d, err := os.Create(dst)
+// overrideNetConfs substitutes the configurations of the loaded
+// networks with the ones in the pod's override directory, if any. The
+// overridden networks keep the place given by their configuration
+// file in the setup order.
+func (e *podEnv) overrideNetConfs(nets map[string]activeNet) error {
+	dir := filepath.Join(e.podRoot, NetConfOverrideDir)
+	files, err := listFiles(dir)
 	if err != nil {
-		return "", err
+		return err
 	}
-	defer d.Close()
+	for _, filename := range files {
+		if !strings.HasSuffix(filename, ".conf") {
+			continue
+		}
+		name := strings.TrimSuffix(filename, ".conf")
+		n, ok := nets[name]
+		if !ok {
+			return fmt.Errorf("overridden network %q is not loaded", name)
+		}
+		confBytes, err := ioutil.ReadFile(filepath.Join(dir, filename))
+		if err != nil {
+			return err
+		}
+		conf, err := ParseNetConfOverride(name, confBytes)
+		if err != nil {
+			return err
+		}
+		stderr.Printf("overriding the configuration of network %q for this run", name)
+		n.confBytes = confBytes
+		n.conf = conf
+		nets[name] = n
+	}
+	return nil
+}
 
-	_, err = io.Copy(d, s)
-	return dst, err
+// saveNetConfToDir writes the configuration of the network to a file
+// with the same name in dstdir. The loaded configuration is written
+// instead of copying the file, so the configurations fetched from a
+// URL are not fetched again when loading the saved networks.
+func saveNetConfToDir(n *activeNet, dstdir string) (string, error) {
+	dst := filepath.Join(dstdir, filepath.Base(n.runtime.ConfPath))
+	return dst, ioutil.WriteFile(dst, n.confBytes, 0644)
 }
@@ -0,0 +1,646 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/appc/spec/schema/types"
+	"github.com/rkt/rkt/common"
+	"github.com/rkt/rkt/networking/netinfo"
+	"github.com/rkt/rkt/pkg/log"
+)
+
+const testCNIConf = `{"name": "remote", "type": "bridge", "ipMasq": true}`
+
+func writeRemoteNetConf(t *testing.T, dir, contents string) string {
+	path := filepath.Join(dir, "10-remote.conf")
+	if err := ioutil.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("failed to write %v: %v", path, err)
+	}
+	return path
+}
+
+func TestLoadNetFromURL(t *testing.T) {
+	stderr = log.New(ioutil.Discard, "networking", false)
+
+	dir, err := ioutil.TempDir("", "rkt-networking-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	available := true
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(testCNIConf))
+	}))
+	defer ts.Close()
+
+	path := writeRemoteNetConf(t, dir, fmt.Sprintf(`{"name": "remote", "cniConfUrl": %q, "useCacheOnFailure": true}`, ts.URL))
+	n, err := loadNet(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n.conf.Name != "remote" || n.conf.Type != "bridge" || !n.conf.IPMasq {
+		t.Errorf("unexpected network configuration: %+v", n.conf)
+	}
+	if n.runtime.ConfPath != path {
+		t.Errorf("expected the configuration path to be %v, got %v", path, n.runtime.ConfPath)
+	}
+	cached, err := ioutil.ReadFile(path + remoteNetConfCacheSuffix)
+	if err != nil {
+		t.Fatalf("expected the configuration to be cached: %v", err)
+	}
+	if string(cached) != testCNIConf {
+		t.Errorf("unexpected cached configuration: %s", cached)
+	}
+
+	// the server is down, the cached copy is used
+	available = false
+	if n, err = loadNet(path); err != nil {
+		t.Fatalf("unexpected error with a cached copy: %v", err)
+	}
+	if n.conf.Name != "remote" {
+		t.Errorf("unexpected network configuration: %+v", n.conf)
+	}
+
+	// the server is down and the cached copy is not allowed
+	path = writeRemoteNetConf(t, dir, fmt.Sprintf(`{"name": "remote", "cniConfUrl": %q}`, ts.URL))
+	if _, err := loadNet(path); err == nil {
+		t.Errorf("expected an error when the server is down")
+	}
+
+	// the server is down and there is no cached copy
+	if err := os.Remove(path + remoteNetConfCacheSuffix); err != nil {
+		t.Fatalf("failed to remove the cached copy: %v", err)
+	}
+	path = writeRemoteNetConf(t, dir, fmt.Sprintf(`{"name": "remote", "cniConfUrl": %q, "useCacheOnFailure": true}`, ts.URL))
+	if _, err := loadNet(path); err == nil {
+		t.Errorf("expected an error without a cached copy")
+	}
+
+	// the fetched configuration has a different name
+	available = true
+	path = writeRemoteNetConf(t, dir, fmt.Sprintf(`{"name": "other", "cniConfUrl": %q}`, ts.URL))
+	if _, err := loadNet(path); err == nil {
+		t.Errorf("expected an error for a mismatched network name")
+	}
+}
+
+func TestSetCNIVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rkt-networking-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		conf           string
+		defaultVersion string
+		version        string
+		fail           bool
+	}{
+		// supported
+		{`{"name": "supported", "type": "bridge", "cniVersion": "0.2.0"}`, "0.1.0", "0.2.0", false},
+		// unsupported
+		{`{"name": "unsupported", "type": "bridge", "cniVersion": "0.3.0"}`, "0.1.0", "", true},
+		// omitted, the default is used
+		{`{"name": "omitted", "type": "bridge", "mtu": 1400}`, "0.1.0", "0.1.0", false},
+		{`{"name": "omitted", "type": "bridge", "mtu": 1400}`, "0.2.0", "0.2.0", false},
+	}
+	for i, tt := range tests {
+		path := filepath.Join(dir, fmt.Sprintf("%d.conf", i))
+		if err := ioutil.WriteFile(path, []byte(tt.conf), 0644); err != nil {
+			t.Fatalf("failed to write %v: %v", path, err)
+		}
+		n, err := loadNet(path)
+		if err != nil {
+			t.Fatalf("#%d: unexpected error loading the network: %v", i, err)
+		}
+		err = n.setCNIVersion(tt.defaultVersion)
+		if tt.fail {
+			if err == nil {
+				t.Errorf("#%d: expected an error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if n.conf.CNIVersion != tt.version {
+			t.Errorf("#%d: expected CNI version %q, got %q", i, tt.version, n.conf.CNIVersion)
+		}
+		// the plugins get the version in their configuration
+		var conf NetConf
+		if err := json.Unmarshal(n.confBytes, &conf); err != nil {
+			t.Fatalf("#%d: unexpected error parsing the plugin configuration: %v", i, err)
+		}
+		if conf.CNIVersion != tt.version || conf.Name != n.conf.Name || conf.MTU != n.conf.MTU {
+			t.Errorf("#%d: unexpected plugin configuration: %s", i, n.confBytes)
+		}
+	}
+}
+
+func TestDefaultCNIVersion(t *testing.T) {
+	defer os.Unsetenv(common.EnvDefaultCNIVersion)
+
+	tests := []struct {
+		env     string
+		version string
+		fail    bool
+	}{
+		{"", builtinCNIVersion, false},
+		{"0.2.0", "0.2.0", false},
+		{"0.3.0", "", true},
+	}
+	for _, tt := range tests {
+		os.Setenv(common.EnvDefaultCNIVersion, tt.env)
+		version, err := defaultCNIVersion()
+		if tt.fail {
+			if err == nil {
+				t.Errorf("expected an error for %q", tt.env)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("unexpected error for %q: %v", tt.env, err)
+		} else if version != tt.version {
+			t.Errorf("expected version %q for %q, got %q", tt.version, tt.env, version)
+		}
+	}
+}
+
+type fakeNetnsProvider string
+
+func (p fakeNetnsProvider) NetnsPath() (string, error) {
+	return string(p), nil
+}
+
+func TestNetnsProvider(t *testing.T) {
+	stderr = log.New(ioutil.Discard, "networking", false)
+
+	dir, err := ioutil.TempDir("", "rkt-networking-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the stub plugin records the command and the CNI_NETNS it got
+	record := filepath.Join(dir, "record")
+	plugin := fmt.Sprintf("#!/bin/sh\necho \"${CNI_COMMAND} ${CNI_NETNS}\" >>%s\necho '{}'\n", record)
+	if err := ioutil.WriteFile(filepath.Join(dir, "stub"), []byte(plugin), 0755); err != nil {
+		t.Fatalf("failed to write the stub plugin: %v", err)
+	}
+
+	podID, err := types.NewUUID(testPodUUID)
+	if err != nil {
+		t.Fatalf("failed to parse the pod UUID: %v", err)
+	}
+	e := &podEnv{
+		podRoot:       filepath.Join(dir, "pod"),
+		podID:         *podID,
+		netnsProvider: fakeNetnsProvider("/run/fake/netns"),
+	}
+
+	conf := &NetConf{PluginDirs: []string{dir}}
+	conf.Name = "fake"
+	conf.Type = "stub"
+	nets := []activeNet{{
+		confBytes: []byte(`{"name": "fake", "type": "stub"}`),
+		conf:      conf,
+		runtime: &netinfo.NetInfo{
+			NetName:  conf.Name,
+			ConfPath: filepath.Join(dir, "10-fake.conf"),
+		},
+	}}
+
+	if err := e.setupNets(nets, false); err != nil {
+		t.Fatalf("unexpected error setting up the networks: %v", err)
+	}
+	e.teardownNets(nets)
+
+	output, err := ioutil.ReadFile(record)
+	if err != nil {
+		t.Fatalf("failed to read what the stub plugin recorded: %v", err)
+	}
+	if expected := "ADD /run/fake/netns\nDEL /run/fake/netns\n"; string(output) != expected {
+		t.Errorf("expected the plugin to be called with %q, got %q", expected, output)
+	}
+}
+
+func TestTeardownPriority(t *testing.T) {
+	stderr = log.New(ioutil.Discard, "networking", false)
+
+	dir, err := ioutil.TempDir("", "rkt-networking-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the stub plugin records the command and the interface it got
+	record := filepath.Join(dir, "record")
+	plugin := fmt.Sprintf("#!/bin/sh\necho \"${CNI_COMMAND} ${CNI_IFNAME}\" >>%s\necho '{}'\n", record)
+	if err := ioutil.WriteFile(filepath.Join(dir, "stub"), []byte(plugin), 0755); err != nil {
+		t.Fatalf("failed to write the stub plugin: %v", err)
+	}
+
+	podID, err := types.NewUUID(testPodUUID)
+	if err != nil {
+		t.Fatalf("failed to parse the pod UUID: %v", err)
+	}
+	e := &podEnv{
+		podRoot:       filepath.Join(dir, "pod"),
+		podID:         *podID,
+		netnsProvider: fakeNetnsProvider("/run/fake/netns"),
+	}
+
+	// ipam is set up first but torn down first too, and l2 is torn
+	// down last, after default which has the default priority
+	var nets []activeNet
+	for _, n := range []struct {
+		name     string
+		priority int
+	}{
+		{"ipam", 10},
+		{"l2", -1},
+		{"default", 0},
+	} {
+		conf := &NetConf{PluginDirs: []string{dir}, TeardownPriority: n.priority}
+		conf.Name = n.name
+		conf.Type = "stub"
+		nets = append(nets, activeNet{
+			confBytes: []byte(fmt.Sprintf(`{"name": %q, "type": "stub", "teardownPriority": %d}`, n.name, n.priority)),
+			conf:      conf,
+			runtime: &netinfo.NetInfo{
+				NetName:  conf.Name,
+				ConfPath: filepath.Join(dir, n.name+".conf"),
+			},
+		})
+	}
+
+	if err := e.setupNets(nets, false); err != nil {
+		t.Fatalf("unexpected error setting up the networks: %v", err)
+	}
+	e.teardownNets(nets)
+
+	output, err := ioutil.ReadFile(record)
+	if err != nil {
+		t.Fatalf("failed to read what the stub plugin recorded: %v", err)
+	}
+	expected := "ADD eth0\nADD eth1\nADD eth2\nDEL eth0\nDEL eth2\nDEL eth1\n"
+	if string(output) != expected {
+		t.Errorf("expected the plugin to be called with %q, got %q", expected, output)
+	}
+}
+
+func TestInvocationAudit(t *testing.T) {
+	stderr = log.New(ioutil.Discard, "networking", false)
+
+	dir, err := ioutil.TempDir("", "rkt-networking-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the stub plugin succeeds to add and fails to delete
+	plugin := "#!/bin/sh\nif [ \"${CNI_COMMAND}\" = DEL ]; then echo '{\"msg\": \"stub failure\"}'; exit 1; fi\necho '{}'\n"
+	pluginPath := filepath.Join(dir, "stub")
+	if err := ioutil.WriteFile(pluginPath, []byte(plugin), 0755); err != nil {
+		t.Fatalf("failed to write the stub plugin: %v", err)
+	}
+
+	podID, err := types.NewUUID(testPodUUID)
+	if err != nil {
+		t.Fatalf("failed to parse the pod UUID: %v", err)
+	}
+	e := &podEnv{
+		podRoot:       filepath.Join(dir, "pod"),
+		podID:         *podID,
+		netnsProvider: fakeNetnsProvider("/run/fake/netns"),
+	}
+
+	conf := &NetConf{PluginDirs: []string{dir}, Labels: map[string]string{"app": "test"}}
+	conf.Name = "fake"
+	conf.Type = "stub"
+	nets := []activeNet{{
+		confBytes: []byte(`{"name": "fake", "type": "stub"}`),
+		conf:      conf,
+		runtime: &netinfo.NetInfo{
+			NetName:  conf.Name,
+			ConfPath: filepath.Join(dir, "10-fake.conf"),
+		},
+	}}
+
+	if err := e.setupNets(nets, false); err != nil {
+		t.Fatalf("unexpected error setting up the networks: %v", err)
+	}
+	e.teardownNets(nets)
+
+	f, err := os.Open(e.auditLogPath())
+	if err != nil {
+		t.Fatalf("failed to open the audit log: %v", err)
+	}
+	defer f.Close()
+
+	var records []InvocationRecord
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var r InvocationRecord
+		if err := dec.Decode(&r); err != nil {
+			t.Fatalf("failed to decode the audit log: %v", err)
+		}
+		records = append(records, r)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected two audit records, got %d: %+v", len(records), records)
+	}
+
+	for i, cmd := range []string{"ADD", "DEL"} {
+		r := records[i]
+		if r.Command != cmd {
+			t.Errorf("expected record %d to be for %s, got %q", i, cmd, r.Command)
+		}
+		if r.NetName != "fake" || r.PluginPath != pluginPath || r.Netns != "/run/fake/netns" || r.IfName != "eth0" || r.Args != "app=test" {
+			t.Errorf("unexpected %s record %+v", cmd, r)
+		}
+		if r.Time.IsZero() || r.Duration <= 0 {
+			t.Errorf("expected the %s record to have a time and a duration", cmd)
+		}
+	}
+	if records[0].Result != "{}\n" || records[0].Error != "" {
+		t.Errorf("unexpected ADD result %q and error %q", records[0].Result, records[0].Error)
+	}
+	if records[1].Error == "" {
+		t.Errorf("expected the DEL record to have an error")
+	}
+}
+
+type invocation struct {
+	plugin   string
+	command  string
+	duration time.Duration
+	err      error
+}
+
+type fakeMetricsSink []invocation
+
+func (s *fakeMetricsSink) PluginInvoked(plugin, command string, duration time.Duration, err error) {
+	*s = append(*s, invocation{plugin, command, duration, err})
+}
+
+func TestPluginMetrics(t *testing.T) {
+	stderr = log.New(ioutil.Discard, "networking", false)
+
+	dir, err := ioutil.TempDir("", "rkt-networking-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the stub plugin sleeps for the delay given in its arguments
+	const delay = 200 * time.Millisecond
+	plugin := "#!/bin/sh\nsleep \"${CNI_ARGS#DELAY=}\"\nif [ \"${CNI_COMMAND}\" = DEL ]; then exit 1; fi\necho '{}'\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "stub"), []byte(plugin), 0755); err != nil {
+		t.Fatalf("failed to write the stub plugin: %v", err)
+	}
+
+	podID, err := types.NewUUID(testPodUUID)
+	if err != nil {
+		t.Fatalf("failed to parse the pod UUID: %v", err)
+	}
+	var metrics fakeMetricsSink
+	e := &podEnv{
+		podRoot:       filepath.Join(dir, "pod"),
+		podID:         *podID,
+		netnsProvider: fakeNetnsProvider("/run/fake/netns"),
+		metrics:       &metrics,
+	}
+
+	conf := &NetConf{PluginDirs: []string{dir}}
+	conf.Name = "fake"
+	conf.Type = "stub"
+	nets := []activeNet{{
+		confBytes: []byte(`{"name": "fake", "type": "stub"}`),
+		conf:      conf,
+		runtime: &netinfo.NetInfo{
+			NetName:  conf.Name,
+			ConfPath: filepath.Join(dir, "10-fake.conf"),
+			Args:     fmt.Sprintf("DELAY=%.1f", delay.Seconds()),
+		},
+	}}
+
+	if err := e.setupNets(nets, false); err != nil {
+		t.Fatalf("unexpected error setting up the networks: %v", err)
+	}
+	e.teardownNets(nets)
+
+	if len(metrics) != 2 {
+		t.Fatalf("expected two invocations, got %d: %+v", len(metrics), metrics)
+	}
+	for i, cmd := range []string{"ADD", "DEL"} {
+		inv := metrics[i]
+		if inv.plugin != "stub" || inv.command != cmd {
+			t.Errorf("expected invocation %d to be the %s of the stub plugin, got %+v", i, cmd, inv)
+		}
+		if inv.duration < delay {
+			t.Errorf("expected the %s to take at least %v, got %v", cmd, delay, inv.duration)
+		}
+	}
+	if metrics[0].err != nil || metrics[1].err == nil {
+		t.Errorf("expected only the DEL to fail, got %v and %v", metrics[0].err, metrics[1].err)
+	}
+}
+
+func TestFlyFlavorNetworking(t *testing.T) {
+	stderr = log.New(ioutil.Discard, "networking", false)
+
+	dir, err := ioutil.TempDir("", "rkt-networking-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the stub plugin records that it was called
+	record := filepath.Join(dir, "record")
+	plugin := fmt.Sprintf("#!/bin/sh\necho \"${CNI_COMMAND}\" >>%s\necho '{}'\n", record)
+	if err := ioutil.WriteFile(filepath.Join(dir, "stub"), []byte(plugin), 0755); err != nil {
+		t.Fatalf("failed to write the stub plugin: %v", err)
+	}
+	netDir := filepath.Join(dir, "local", UserNetPathSuffix)
+	if err := os.MkdirAll(netDir, 0755); err != nil {
+		t.Fatalf("failed to create the network configuration directory: %v", err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(netDir, "10-fake.conf"), []byte(fmt.Sprintf(`{"name": "fake", "type": "stub", "pluginDirs": [%q]}`, dir)), 0644); err != nil {
+		t.Fatalf("failed to write the network configuration: %v", err)
+	}
+
+	podID, err := types.NewUUID(testPodUUID)
+	if err != nil {
+		t.Fatalf("failed to parse the pod UUID: %v", err)
+	}
+	netList := common.NetList{}
+	if err := netList.Set("fake"); err != nil {
+		t.Fatalf("failed to set the network list: %v", err)
+	}
+	e := &podEnv{
+		podRoot:       filepath.Join(dir, "pod"),
+		podID:         *podID,
+		netsLoadList:  netList,
+		localConfig:   filepath.Join(dir, "local"),
+		netnsProvider: fakeNetnsProvider("/run/fake/netns"),
+		flavor:        flavorFly,
+	}
+
+	if _, err := e.loadNets(); err != ErrNotApplicable {
+		t.Errorf("expected %v loading the networks, got %v", ErrNotApplicable, err)
+	}
+
+	conf := &NetConf{PluginDirs: []string{dir}}
+	conf.Name = "fake"
+	conf.Type = "stub"
+	nets := []activeNet{{
+		confBytes: []byte(`{"name": "fake", "type": "stub"}`),
+		conf:      conf,
+		runtime: &netinfo.NetInfo{
+			NetName:  conf.Name,
+			ConfPath: filepath.Join(dir, "10-fake.conf"),
+		},
+	}}
+	if err := e.setupNets(nets, false); err != nil {
+		t.Fatalf("unexpected error setting up the networks: %v", err)
+	}
+	e.teardownNets(nets)
+
+	n, err := Setup(e.podRoot, *podID, nil, netList, e.localConfig, flavorFly, false, false)
+	if err != nil {
+		t.Fatalf("unexpected error setting up the networking: %v", err)
+	}
+	n.Teardown(flavorFly, false)
+
+	if _, err := os.Stat(record); !os.IsNotExist(err) {
+		t.Errorf("expected the plugin not to be called for the fly flavor")
+	}
+}
+
+func TestNetConfOverride(t *testing.T) {
+	stderr = log.New(ioutil.Discard, "networking", false)
+
+	dir, err := ioutil.TempDir("", "rkt-networking-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// the stub plugin records the configuration it got on stdin
+	record := filepath.Join(dir, "record")
+	plugin := fmt.Sprintf("#!/bin/sh\ncat >>%s\necho '{}'\n", record)
+	if err := ioutil.WriteFile(filepath.Join(dir, "stub"), []byte(plugin), 0755); err != nil {
+		t.Fatalf("failed to write the stub plugin: %v", err)
+	}
+	netDir := filepath.Join(dir, "local", UserNetPathSuffix)
+	if err := os.MkdirAll(netDir, 0755); err != nil {
+		t.Fatalf("failed to create the network configuration directory: %v", err)
+	}
+	stored := fmt.Sprintf(`{"cniVersion": "0.2.0", "name": "fake", "type": "stub", "pluginDirs": [%q], "mtu": 1500}`, dir)
+	if err := ioutil.WriteFile(filepath.Join(netDir, "10-fake.conf"), []byte(stored), 0644); err != nil {
+		t.Fatalf("failed to write the network configuration: %v", err)
+	}
+	podRoot := filepath.Join(dir, "pod")
+	overrideDir := filepath.Join(podRoot, NetConfOverrideDir)
+	if err := os.MkdirAll(overrideDir, 0755); err != nil {
+		t.Fatalf("failed to create the override directory: %v", err)
+	}
+	override := fmt.Sprintf(`{"cniVersion": "0.2.0", "name": "fake", "type": "stub", "pluginDirs": [%q], "mtu": 9000}`, dir)
+	if err := ioutil.WriteFile(filepath.Join(overrideDir, "fake.conf"), []byte(override), 0644); err != nil {
+		t.Fatalf("failed to write the overriding configuration: %v", err)
+	}
+
+	podID, err := types.NewUUID(testPodUUID)
+	if err != nil {
+		t.Fatalf("failed to parse the pod UUID: %v", err)
+	}
+	netList := common.NetList{}
+	if err := netList.Set("fake"); err != nil {
+		t.Fatalf("failed to set the network list: %v", err)
+	}
+	e := &podEnv{
+		podRoot:       podRoot,
+		podID:         *podID,
+		netsLoadList:  netList,
+		localConfig:   filepath.Join(dir, "local"),
+		netnsProvider: fakeNetnsProvider("/run/fake/netns"),
+	}
+
+	nets, err := e.loadNets()
+	if err != nil {
+		t.Fatalf("unexpected error loading the networks: %v", err)
+	}
+	if len(nets) != 1 || nets[0].conf.MTU != 9000 {
+		t.Fatalf("expected the overriding configuration to be loaded, got %v", nets)
+	}
+	if err := e.setupNets(nets, false); err != nil {
+		t.Fatalf("unexpected error setting up the networks: %v", err)
+	}
+
+	output, err := ioutil.ReadFile(record)
+	if err != nil {
+		t.Fatalf("failed to read what the stub plugin recorded: %v", err)
+	}
+	if string(output) != override {
+		t.Errorf("expected the plugin to get %q, got %q", override, output)
+	}
+
+	// overriding a network which is not loaded is an error
+	if err := ioutil.WriteFile(filepath.Join(overrideDir, "other.conf"), []byte(`{"name": "other", "type": "stub"}`), 0644); err != nil {
+		t.Fatalf("failed to write the overriding configuration: %v", err)
+	}
+	if _, err := e.loadNets(); err == nil {
+		t.Errorf("expected an error overriding a network which is not loaded")
+	}
+}
+
+func TestParseNetConfOverride(t *testing.T) {
+	tests := []struct {
+		conf string
+		fail bool
+	}{
+		{`{"name": "fake", "type": "bridge"}`, false},
+		{`{"name": "fake", "type": "bridge"`, true},
+		{`{"name": "other", "type": "bridge"}`, true},
+		{`{"type": "bridge"}`, true},
+		{`{"name": "fake"}`, true},
+	}
+	for _, tt := range tests {
+		_, err := ParseNetConfOverride("fake", []byte(tt.conf))
+		if tt.fail && err == nil {
+			t.Errorf("expected an error parsing %q", tt.conf)
+		} else if !tt.fail && err != nil {
+			t.Errorf("unexpected error parsing %q: %v", tt.conf, err)
+		}
+	}
+}
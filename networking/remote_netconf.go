@@ -0,0 +1,105 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+)
+
+const (
+	// Suffix of the file holding the last successfully fetched
+	// copy of a remote network configuration
+	remoteNetConfCacheSuffix = ".cache"
+
+	remoteNetConfTimeout = 30 * time.Second
+)
+
+// remoteNetConf is a network definition that refers to a CNI
+// configuration served over HTTP(S) instead of containing it.
+type remoteNetConf struct {
+	Name string `json:"name"`
+	// CNIConfURL is the location of the CNI configuration.
+	CNIConfURL string `json:"cniConfUrl"`
+	// InsecureSkipTLSVerify disables the TLS certificate
+	// validation, like --insecure-options=tls does for images.
+	InsecureSkipTLSVerify bool `json:"insecureSkipTLSVerify"`
+	// UseCacheOnFailure allows using the last fetched copy of the
+	// configuration when the URL cannot be fetched.
+	UseCacheOnFailure bool `json:"useCacheOnFailure"`
+}
+
+// fetchRemoteNetConf downloads the CNI configuration referred by the
+// network definition in confPath and caches it next to the
+// definition. If the download fails and rn allows it, the cached
+// copy is returned instead.
+func fetchRemoteNetConf(confPath string, rn *remoteNetConf) ([]byte, error) {
+	cachePath := confPath + remoteNetConfCacheSuffix
+	data, err := downloadNetConf(rn)
+	if err == nil {
+		if err := ioutil.WriteFile(cachePath, data, 0644); err != nil {
+			stderr.PrintE(fmt.Sprintf("failed to cache the network configuration from %q", rn.CNIConfURL), err)
+		}
+		return data, nil
+	}
+
+	fetchErr := errwrap.Wrap(fmt.Errorf("failed to fetch the network configuration from %q for %v", rn.CNIConfURL, confPath), err)
+	if !rn.UseCacheOnFailure {
+		return nil, fetchErr
+	}
+	cached, cacheErr := ioutil.ReadFile(cachePath)
+	if cacheErr != nil {
+		return nil, errwrap.Wrap(fmt.Errorf("no cached copy of the network configuration in %v", cachePath), fetchErr)
+	}
+	stderr.PrintE(fmt.Sprintf("using the cached copy of the network configuration from %v", cachePath), fetchErr)
+	return cached, nil
+}
+
+func downloadNetConf(rn *remoteNetConf) ([]byte, error) {
+	u, err := url.Parse(rn.CNIConfURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid URL scheme %q, expected http or https", u.Scheme)
+	}
+
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+	}
+	if rn.InsecureSkipTLSVerify {
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   remoteNetConfTimeout,
+	}
+
+	res, err := client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad HTTP status code: %d", res.StatusCode)
+	}
+	return ioutil.ReadAll(res.Body)
+}
@@ -0,0 +1,110 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/rkt/rkt/common"
+)
+
+// subnetConflict is a pair of networks whose plugins assigned
+// overlapping subnets to the pod.
+type subnetConflict struct {
+	net1, net2       string
+	subnet1, subnet2 *net.IPNet
+}
+
+func (c subnetConflict) String() string {
+	return fmt.Sprintf("networks %q (%s) and %q (%s) have overlapping subnets", c.net1, c.subnet1, c.net2, c.subnet2)
+}
+
+// subnetsOverlap tells whether one of the subnets contains the other.
+func subnetsOverlap(a, b *net.IPNet) bool {
+	return a.Contains(b.IP.Mask(b.Mask)) || b.Contains(a.IP.Mask(a.Mask))
+}
+
+// findSubnetConflicts compares the subnets of the IPs assigned by the
+// plugins of the networks, and returns the pairs of networks with
+// overlapping subnets, in setup order.
+func findSubnetConflicts(nets []activeNet) []subnetConflict {
+	var conflicts []subnetConflict
+	for i := range nets {
+		ip1 := nets[i].runtime.IP4
+		if ip1 == nil {
+			continue
+		}
+		for j := i + 1; j < len(nets); j++ {
+			ip2 := nets[j].runtime.IP4
+			if ip2 == nil {
+				continue
+			}
+			if subnetsOverlap(&ip1.IP, &ip2.IP) {
+				conflicts = append(conflicts, subnetConflict{
+					net1:    nets[i].conf.Name,
+					net2:    nets[j].conf.Name,
+					subnet1: &ip1.IP,
+					subnet2: &ip2.IP,
+				})
+			}
+		}
+	}
+	return conflicts
+}
+
+// strictSubnets tells whether overlapping subnets are an error instead
+// of a warning, as requested with the RKT_STRICT_SUBNETS environment
+// variable.
+func strictSubnets() (bool, error) {
+	value := os.Getenv(common.EnvStrictSubnets)
+	if value == "" {
+		return false, nil
+	}
+	strict, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errwrap.Wrap(fmt.Errorf("invalid %s", common.EnvStrictSubnets), err)
+	}
+	return strict, nil
+}
+
+// checkSubnetConflicts warns about the networks of the pod with
+// overlapping subnets, which break the routing in the pod in subtle
+// ways. In strict mode, it returns an error instead.
+func checkSubnetConflicts(nets []activeNet) error {
+	conflicts := findSubnetConflicts(nets)
+	if len(conflicts) == 0 {
+		return nil
+	}
+	strict, err := strictSubnets()
+	if err != nil {
+		return err
+	}
+	if !strict {
+		for _, c := range conflicts {
+			stderr.Printf("Warning: %s, the routing in the pod may be broken", c)
+		}
+		return nil
+	}
+	descriptions := make([]string, 0, len(conflicts))
+	for _, c := range conflicts {
+		descriptions = append(descriptions, c.String())
+	}
+	return fmt.Errorf("conflicting subnet allocations: %s", strings.Join(descriptions, "; "))
+}
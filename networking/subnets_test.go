@@ -0,0 +1,109 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networking
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/appc/spec/schema/types"
+	"github.com/rkt/rkt/common"
+	"github.com/rkt/rkt/networking/netinfo"
+	"github.com/rkt/rkt/pkg/log"
+)
+
+func TestSubnetConflicts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rkt-networking-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// each stub plugin assigns an IP in its subnet and records the
+	// commands it got
+	record := filepath.Join(dir, "record")
+	subnets := []struct {
+		name string
+		ip   string
+	}{
+		{"wide", "10.1.0.2/16"},
+		{"narrow", "10.1.2.3/24"},
+		{"other", "10.2.0.2/16"},
+	}
+	var nets []activeNet
+	for _, s := range subnets {
+		plugin := fmt.Sprintf("#!/bin/sh\necho \"${CNI_COMMAND} %s\" >>%s\necho '{\"ip4\": {\"ip\": \"%s\"}}'\n", s.name, record, s.ip)
+		if err := ioutil.WriteFile(filepath.Join(dir, s.name), []byte(plugin), 0755); err != nil {
+			t.Fatalf("failed to write the stub plugin: %v", err)
+		}
+		conf := &NetConf{PluginDirs: []string{dir}}
+		conf.Name = s.name
+		conf.Type = s.name
+		nets = append(nets, activeNet{
+			confBytes: []byte(fmt.Sprintf(`{"name": %q, "type": %q}`, s.name, s.name)),
+			conf:      conf,
+			runtime: &netinfo.NetInfo{
+				NetName:  conf.Name,
+				ConfPath: filepath.Join(dir, s.name+".conf"),
+			},
+		})
+	}
+
+	podID, err := types.NewUUID(testPodUUID)
+	if err != nil {
+		t.Fatalf("failed to parse the pod UUID: %v", err)
+	}
+	e := &podEnv{
+		podRoot:       filepath.Join(dir, "pod"),
+		podID:         *podID,
+		netnsProvider: fakeNetnsProvider("/run/fake/netns"),
+	}
+
+	var warnings bytes.Buffer
+	stderr = log.New(&warnings, "networking", false)
+	if err := e.setupNets(nets, true); err != nil {
+		t.Fatalf("unexpected error setting up the networks: %v", err)
+	}
+	output := warnings.String()
+	if !strings.Contains(output, `networks "wide" (10.1.0.2/16) and "narrow" (10.1.2.3/24) have overlapping subnets`) {
+		t.Errorf("expected a warning about the overlapping subnets, got %q", output)
+	}
+	if strings.Contains(output, `"other"`) {
+		t.Errorf("unexpected warning about a network without overlapping subnets: %q", output)
+	}
+
+	// in strict mode, the conflict is an error and all the networks
+	// are torn down
+	os.Setenv(common.EnvStrictSubnets, "true")
+	defer os.Unsetenv(common.EnvStrictSubnets)
+	os.Remove(record)
+	stderr = log.New(ioutil.Discard, "networking", false)
+	if err := e.setupNets(nets, true); err == nil {
+		t.Errorf("expected an error setting up networks with overlapping subnets in strict mode")
+	}
+	commands, err := ioutil.ReadFile(record)
+	if err != nil {
+		t.Fatalf("failed to read what the stub plugins recorded: %v", err)
+	}
+	expected := "ADD wide\nADD narrow\nADD other\nDEL other\nDEL narrow\nDEL wide\n"
+	if string(commands) != expected {
+		t.Errorf("expected the plugins to be called with %q, got %q", expected, commands)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pod
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/appc/spec/schema"
+	"github.com/rkt/rkt/common"
+)
+
+// InconsistencyKind describes how the pod manifest and the on-disk files of
+// an app disagree.
+type InconsistencyKind string
+
+const (
+	// MissingAppInfo means the app is in the pod manifest but has no app
+	// info directory.
+	MissingAppInfo InconsistencyKind = "missing app info"
+	// OrphanedAppInfo means the app has an app info directory but is not
+	// in the pod manifest.
+	OrphanedAppInfo InconsistencyKind = "orphaned app info"
+	// OrphanedAppStatus means the app has a status file but is not in the
+	// pod manifest.
+	OrphanedAppStatus InconsistencyKind = "orphaned app status"
+	// OrphanedAppEnv means the app has an environment file but is not in
+	// the pod manifest.
+	OrphanedAppEnv InconsistencyKind = "orphaned app env"
+)
+
+// Inconsistency is a mismatch between the pod manifest and the files kept
+// for an app, like the ones left behind when adding or removing an app is
+// interrupted.
+type Inconsistency struct {
+	Kind InconsistencyKind
+	App  string
+	// Path is the file or directory which is missing or orphaned.
+	Path string
+}
+
+func (i Inconsistency) String() string {
+	return fmt.Sprintf("%s for app %q: %s", i.Kind, i.App, i.Path)
+}
+
+// statusSuffixes are the suffixes of the per-app files in the status
+// directory, besides the exit status file named after the app.
+var statusSuffixes = []string{"-created", "-started"}
+
+// CheckConsistency compares the apps in the pod manifest against their app
+// info directories, status files and environment files, and returns the
+// mismatches found. Environment files are not kept while the pod is not
+// running, so only orphaned ones are reported.
+func (p *Pod) CheckConsistency() ([]Inconsistency, error) {
+	_, pm, err := p.PodManifest()
+	if err != nil {
+		return nil, err
+	}
+	stage1RootfsPath, err := p.Stage1RootfsPath()
+	if err != nil {
+		return nil, err
+	}
+	return checkConsistency(p.Path(), stage1RootfsPath, pm)
+}
+
+func checkConsistency(podPath, stage1RootfsPath string, pm *schema.PodManifest) ([]Inconsistency, error) {
+	inManifest := make(map[string]struct{})
+	for _, ra := range pm.Apps {
+		inManifest[ra.Name.String()] = struct{}{}
+	}
+	orphaned := func(name string) bool {
+		_, ok := inManifest[name]
+		return !ok
+	}
+
+	var inconsistencies []Inconsistency
+	for _, ra := range pm.Apps {
+		appInfoDir := common.AppInfoPath(podPath, ra.Name)
+		if _, err := os.Stat(appInfoDir); err != nil {
+			if !os.IsNotExist(err) {
+				return nil, err
+			}
+			inconsistencies = append(inconsistencies, Inconsistency{MissingAppInfo, ra.Name.String(), appInfoDir})
+		}
+	}
+
+	appsInfoDir := common.AppsInfoPath(podPath)
+	names, err := dirEntries(appsInfoDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if orphaned(name) {
+			inconsistencies = append(inconsistencies, Inconsistency{OrphanedAppInfo, name, filepath.Join(appsInfoDir, name)})
+		}
+	}
+
+	statusDir := common.AppsStatusesPathFromStage1Rootfs(stage1RootfsPath)
+	names, err = dirEntries(statusDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if !orphaned(name) {
+			continue
+		}
+		app := name
+		for _, suffix := range statusSuffixes {
+			if strings.HasSuffix(name, suffix) {
+				app = strings.TrimSuffix(name, suffix)
+				break
+			}
+		}
+		if orphaned(app) {
+			inconsistencies = append(inconsistencies, Inconsistency{OrphanedAppStatus, app, filepath.Join(statusDir, name)})
+		}
+	}
+
+	envDir := filepath.Join(stage1RootfsPath, "rkt", "env")
+	names, err = dirEntries(envDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if orphaned(name) {
+			inconsistencies = append(inconsistencies, Inconsistency{OrphanedAppEnv, name, filepath.Join(envDir, name)})
+		}
+	}
+
+	return inconsistencies, nil
+}
+
+// dirEntries returns the names of the entries of dir, or nothing if
+// dir does not exist.
+func dirEntries(dir string) ([]string, error) {
+	fis, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	names := make([]string, 0, len(fis))
+	for _, fi := range fis {
+		names = append(names, fi.Name())
+	}
+	return names, nil
+}
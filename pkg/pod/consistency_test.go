@@ -0,0 +1,119 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pod
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/appc/spec/schema"
+	"github.com/appc/spec/schema/types"
+	"github.com/rkt/rkt/common"
+)
+
+func TestCheckConsistency(t *testing.T) {
+	tests := []struct {
+		apps     []string
+		appInfo  []string
+		status   []string
+		env      []string
+		expected []Inconsistency
+	}{
+		// consistent pod, apps without status or env files are fine
+		{
+			apps:    []string{"app1", "app2"},
+			appInfo: []string{"app1", "app2"},
+			status:  []string{"app1", "app1-created", "app1-started"},
+			env:     []string{"app1"},
+		},
+		// crash after removing the app info directory in app rm
+		{
+			apps:     []string{"app1", "app2"},
+			appInfo:  []string{"app1"},
+			expected: []Inconsistency{{MissingAppInfo, "app2", "appsinfo/app2"}},
+		},
+		// crash before updating the manifest in app add
+		{
+			apps:    []string{"app1"},
+			appInfo: []string{"app1", "app2"},
+			status:  []string{"app2-created"},
+			env:     []string{"app2"},
+			expected: []Inconsistency{
+				{OrphanedAppInfo, "app2", "appsinfo/app2"},
+				{OrphanedAppStatus, "app2", "stage1/rootfs/rkt/status/app2-created"},
+				{OrphanedAppEnv, "app2", "stage1/rootfs/rkt/env/app2"},
+			},
+		},
+		// crash before updating the manifest in app rm
+		{
+			apps:    []string{"app1"},
+			appInfo: []string{"app1"},
+			status:  []string{"app2", "app2-started", "app1-created"},
+			expected: []Inconsistency{
+				{OrphanedAppStatus, "app2", "stage1/rootfs/rkt/status/app2"},
+				{OrphanedAppStatus, "app2", "stage1/rootfs/rkt/status/app2-started"},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		podPath, err := ioutil.TempDir("", "")
+		if err != nil {
+			panic(err)
+		}
+		defer os.RemoveAll(podPath)
+		stage1RootfsPath := common.Stage1RootfsPath(podPath)
+
+		pm := schema.BlankPodManifest()
+		for _, app := range tt.apps {
+			pm.Apps = append(pm.Apps, schema.RuntimeApp{Name: *types.MustACName(app)})
+		}
+		for _, app := range tt.appInfo {
+			if err := os.MkdirAll(common.AppInfoPath(podPath, *types.MustACName(app)), 0755); err != nil {
+				panic(err)
+			}
+		}
+		for dir, files := range map[string][]string{
+			common.AppsStatusesPathFromStage1Rootfs(stage1RootfsPath): tt.status,
+			filepath.Join(stage1RootfsPath, "rkt", "env"):             tt.env,
+		} {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				panic(err)
+			}
+			for _, f := range files {
+				if err := ioutil.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+					panic(err)
+				}
+			}
+		}
+
+		inconsistencies, err := checkConsistency(podPath, stage1RootfsPath, pm)
+		if err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		var expected []Inconsistency
+		for _, inc := range tt.expected {
+			inc.Path = filepath.Join(podPath, inc.Path)
+			expected = append(expected, inc)
+		}
+		if !reflect.DeepEqual(inconsistencies, expected) {
+			t.Errorf("#%d: expected %v, got %v", i, expected, inconsistencies)
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/appc/spec/schema/types"
 	"github.com/hashicorp/errwrap"
+	"github.com/rkt/rkt/common"
 )
 
 // matchUUID attempts to match the uuid specified as uuid against all pods present.
@@ -51,6 +52,13 @@ func matchUUID(dataDir, uuid string) ([]string, error) {
 // An unambiguously matched uuid or nil is returned.
 func resolveUUID(dataDir, uuid string) (*types.UUID, error) {
 	uuid = strings.ToLower(uuid)
+	if err := common.ValidatePodUUIDPrefix(uuid); err != nil {
+		return nil, err
+	}
+	// pods are stored under their canonical UUIDs, with dashes
+	if u, err := common.ParsePodUUID(uuid); err == nil {
+		uuid = u.String()
+	}
 	m, err := matchUUID(dataDir, uuid)
 	if err != nil {
 		return nil, err
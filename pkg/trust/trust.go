@@ -0,0 +1,195 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trust manages the keys trusted for verifying the images
+// directly in the keystore, like the rkt trust command does. An empty
+// prefix stands for the root keys, trusted for all the images.
+package trust
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/appc/spec/schema/types"
+	"github.com/hashicorp/errwrap"
+	"github.com/rkt/rkt/pkg/keystore"
+)
+
+// TrustedKey describes a key trusted for a prefix.
+type TrustedKey struct {
+	// Prefix is the prefix the key is trusted for, empty for
+	// the root keys.
+	Prefix string
+	// Fingerprint is the fingerprint of the key, also used as
+	// its file name.
+	Fingerprint string
+	// Path is the path of the key file.
+	Path string
+	// System tells whether the key comes from the system
+	// keystore directory, such keys can only be masked, not
+	// removed.
+	System bool
+}
+
+// TrustKey stores the armored public key read from keyReader as
+// trusted for the prefix and returns the path of the stored key.
+func TrustKey(ks *keystore.Keystore, prefix string, keyReader io.Reader) (string, error) {
+	if prefix == "" {
+		path, err := ks.StoreTrustedKeyRoot(keyReader)
+		if err != nil {
+			return "", errwrap.Wrap(errors.New("error adding root key"), err)
+		}
+		return path, nil
+	}
+	path, err := ks.StoreTrustedKeyPrefix(prefix, keyReader)
+	if err != nil {
+		return "", errwrap.Wrap(fmt.Errorf("error adding key for prefix %q", prefix), err)
+	}
+	return path, nil
+}
+
+// ListTrustedKeys returns the keys trusted for exactly the prefix,
+// sorted by their fingerprints. The keys trusted for the parent
+// prefixes are not included. The system keys masked in the local
+// keystore directory are skipped.
+func ListTrustedKeys(ks *keystore.Keystore, prefix string) ([]TrustedKey, error) {
+	systemDir, localDir, err := keyDirs(ks, prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]TrustedKey)
+	for _, d := range []struct {
+		dir    string
+		system bool
+	}{
+		{systemDir, true},
+		{localDir, false},
+	} {
+		infos, err := ioutil.ReadDir(d.dir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, errwrap.Wrap(fmt.Errorf("error reading the keys in %q", d.dir), err)
+		}
+		for _, info := range infos {
+			if info.IsDir() {
+				continue
+			}
+			// an empty local file masks the system key
+			if info.Size() == 0 {
+				delete(keys, info.Name())
+				continue
+			}
+			keys[info.Name()] = TrustedKey{
+				Prefix:      prefix,
+				Fingerprint: info.Name(),
+				Path:        filepath.Join(d.dir, info.Name()),
+				System:      d.system,
+			}
+		}
+	}
+
+	fingerprints := make([]string, 0, len(keys))
+	for fingerprint := range keys {
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	sort.Strings(fingerprints)
+	list := make([]TrustedKey, 0, len(keys))
+	for _, fingerprint := range fingerprints {
+		list = append(list, keys[fingerprint])
+	}
+	return list, nil
+}
+
+// RemoveTrustedKey stops trusting the key with the fingerprint for
+// the prefix. The key is removed from the local keystore directory
+// and, if it is also in the system one, masked there.
+func RemoveTrustedKey(ks *keystore.Keystore, prefix, fingerprint string) error {
+	keys, err := ListTrustedKeys(ks, prefix)
+	if err != nil {
+		return err
+	}
+	var local, system bool
+	for _, key := range keys {
+		if key.Fingerprint == fingerprint {
+			local = !key.System
+			system = key.System
+		}
+	}
+	systemDir, localDir, err := keyDirs(ks, prefix)
+	if err != nil {
+		return err
+	}
+	// a local key overrides the system one with the same
+	// fingerprint, so check for the latter too
+	if local {
+		if _, err := os.Stat(filepath.Join(systemDir, fingerprint)); err == nil {
+			system = true
+		}
+	}
+	if !local && !system {
+		return fmt.Errorf("no key with fingerprint %q is trusted for %s", fingerprint, prefixDescription(prefix))
+	}
+
+	if local {
+		if prefix == "" {
+			err = ks.DeleteTrustedKeyRoot(fingerprint)
+		} else {
+			err = ks.DeleteTrustedKeyPrefix(prefix, fingerprint)
+		}
+		if err != nil {
+			return errwrap.Wrap(fmt.Errorf("error removing key %q for %s", fingerprint, prefixDescription(prefix)), err)
+		}
+	}
+	if system {
+		if err := os.MkdirAll(localDir, 0755); err != nil {
+			return err
+		}
+		if prefix == "" {
+			_, err = ks.MaskTrustedKeySystemRoot(fingerprint)
+		} else {
+			_, err = ks.MaskTrustedKeySystemPrefix(prefix, fingerprint)
+		}
+		if err != nil {
+			return errwrap.Wrap(fmt.Errorf("error masking system key %q for %s", fingerprint, prefixDescription(prefix)), err)
+		}
+	}
+	return nil
+}
+
+// keyDirs returns the system and the local directories with the keys
+// for the prefix.
+func keyDirs(ks *keystore.Keystore, prefix string) (string, string, error) {
+	if prefix == "" {
+		return ks.SystemRootPath, ks.LocalRootPath, nil
+	}
+	acidentifier, err := types.NewACIdentifier(prefix)
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Join(ks.SystemPrefixPath, acidentifier.String()), filepath.Join(ks.LocalPrefixPath, acidentifier.String()), nil
+}
+
+func prefixDescription(prefix string) string {
+	if prefix == "" {
+		return "the root"
+	}
+	return fmt.Sprintf("prefix %q", prefix)
+}
@@ -0,0 +1,155 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trust
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/rkt/rkt/pkg/keystore"
+	"github.com/rkt/rkt/pkg/keystore/keystoretest"
+)
+
+func fingerprints(keys []TrustedKey) []string {
+	fps := []string{}
+	for _, key := range keys {
+		fps = append(fps, key.Fingerprint)
+	}
+	return fps
+}
+
+func TestTrustKey(t *testing.T) {
+	ks, ksPath, err := keystore.NewTestKeystore()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer os.RemoveAll(ksPath)
+
+	exampleKey := keystoretest.KeyMap["example.com"]
+	acmeKey := keystoretest.KeyMap["acme.com"]
+
+	for _, tt := range []struct {
+		prefix string
+		key    *keystoretest.KeyDetails
+	}{
+		{"example.com/foo", exampleKey},
+		{"example.com/foo", acmeKey},
+		{"", acmeKey},
+	} {
+		path, err := TrustKey(ks, tt.prefix, bytes.NewBufferString(tt.key.ArmoredPublicKey))
+		if err != nil {
+			t.Fatalf("unexpected error trusting a key for %q: %v", tt.prefix, err)
+		}
+		if filepath.Base(path) != tt.key.Fingerprint {
+			t.Errorf("expected the key to be stored as %s, got %s", tt.key.Fingerprint, path)
+		}
+	}
+	if _, err := TrustKey(ks, "example.com/foo", bytes.NewBufferString("not a key")); err == nil {
+		t.Errorf("expected an error trusting an invalid key")
+	}
+
+	// the keys are sorted by their fingerprints
+	prefixKeys := []string{acmeKey.Fingerprint, exampleKey.Fingerprint}
+	sort.Strings(prefixKeys)
+	tests := []struct {
+		prefix   string
+		expected []string
+	}{
+		{"example.com/foo", prefixKeys},
+		// the keys of the parent prefixes are not listed
+		{"example.com/foo/bar", []string{}},
+		{"example.com", []string{}},
+		{"", []string{acmeKey.Fingerprint}},
+	}
+	for _, tt := range tests {
+		keys, err := ListTrustedKeys(ks, tt.prefix)
+		if err != nil {
+			t.Fatalf("unexpected error listing the keys for %q: %v", tt.prefix, err)
+		}
+		if result := fingerprints(keys); !reflect.DeepEqual(result, tt.expected) {
+			t.Errorf("expected keys %v for %q, got %v", tt.expected, tt.prefix, result)
+		}
+		for _, key := range keys {
+			if key.Prefix != tt.prefix || key.System {
+				t.Errorf("unexpected key for %q: %+v", tt.prefix, key)
+			}
+		}
+	}
+
+	if err := RemoveTrustedKey(ks, "example.com/foo", exampleKey.Fingerprint); err != nil {
+		t.Fatalf("unexpected error removing a prefix key: %v", err)
+	}
+	if err := RemoveTrustedKey(ks, "", acmeKey.Fingerprint); err != nil {
+		t.Fatalf("unexpected error removing a root key: %v", err)
+	}
+	if err := RemoveTrustedKey(ks, "", acmeKey.Fingerprint); err == nil {
+		t.Errorf("expected an error removing a key that is not trusted")
+	}
+	if keys, err := ListTrustedKeys(ks, "example.com/foo"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if result := fingerprints(keys); !reflect.DeepEqual(result, []string{acmeKey.Fingerprint}) {
+		t.Errorf("expected only the acme.com key to stay, got %v", result)
+	}
+	if keys, err := ListTrustedKeys(ks, ""); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	} else if len(keys) != 0 {
+		t.Errorf("expected no root keys, got %v", fingerprints(keys))
+	}
+}
+
+func TestRemoveSystemTrustedKey(t *testing.T) {
+	ks, ksPath, err := keystore.NewTestKeystore()
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer os.RemoveAll(ksPath)
+
+	key := keystoretest.KeyMap["example.com"]
+	for _, dir := range []string{ks.SystemRootPath, filepath.Join(ks.SystemPrefixPath, "example.com")} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, key.Fingerprint), []byte(key.ArmoredPublicKey), 0644); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+
+	for _, prefix := range []string{"", "example.com"} {
+		keys, err := ListTrustedKeys(ks, prefix)
+		if err != nil {
+			t.Fatalf("unexpected error listing the keys for %q: %v", prefix, err)
+		}
+		if len(keys) != 1 || !keys[0].System {
+			t.Fatalf("expected a system key for %q, got %+v", prefix, keys)
+		}
+		if err := RemoveTrustedKey(ks, prefix, key.Fingerprint); err != nil {
+			t.Fatalf("unexpected error removing the system key for %q: %v", prefix, err)
+		}
+		// the system key is masked, not removed
+		if _, err := os.Stat(keys[0].Path); err != nil {
+			t.Errorf("expected the system key for %q to stay: %v", prefix, err)
+		}
+		if keys, err := ListTrustedKeys(ks, prefix); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		} else if len(keys) != 0 {
+			t.Errorf("expected the system key for %q to be masked, got %+v", prefix, keys)
+		}
+	}
+}
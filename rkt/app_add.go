@@ -16,8 +16,10 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/rkt/rkt/common"
+	"github.com/rkt/rkt/common/apps"
 	pkgPod "github.com/rkt/rkt/pkg/pod"
 	"github.com/rkt/rkt/rkt/image"
 	"github.com/rkt/rkt/stage0"
@@ -25,15 +27,24 @@ import (
 	"github.com/rkt/rkt/store/treestore"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var (
 	cmdAppAdd = &cobra.Command{
 		Use:   "add UUID IMAGEID ...",
 		Short: "Add an app to a pod",
-		Long:  "This adds an application available in the local image store to a running mutable pod.",
-		Run:   runWrapper(runAppAdd),
+		Long: `This adds an application available in the local image store to a running mutable pod.
+
+With --apps-from, several apps are added at once from a JSON list of app specs,
+each with an "image" and optional per-app "flags" and "args", e.g.
+
+	[{"image": "example.com/app", "flags": ["--name=app", "--restart=Always"], "args": ["--verbose"]}]
+
+If adding one of them fails, the apps already added are removed again.`,
+		Run: runWrapper(runAppAdd),
 	}
+	flagAppsFrom string
 )
 
 func init() {
@@ -43,6 +54,12 @@ func init() {
 
 	// Add per-app volume mounts only for sandbox for now
 	cmdAppAdd.Flags().Var((*appMountVolume)(&rktApps), "mnt-volume", "Configure a per-app mount and volume directly")
+	cmdAppAdd.Flags().Var((*appRestartPolicy)(&rktApps), "restart", "restart policy recorded for an external supervisor (Never, OnFailure or Always)")
+	cmdAppAdd.Flags().Var((*appHealthCheck)(&rktApps), "health-check", "health check command to run inside the app, the executable must be an absolute path (example: '--health-check=/bin/check --verbose')")
+	cmdAppAdd.Flags().Var((*appStdout)(&rktApps), "stdout", "stdout mode for the app (example: '--stdout=journal')")
+	cmdAppAdd.Flags().Var((*appStderr)(&rktApps), "stderr", "stderr mode for the app (example: '--stderr=null')")
+	cmdAppAdd.Flags().StringVar(&flagAppsFrom, "apps-from", "", "read a JSON list of apps to add from the given file, or from stdin if '-'")
+	cmdAppAdd.Flags().Var((*appUnitOption)(&rktApps), "unit-option", "extra systemd unit option for the app, can be repeated (example: '--unit-option=Service.LimitNOFILE=65536')")
 
 	// Disable interspersed flags to stop parsing after the first non flag
 	// argument. All the subsequent parsing will be done by parseApps.
@@ -51,20 +68,32 @@ func init() {
 }
 
 func runAppAdd(cmd *cobra.Command, args []string) (exit int) {
-	if len(args) < 2 {
-		stderr.Print("must provide the pod UUID and an IMAGEID")
-		return 254
-	}
-
-	err := parseApps(&rktApps, args[1:], cmd.Flags(), true)
-	if err != nil {
-		stderr.PrintE("error parsing app image arguments", err)
-		return 254
-	}
-
-	if rktApps.Count() > 1 {
-		stderr.Print("must give only one app")
-		return 254
+	if flagAppsFrom != "" {
+		if len(args) != 1 {
+			stderr.Print("must provide only the pod UUID with --apps-from")
+			return 254
+		}
+
+		if err := readAppSpecs(flagAppsFrom, cmd.Flags()); err != nil {
+			stderr.PrintE("error reading app specs", err)
+			return 254
+		}
+	} else {
+		if len(args) < 2 {
+			stderr.Print("must provide the pod UUID and an IMAGEID")
+			return 254
+		}
+
+		err := parseApps(&rktApps, args[1:], cmd.Flags(), true, false)
+		if err != nil {
+			stderr.PrintE("error parsing app image arguments", err)
+			return 254
+		}
+
+		if rktApps.Count() > 1 {
+			stderr.Print("must give only one app")
+			return 254
+		}
 	}
 
 	p, err := pkgPod.PodFromUUIDString(getDataDir(), args[0])
@@ -104,12 +133,17 @@ func runAppAdd(cmd *cobra.Command, args []string) (exit int) {
 		PullPolicy: image.PullPolicyNever,
 	}
 
-	img, err := fn.FindImage(args[1], "")
-	if err != nil {
+	if err := rktApps.Walk(func(app *apps.App) error {
+		img, err := fn.FindImage(app.Image, nil)
+		if err != nil {
+			return err
+		}
+		app.ImageID = *img
+		return nil
+	}); err != nil {
 		stderr.PrintE("error finding images", err)
 		return 254
 	}
-	rktApps.Last().ImageID = *img
 
 	podPID, err := p.ContainerPid1()
 	if err != nil {
@@ -133,7 +167,7 @@ func runAppAdd(cmd *cobra.Command, args []string) (exit int) {
 
 	cfg := stage0.AddConfig{
 		CommonConfig: &ccfg,
-		Image:        *img,
+		Image:        rktApps.Last().ImageID,
 		Apps:         &rktApps,
 		RktGid:       rktgid,
 		UsesOverlay:  p.UsesOverlay(),
@@ -145,7 +179,11 @@ func runAppAdd(cmd *cobra.Command, args []string) (exit int) {
 		stage0.InitDebug()
 	}
 
-	err = stage0.AddApp(cfg)
+	if flagAppsFrom != "" {
+		err = stage0.AddApps(cfg)
+	} else {
+		err = stage0.AddApp(cfg)
+	}
 	if err != nil {
 		stderr.PrintE("error adding app to pod", err)
 		return 254
@@ -153,3 +191,19 @@ func runAppAdd(cmd *cobra.Command, args []string) (exit int) {
 
 	return 0
 }
+
+// readAppSpecs adds the apps from the app specs in the given file, or
+// from stdin if it is "-".
+func readAppSpecs(path string, flags *pflag.FlagSet) error {
+	r := os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	return parseAppSpecs(&rktApps, r, flags)
+}
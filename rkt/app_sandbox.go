@@ -58,6 +58,7 @@ func init() {
 	cmdAppSandbox.Flags().StringVar(&flagDNSDomain, "dns-domain", "", "DNS domain to write in /etc/resolv.conf")
 	cmdAppSandbox.Flags().Var(&flagHostsEntries, "hosts-entry", "Entries to add to the pod-wide /etc/hosts. Pass 'host' to use the host's /etc/hosts")
 	cmdAppSandbox.Flags().StringVar(&flagHostname, "hostname", "", `pod's hostname. If empty, it will be "rkt-$PODUUID"`)
+	cmdAppSandbox.Flags().BoolVar(&flagEnvTmpfs, "env-tmpfs", false, "keep the apps' environment files on a tmpfs instead of the pod directory, so they are never written to disk")
 	cmdAppSandbox.Flags().Var(&flagAppPorts, "port", "ports to forward. format: \"name:proto:podPort:hostIP:hostPort\"")
 
 	flagAppPorts = appPortList{}
@@ -206,6 +207,7 @@ func runAppSandbox(cmd *cobra.Command, args []string) int {
 		InsecureSeccomp:      globalFlags.InsecureFlags.SkipSeccomp(),
 		UseOverlay:           useOverlay,
 		HostsEntries:         *HostsEntries,
+		EnvTmpfs:             flagEnvTmpfs,
 	}
 
 	_, manifest, err := p.PodManifest()
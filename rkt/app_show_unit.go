@@ -0,0 +1,66 @@
+// Copyright 2016 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	rkt "github.com/rkt/rkt/lib"
+
+	"github.com/appc/spec/schema/types"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdAppShowUnit = &cobra.Command{
+		Use:   "show-unit UUID --app=NAME",
+		Short: "Show the systemd service unit of an app in a pod",
+		Long:  "This prints the systemd service unit generated by stage1 for an application of a pod.",
+		Run:   runWrapper(runAppShowUnit),
+	}
+)
+
+func init() {
+	cmdAppShowUnit.Flags().StringVar(&flagAppName, "app", "", "app whose unit to show")
+	cmdApp.AddCommand(cmdAppShowUnit)
+}
+
+func runAppShowUnit(cmd *cobra.Command, args []string) (exit int) {
+	if len(args) != 1 {
+		stderr.Print("must provide the pod UUID")
+		return 254
+	}
+
+	if flagAppName == "" {
+		stderr.Print("must provide the app whose unit to show")
+		return 254
+	}
+
+	appName, err := types.NewACName(flagAppName)
+	if err != nil {
+		stderr.PrintE("invalid app name", err)
+		return 254
+	}
+
+	unit, err := rkt.AppServiceUnit(args[0], getDataDir(), *appName)
+	if err == rkt.ErrUnitNotFound {
+		stderr.Printf("app %q has no service unit, the pod's stage1 may not be systemd-based", appName)
+		return 254
+	} else if err != nil {
+		stderr.PrintE("error reading the app's service unit", err)
+		return 254
+	}
+
+	stdout.Print(string(unit))
+	return 0
+}
@@ -17,13 +17,17 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"strconv"
 	"strings"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/rkt/rkt/common/apps"
+	"github.com/rkt/rkt/rkt/config"
 
 	"github.com/appc/spec/schema"
 	"github.com/appc/spec/schema/types"
@@ -38,7 +42,8 @@ var (
 // Between per-app argument lists flags.Parse() is called using the supplied FlagSet.
 // Anything not consumed by flags.Parse() and not found to be a per-app argument list is treated as an image.
 // allowAppArgs controls whether "--" prefixed per-app arguments will be accepted or not.
-func parseApps(al *apps.Apps, args []string, flags *pflag.FlagSet, allowAppArgs bool) error {
+// strict controls whether a "---" found outside of per-app arguments is an error or is ignored.
+func parseApps(al *apps.Apps, args []string, flags *pflag.FlagSet, allowAppArgs, strict bool) error {
 	nAppsLastAppArgs := al.Count()
 
 	// valid args here may either be:
@@ -80,10 +85,12 @@ func parseApps(al *apps.Apps, args []string, flags *pflag.FlagSet, allowAppArgs
 				}
 				nAppsLastAppArgs = al.Count()
 			case "---":
-				// ignore triple dashes since they aren't images
-				// TODO(vc): I don't think ignoring this is appropriate, probably should error; it implies malformed argv.
 				// "---" is not an image separator, it's an optional argument list terminator.
-				// encountering it outside of inAppArgs is likely to be "--" typoed
+				// encountering it outside of inAppArgs is likely to be "--" typoed, so in
+				// strict mode it's an error, otherwise it's ignored since it isn't an image.
+				if strict {
+					return fmt.Errorf(`unexpected "---" outside of app arguments, did you mean "--"?`)
+				}
 			default:
 				// consume any potential inter-app flags
 				if err := flags.Parse(args[i:]); err != nil {
@@ -110,9 +117,63 @@ func parseApps(al *apps.Apps, args []string, flags *pflag.FlagSet, allowAppArgs
 	return al.Validate()
 }
 
+// appSpec describes an app read with --apps-from: its image, and its
+// per-app flags and arguments as they would be given on the command line.
+type appSpec struct {
+	Image string   `json:"image"`
+	Flags []string `json:"flags,omitempty"`
+	Args  []string `json:"args,omitempty"`
+}
+
+// parseAppSpecs reads a JSON list of app specs from r and adds the apps to
+// al. The per-app flags, in the "--flag=value" form, are handled by
+// parseApps using the supplied FlagSet.
+func parseAppSpecs(al *apps.Apps, r io.Reader, flags *pflag.FlagSet) error {
+	var specs []appSpec
+	if err := json.NewDecoder(r).Decode(&specs); err != nil {
+		return errwrap.Wrap(errors.New("error decoding app specs"), err)
+	}
+	if len(specs) == 0 {
+		return errors.New("no apps specified")
+	}
+
+	n := al.Count()
+	for i, spec := range specs {
+		if spec.Image == "" {
+			return fmt.Errorf("app spec #%d has no image", i)
+		}
+		args := []string{spec.Image}
+		for _, f := range spec.Flags {
+			if !strings.HasPrefix(f, "--") || f == "--" || f == "---" {
+				return fmt.Errorf("app spec #%d: %q is not a flag", i, f)
+			}
+			args = append(args, f)
+		}
+		for _, a := range spec.Args {
+			if a == "---" {
+				return fmt.Errorf(`app spec #%d: arguments cannot contain "---"`, i)
+			}
+		}
+		if len(spec.Args) > 0 {
+			args = append(args, "--")
+			args = append(args, spec.Args...)
+			args = append(args, "---")
+		}
+		if err := parseApps(al, args, flags, true, true); err != nil {
+			return errwrap.Wrap(fmt.Errorf("invalid app spec #%d", i), err)
+		}
+		if al.Count() != n+i+1 {
+			return fmt.Errorf("app spec #%d must have exactly one image", i)
+		}
+	}
+
+	return nil
+}
+
 // Value interface implementations for the various per-app fields we provide flags for
 
-// appAsc is for aci --signature
+// appAsc is for aci --signature, it can be specified multiple times for
+// the same image, the image is accepted if any of the signatures is valid
 type appAsc apps.Apps
 
 func (aa *appAsc) Set(s string) error {
@@ -120,10 +181,7 @@ func (aa *appAsc) Set(s string) error {
 	if app == nil {
 		return fmt.Errorf("--signature must follow an image")
 	}
-	if app.Asc != "" {
-		return fmt.Errorf("--signature specified multiple times for the same image")
-	}
-	app.Asc = s
+	app.Asc = append(app.Asc, s)
 
 	return nil
 }
@@ -133,7 +191,7 @@ func (aa *appAsc) String() string {
 	if app == nil {
 		return ""
 	}
-	return app.Asc
+	return strings.Join(app.Asc, ",")
 }
 
 func (aa *appAsc) Type() string {
@@ -247,6 +305,42 @@ func (al *appsVolume) String() string {
 	return strings.Join(vs, " ")
 }
 
+// addDefaultVolumes injects the configured default volumes into the
+// apps as host volumes mounted in every app. Volumes whose name is
+// already used by a --volume flag are skipped. The mounts are global,
+// so per-app and earlier global mounts on the same target take
+// precedence over them when the pod manifest is generated.
+func addDefaultVolumes(al *apps.Apps, defaultVolumes []config.DefaultVolume) error {
+	for _, dv := range defaultVolumes {
+		name, err := types.NewACName(dv.Name)
+		if err != nil {
+			return fmt.Errorf("invalid default volume name %q: %v", dv.Name, err)
+		}
+		used := false
+		for _, v := range al.Volumes {
+			if v.Name == *name {
+				used = true
+				break
+			}
+		}
+		if used {
+			continue
+		}
+		readOnly := dv.ReadOnly
+		al.Volumes = append(al.Volumes, types.Volume{
+			Name:     *name,
+			Kind:     "host",
+			Source:   dv.Source,
+			ReadOnly: &readOnly,
+		})
+		al.Mounts = append(al.Mounts, schema.Mount{
+			Volume: *name,
+			Path:   dv.Target,
+		})
+	}
+	return nil
+}
+
 // appMountVolume is for CRI style per-app-volumes
 // this is a mount and volume in a single argument
 // It is exactly like --volume, but with a "target" param
@@ -526,6 +620,9 @@ func (au *appSeccompFilter) Set(s string) error {
 	if app == nil {
 		return fmt.Errorf("--seccomp must follow an image")
 	}
+	if _, _, _, err := (&apps.App{SeccompFilter: s}).SeccompOverride(); err != nil {
+		return err
+	}
 	app.SeccompFilter = s
 	return nil
 }
@@ -877,10 +974,11 @@ func (au *appStdout) Set(s string) error {
 		return fmt.Errorf("--stdout must follow an application")
 	}
 	allowedOutModes := map[string]apps.AppIO{
-		apps.AppIOLog.String():    apps.AppIOLog,
-		apps.AppIONull.String():   apps.AppIONull,
-		apps.AppIOStream.String(): apps.AppIOStream,
-		apps.AppIOTTY.String():    apps.AppIOTTY,
+		apps.AppIOJournal.String(): apps.AppIOJournal,
+		apps.AppIOLog.String():     apps.AppIOLog,
+		apps.AppIONull.String():    apps.AppIONull,
+		apps.AppIOStream.String():  apps.AppIOStream,
+		apps.AppIOTTY.String():     apps.AppIOTTY,
 	}
 	mode, ok := allowedOutModes[s]
 	if !ok {
@@ -911,10 +1009,11 @@ func (au *appStderr) Set(s string) error {
 		return fmt.Errorf("--stderr must follow an application")
 	}
 	allowedErrModes := map[string]apps.AppIO{
-		apps.AppIOLog.String():    apps.AppIOLog,
-		apps.AppIONull.String():   apps.AppIONull,
-		apps.AppIOStream.String(): apps.AppIOStream,
-		apps.AppIOTTY.String():    apps.AppIOTTY,
+		apps.AppIOJournal.String(): apps.AppIOJournal,
+		apps.AppIOLog.String():     apps.AppIOLog,
+		apps.AppIONull.String():    apps.AppIONull,
+		apps.AppIOStream.String():  apps.AppIOStream,
+		apps.AppIOTTY.String():     apps.AppIOTTY,
 	}
 	mode, ok := allowedErrModes[s]
 	if !ok {
@@ -935,3 +1034,86 @@ func (au *appStderr) String() string {
 func (au *appStderr) Type() string {
 	return "appStderr"
 }
+
+// appHealthCheck is for --health-check flags in the form of: --health-check="/path/to/check arg..."
+type appHealthCheck apps.Apps
+
+func (ah *appHealthCheck) Set(s string) error {
+	app := (*apps.Apps)(ah).Last()
+	if app == nil {
+		return fmt.Errorf("--health-check must follow an image")
+	}
+	cmd := strings.Fields(s)
+	if err := apps.ValidateHealthCheck(cmd); err != nil {
+		return err
+	}
+	app.HealthCheck = cmd
+	return nil
+}
+
+func (ah *appHealthCheck) String() string {
+	app := (*apps.Apps)(ah).Last()
+	if app == nil {
+		return ""
+	}
+	return strings.Join(app.HealthCheck, " ")
+}
+
+func (ah *appHealthCheck) Type() string {
+	return "appHealthCheck"
+}
+
+// appRestartPolicy is for --restart flags in the form of: --restart=OnFailure
+type appRestartPolicy apps.Apps
+
+func (ar *appRestartPolicy) Set(s string) error {
+	app := (*apps.Apps)(ar).Last()
+	if app == nil {
+		return fmt.Errorf("--restart must follow an image")
+	}
+	policy, err := apps.NewRestartPolicy(s)
+	if err != nil {
+		return err
+	}
+	app.RestartPolicy = policy
+	return nil
+}
+
+func (ar *appRestartPolicy) String() string {
+	app := (*apps.Apps)(ar).Last()
+	if app == nil {
+		return ""
+	}
+	return app.RestartPolicy.String()
+}
+
+func (ar *appRestartPolicy) Type() string {
+	return "appRestartPolicy"
+}
+
+// appUnitOption is for --unit-option flags in the form of: --unit-option=Service.LimitNOFILE=65536
+type appUnitOption apps.Apps
+
+func (au *appUnitOption) Set(s string) error {
+	app := (*apps.Apps)(au).Last()
+	if app == nil {
+		return fmt.Errorf("--unit-option must follow an image")
+	}
+	if _, _, _, err := apps.ParseUnitOption(s); err != nil {
+		return err
+	}
+	app.UnitOptions = append(app.UnitOptions, s)
+	return nil
+}
+
+func (au *appUnitOption) String() string {
+	app := (*apps.Apps)(au).Last()
+	if app == nil {
+		return ""
+	}
+	return strings.Join(app.UnitOptions, " ")
+}
+
+func (au *appUnitOption) Type() string {
+	return "appUnitOption"
+}
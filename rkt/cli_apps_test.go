@@ -15,10 +15,16 @@
 package main
 
 import (
+	"io/ioutil"
 	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/rkt/rkt/common/apps"
+	"github.com/rkt/rkt/rkt/config"
+	"github.com/rkt/rkt/stage0"
+
+	"github.com/appc/spec/schema"
 	"github.com/appc/spec/schema/types"
 	flag "github.com/spf13/pflag"
 )
@@ -75,7 +81,7 @@ func TestParseAppArgs(t *testing.T) {
 
 	for i, tt := range tests {
 		rktApps.Reset()
-		err := parseApps(&rktApps, strings.Split(tt.in, " "), flags, true)
+		err := parseApps(&rktApps, strings.Split(tt.in, " "), flags, true, false)
 		ga := rktApps.GetArgs()
 		gi := rktApps.GetImages()
 		if gerr := (err != nil); gerr != tt.werr {
@@ -91,6 +97,108 @@ func TestParseAppArgs(t *testing.T) {
 
 }
 
+func TestParseAppArgsStrictTripleDash(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ExitOnError)
+	flags.SetInterspersed(false)
+	tests := []struct {
+		in     string
+		strict bool
+		images []string
+		werr   bool
+	}{
+		{
+			"example.com/foo --- example.com/bar",
+			false,
+			[]string{"example.com/foo", "example.com/bar"},
+			false,
+		},
+		{
+			"example.com/foo --- example.com/bar",
+			true,
+			nil,
+			true,
+		},
+		{
+			"example.com/foo -- --help --- example.com/bar",
+			true,
+			[]string{"example.com/foo", "example.com/bar"},
+			false,
+		},
+		{
+			"example.com/foo -- --help --- --- example.com/bar",
+			true,
+			nil,
+			true,
+		},
+	}
+
+	for i, tt := range tests {
+		rktApps.Reset()
+		err := parseApps(&rktApps, strings.Split(tt.in, " "), flags, true, tt.strict)
+		if gerr := (err != nil); gerr != tt.werr {
+			t.Errorf("#%d: err==%v, want errstate %t", i, err, tt.werr)
+		}
+		if tt.werr {
+			continue
+		}
+		if gi := rktApps.GetImages(); !reflect.DeepEqual(gi, tt.images) {
+			t.Errorf("#%d: got images %v, want images %v", i, gi, tt.images)
+		}
+	}
+}
+
+func TestParseAppSignatures(t *testing.T) {
+	tests := []struct {
+		in   string
+		ascs [][]string
+	}{
+		{
+			"example.com/foo --signature=foo.asc",
+			[][]string{
+				{"foo.asc"},
+			},
+		},
+		{
+			"example.com/foo --signature=foo1.asc --signature=foo2.asc example.com/bar --signature=bar.asc",
+			[][]string{
+				{"foo1.asc", "foo2.asc"},
+				{"bar.asc"},
+			},
+		},
+		{
+			"example.com/foo example.com/bar --signature=bar1.asc --signature=bar2.asc",
+			[][]string{
+				nil,
+				{"bar1.asc", "bar2.asc"},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		rktApps.Reset()
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetInterspersed(false)
+		flags.Var((*appAsc)(&rktApps), "signature", "")
+		if err := parseApps(&rktApps, strings.Split(tt.in, " "), flags, true, false); err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		var ascs [][]string
+		rktApps.Walk(func(app *apps.App) error {
+			ascs = append(ascs, app.Asc)
+			return nil
+		})
+		if !reflect.DeepEqual(ascs, tt.ascs) {
+			t.Errorf("#%d: got signatures %v, want signatures %v", i, ascs, tt.ascs)
+		}
+	}
+
+	rktApps.Reset()
+	if err := (*appAsc)(&rktApps).Set("foo.asc"); err == nil {
+		t.Errorf("expected an error for a signature not following an image")
+	}
+}
+
 func TestParsePortFlag(t *testing.T) {
 	tests := []struct {
 		in  string
@@ -147,3 +255,199 @@ func TestParsePortFlag(t *testing.T) {
 		}
 	}
 }
+
+func TestParseSeccompFlag(t *testing.T) {
+	tests := []struct {
+		in  string
+		err bool
+	}{
+		{"mode=retain,chown,chmod", false},
+		{"mode=remove,errno=EPERM,reboot", false},
+		{"mode=retain,@rkt/default-whitelist,_llseek", false},
+		{"chown,chmod", true},
+		{"mode=keep,chown", true},
+		{"mode=remove,foo=bar", true},
+		{"mode=remove,Reboot", true},
+		{"mode=remove,reboot,", true},
+		{"mode=retain,@", true},
+	}
+
+	for _, tt := range tests {
+		var al apps.Apps
+		al.Create("example.com/app")
+		err := (*appSeccompFilter)(&al).Set(tt.in)
+		if err != nil {
+			if !tt.err {
+				t.Errorf("%q failed to parse: %v", tt.in, err)
+			}
+			continue
+		}
+		if tt.err {
+			t.Errorf("%q unexpectedly parsed", tt.in)
+			continue
+		}
+		if got := al.Last().SeccompFilter; got != tt.in {
+			t.Errorf("%q parsed into %q", tt.in, got)
+		}
+	}
+}
+
+func TestParseUnitOptionFlag(t *testing.T) {
+	tests := []struct {
+		in  string
+		err bool
+	}{
+		{"Service.LimitNOFILE=65536", false},
+		{"Service.KillSignal=SIGINT", false},
+		{"Service.LimitNOFILE", true},
+		{"Service.LimitNOFILE=", true},
+		{"LimitNOFILE=65536", true},
+		{".LimitNOFILE=65536", true},
+		{"Service.ExecStartPre=/bin/sh", true},
+		{"Service.RootDirectory=/", true},
+		{"Unit.Description=foo", true},
+		{"Service.LimitNOFILE=1\nExecStartPre=/bin/sh", true},
+	}
+
+	for _, tt := range tests {
+		var al apps.Apps
+		al.Create("example.com/app")
+		err := (*appUnitOption)(&al).Set(tt.in)
+		if err != nil {
+			if !tt.err {
+				t.Errorf("%q failed to parse: %v", tt.in, err)
+			}
+			continue
+		}
+		if tt.err {
+			t.Errorf("%q unexpectedly parsed", tt.in)
+			continue
+		}
+		if got := al.Last().UnitOptions; len(got) != 1 || got[0] != tt.in {
+			t.Errorf("%q parsed into %q", tt.in, got)
+		}
+	}
+}
+
+func TestAddDefaultVolumes(t *testing.T) {
+	defaultVolumes := []config.DefaultVolume{
+		{Name: "ca-certs", Source: "/etc/ssl/certs", Target: "/etc/ssl/certs", ReadOnly: true},
+		{Name: "timezone", Source: "/etc/localtime", Target: "/etc/localtime", ReadOnly: true},
+	}
+
+	var al apps.Apps
+	tzVol, err := types.VolumeFromString("timezone,kind=empty")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	al.Volumes = append(al.Volumes, *tzVol)
+	al.Create("example.com/app1")
+	al.Create("example.com/app2")
+	al.Last().Mounts = append(al.Last().Mounts, schema.Mount{
+		Volume: *types.MustACName("certs"),
+		Path:   "/etc/ssl/certs/",
+	})
+
+	if err := addDefaultVolumes(&al, defaultVolumes); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the timezone volume is already given with --volume
+	if len(al.Volumes) != 2 {
+		t.Fatalf("expected 2 volumes, got %v", al.Volumes)
+	}
+	vol := al.Volumes[1]
+	if vol.Name.String() != "ca-certs" || vol.Kind != "host" || vol.Source != "/etc/ssl/certs" || vol.ReadOnly == nil || !*vol.ReadOnly {
+		t.Errorf("unexpected default volume %v", vol)
+	}
+	expectedMounts := []schema.Mount{
+		{Volume: *types.MustACName("ca-certs"), Path: "/etc/ssl/certs"},
+	}
+	if !reflect.DeepEqual(al.Mounts, expectedMounts) {
+		t.Errorf("expected mounts %v, got %v", expectedMounts, al.Mounts)
+	}
+
+	// an app without a mount on the target gets the default volume,
+	// an explicit app mount on the same target suppresses it
+	expectedVolumes := map[string]string{
+		"example.com/app1": "ca-certs",
+		"example.com/app2": "certs",
+	}
+	al.Walk(func(app *apps.App) error {
+		mounts := stage0.MergeMounts(al.Mounts, app.Mounts)
+		if len(mounts) != 1 || mounts[0].Volume.String() != expectedVolumes[app.Image] {
+			t.Errorf("expected app %q to mount the %q volume, got %v", app.Image, expectedVolumes[app.Image], mounts)
+		}
+		return nil
+	})
+}
+
+func TestParseAppSpecs(t *testing.T) {
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetInterspersed(false)
+	flags.SetOutput(ioutil.Discard)
+	flags.Var((*appName)(&rktApps), "name", "")
+	flags.Var((*appExec)(&rktApps), "exec", "")
+
+	tests := []struct {
+		in     string
+		images []string
+		names  []string
+		args   [][]string
+		werr   bool
+	}{
+		{
+			`[{"image": "example.com/foo", "flags": ["--name=foo"]}, {"image": "example.com/bar", "flags": ["--name=bar", "--exec=/bin/bar"], "args": ["--help", "--"]}]`,
+			[]string{"example.com/foo", "example.com/bar"},
+			[]string{"foo", "bar"},
+			[][]string{nil, {"--help", "--"}},
+			false,
+		},
+		{
+			`[{"image": "example.com/foo"}]`,
+			[]string{"example.com/foo"},
+			[]string{""},
+			[][]string{nil},
+			false,
+		},
+		// no apps
+		{`[]`, nil, nil, nil, true},
+		// invalid JSON
+		{`{"image": "example.com/foo"}`, nil, nil, nil, true},
+		// no image
+		{`[{"flags": ["--name=foo"]}]`, nil, nil, nil, true},
+		// an image hidden in the flags
+		{`[{"image": "example.com/foo", "flags": ["example.com/bar"]}]`, nil, nil, nil, true},
+		// unknown flag
+		{`[{"image": "example.com/foo", "flags": ["--unknown=foo"]}]`, nil, nil, nil, true},
+		// the app arguments terminator
+		{`[{"image": "example.com/foo", "args": ["---", "example.com/bar"]}]`, nil, nil, nil, true},
+	}
+
+	for i, tt := range tests {
+		rktApps.Reset()
+		err := parseAppSpecs(&rktApps, strings.NewReader(tt.in), flags)
+		if gerr := (err != nil); gerr != tt.werr {
+			t.Errorf("#%d: err==%v, want errstate %t", i, err, tt.werr)
+			continue
+		}
+		if tt.werr {
+			continue
+		}
+		if gi := rktApps.GetImages(); !reflect.DeepEqual(gi, tt.images) {
+			t.Errorf("#%d: got images %v, want images %v", i, gi, tt.images)
+		}
+		if ga := rktApps.GetArgs(); !reflect.DeepEqual(ga, tt.args) {
+			t.Errorf("#%d: got args %v, want args %v", i, ga, tt.args)
+		}
+		var names []string
+		rktApps.Walk(func(app *apps.App) error {
+			names = append(names, app.Name)
+			return nil
+		})
+		if !reflect.DeepEqual(names, tt.names) {
+			t.Errorf("#%d: got names %v, want names %v", i, names, tt.names)
+		}
+	}
+	rktApps.Reset()
+}
@@ -58,6 +58,10 @@ type awsV1 struct {
 	Region          string `json:"awsRegion"`
 }
 
+// defaultAuthDomain is the domain of the auth used for the hosts
+// without a specific entry.
+const defaultAuthDomain = "*"
+
 type dockerAuthV1JsonParser struct{}
 
 type dockerAuthV1 struct {
@@ -195,6 +199,13 @@ func (p *authV1JsonParser) parse(config *Config, raw []byte) error {
 		return err
 	}
 	for _, domain := range auth.Domains {
+		if domain == defaultAuthDomain {
+			if config.DefaultAuth != nil {
+				return fmt.Errorf("default auth is already specified")
+			}
+			config.DefaultAuth = headerer
+			continue
+		}
 		if _, ok := config.AuthPerHost[domain]; ok {
 			return fmt.Errorf("auth for domain %q is already specified", domain)
 		}
@@ -15,18 +15,22 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/rkt/rkt/common"
+	rktlog "github.com/rkt/rkt/pkg/log"
 )
 
 // Headerer is an interface for getting additional HTTP headers to use
@@ -47,6 +51,10 @@ type BasicCredentials struct {
 type ConfigurablePaths struct {
 	DataDir         string
 	Stage1ImagesDir string
+	// Check tells how to report the paths being nested or
+	// pointing to a system directory: "warn" (the default),
+	// "error" or "none".
+	Check string
 }
 
 // Stage1 holds name, version and location of a default stage1 image
@@ -55,6 +63,34 @@ type Stage1Data struct {
 	Name     string
 	Version  string
 	Location string
+	// FallbackToBuiltin tells whether to use the built-in stage1
+	// image when the configured one can not be used.
+	FallbackToBuiltin bool
+
+	// fallbackSet tells whether FallbackToBuiltin was specified,
+	// so it can be overridden with false
+	fallbackSet bool
+}
+
+// FetchPolicy holds the settings for downloading images and
+// signatures over HTTP(S). Zero values mean no timeout and no
+// retries.
+type FetchPolicy struct {
+	// Timeout limits the time spent on establishing a connection
+	// and on waiting for the response headers.
+	Timeout time.Duration
+	// Retries is the number of times a download is retried after
+	// a transient failure.
+	Retries int
+}
+
+// DefaultVolume is a host directory or file injected as a volume into
+// every pod, and mounted in every app which has no mount on Target.
+type DefaultVolume struct {
+	Name     string
+	Source   string
+	Target   string
+	ReadOnly bool
 }
 
 // Config is a single place where configuration for rkt frontend needs
@@ -64,13 +100,42 @@ type Config struct {
 	DockerCredentialsPerRegistry map[string]BasicCredentials
 	Paths                        ConfigurablePaths
 	Stage1                       Stage1Data
+	// DefaultInsecureOptions are the insecure options used when
+	// none are passed with --insecure-options.
+	DefaultInsecureOptions []string
+	// RequireSignature are the prefixes of the image names which
+	// are always verified, the image check can not be disabled
+	// for them with --insecure-options.
+	RequireSignature []string
+	// ProxyPerHost maps hosts to the proxies used for fetching
+	// from them, a nil URL means a direct connection.
+	ProxyPerHost map[string]*url.URL
+	// RateLimitPerHost maps hosts to the maximum number of requests
+	// per second sent to them when fetching.
+	RateLimitPerHost map[string]float64
+	// FetchPolicy holds the timeout and retry settings for image
+	// downloads.
+	FetchPolicy FetchPolicy
+	// DefaultAuth is used for the hosts without an entry in
+	// AuthPerHost, it comes from the "*" domain.
+	DefaultAuth Headerer
+	// DefaultVolumes are injected into every prepared pod.
+	DefaultVolumes []DefaultVolume
 }
 
 // MarshalJSON marshals the config for user output.
 func (c *Config) MarshalJSON() ([]byte, error) {
 	stage0 := []interface{}{}
 
+	auths := make(map[string]Headerer, len(c.AuthPerHost)+1)
 	for host, auth := range c.AuthPerHost {
+		auths[host] = auth
+	}
+	if c.DefaultAuth != nil {
+		auths[defaultAuthDomain] = c.DefaultAuth
+	}
+
+	for host, auth := range auths {
 		var typ string
 		var credentials interface{}
 
@@ -126,38 +191,148 @@ func (c *Config) MarshalJSON() ([]byte, error) {
 		RktKind      string `json:"rktKind"`
 		Data         string `json:"data"`
 		Stage1Images string `json:"stage1-images"`
+		PathsCheck   string `json:"paths-check,omitempty"`
 	}{
 		RktVersion:   "v1",
 		RktKind:      "paths",
 		Data:         c.Paths.DataDir,
 		Stage1Images: c.Paths.Stage1ImagesDir,
+		PathsCheck:   c.Paths.Check,
 	}
 
 	stage1 := struct {
+		RktVersion        string `json:"rktVersion"`
+		RktKind           string `json:"rktKind"`
+		Name              string `json:"name"`
+		Version           string `json:"version"`
+		Location          string `json:"location"`
+		FallbackToBuiltin bool   `json:"fallbackToBuiltin,omitempty"`
+	}{
+		RktVersion:        "v1",
+		RktKind:           "stage1",
+		Name:              c.Stage1.Name,
+		Version:           c.Stage1.Version,
+		Location:          c.Stage1.Location,
+		FallbackToBuiltin: c.Stage1.FallbackToBuiltin,
+	}
+
+	for host, proxyURL := range c.ProxyPerHost {
+		proxy := proxyDirect
+		if proxyURL != nil {
+			proxy = proxyURL.String()
+		}
+
+		proxyCfg := struct {
+			RktVersion string   `json:"rktVersion"`
+			RktKind    string   `json:"rktKind"`
+			Domains    []string `json:"domains"`
+			Proxy      string   `json:"proxy"`
+		}{
+			RktVersion: "v1",
+			RktKind:    "proxy",
+			Domains:    []string{host},
+			Proxy:      proxy,
+		}
+
+		stage0 = append(stage0, proxyCfg)
+	}
+
+	for host, rate := range c.RateLimitPerHost {
+		rateLimitCfg := struct {
+			RktVersion        string   `json:"rktVersion"`
+			RktKind           string   `json:"rktKind"`
+			Domains           []string `json:"domains"`
+			RequestsPerSecond float64  `json:"requestsPerSecond"`
+		}{
+			RktVersion:        "v1",
+			RktKind:           "rateLimit",
+			Domains:           []string{host},
+			RequestsPerSecond: rate,
+		}
+
+		stage0 = append(stage0, rateLimitCfg)
+	}
+
+	images := struct {
+		RktVersion       string   `json:"rktVersion"`
+		RktKind          string   `json:"rktKind"`
+		InsecureOptions  []string `json:"insecureOptions,omitempty"`
+		RequireSignature []string `json:"requireSignature,omitempty"`
+	}{
+		RktVersion:       "v1",
+		RktKind:          "images",
+		InsecureOptions:  c.DefaultInsecureOptions,
+		RequireSignature: c.RequireSignature,
+	}
+
+	var timeout string
+	if c.FetchPolicy.Timeout > 0 {
+		timeout = c.FetchPolicy.Timeout.String()
+	}
+	fetch := struct {
 		RktVersion string `json:"rktVersion"`
 		RktKind    string `json:"rktKind"`
-		Name       string `json:"name"`
-		Version    string `json:"version"`
-		Location   string `json:"location"`
+		Timeout    string `json:"timeout,omitempty"`
+		Retries    int    `json:"retries,omitempty"`
 	}{
 		RktVersion: "v1",
-		RktKind:    "stage1",
-		Name:       c.Stage1.Name,
-		Version:    c.Stage1.Version,
-		Location:   c.Stage1.Location,
+		RktKind:    "fetch",
+		Timeout:    timeout,
+		Retries:    c.FetchPolicy.Retries,
 	}
 
-	stage0 = append(stage0, paths, stage1)
+	stage0 = append(stage0, paths, stage1, images, fetch)
+
+	if len(c.DefaultVolumes) > 0 {
+		type volume struct {
+			Name     string `json:"name"`
+			Source   string `json:"source"`
+			Target   string `json:"target"`
+			ReadOnly bool   `json:"readOnly,omitempty"`
+		}
+		vols := make([]volume, 0, len(c.DefaultVolumes))
+		for _, v := range c.DefaultVolumes {
+			vols = append(vols, volume(v))
+		}
+		volumes := struct {
+			RktVersion string   `json:"rktVersion"`
+			RktKind    string   `json:"rktKind"`
+			Volumes    []volume `json:"volumes"`
+		}{
+			RktVersion: "v1",
+			RktKind:    "volumes",
+			Volumes:    vols,
+		}
+
+		stage0 = append(stage0, volumes)
+	}
 
 	data := map[string]interface{}{"stage0": stage0}
 	return json.Marshal(data)
 }
 
+// configParser parses a configuration file of some kind and version
+// into the passed config. A single parser instance is registered for
+// each kind and version and it is shared by all the callers, so it
+// must keep no state of its own.
 type configParser interface {
 	parse(config *Config, raw []byte) error
 }
 
+// disabledMarker is a name of a file which, when present in a
+// configuration subdirectory, makes rkt ignore the whole
+// subdirectory.
+const disabledMarker = ".disabled"
+
+// utf8BOM is the UTF-8 byte order mark, which some editors put at the
+// beginning of the files.
+var utf8BOM = []byte{0xef, 0xbb, 0xbf}
+
 var (
+	// stderr is used for reporting the skipped configuration
+	// subdirectories.
+	stderr = rktlog.New(os.Stderr, "config", false)
+
 	// configSubDirs is a map saying what kinds of configuration
 	// (values) are acceptable in a config subdirectory (key)
 	configSubDirs  = make(map[string][]string)
@@ -174,6 +349,30 @@ func ResolveAuthPerHost(authPerHost map[string]Headerer) map[string]http.Header
 	return hostHeaders
 }
 
+// ResolveAuthForApp is like ResolveAuthPerHost, but it also resolves
+// defaultAuth for the host of the app name if that host has no entry
+// in authPerHost. It is meant for the discovery.
+func ResolveAuthForApp(authPerHost map[string]Headerer, defaultAuth Headerer, appName string) map[string]http.Header {
+	hostHeaders := ResolveAuthPerHost(authPerHost)
+	if defaultAuth == nil {
+		return hostHeaders
+	}
+	host := strings.SplitN(appName, "/", 2)[0]
+	if _, ok := hostHeaders[host]; !ok {
+		hostHeaders[host] = defaultAuth.GetHeader()
+	}
+	return hostHeaders
+}
+
+// HeadererForHost returns the Headerer for the given host from
+// authPerHost, or defaultAuth if the host has no entry there.
+func HeadererForHost(authPerHost map[string]Headerer, defaultAuth Headerer, host string) Headerer {
+	if headerer, ok := authPerHost[host]; ok {
+		return headerer
+	}
+	return defaultAuth
+}
+
 func addParser(kind, version string, parser configParser) {
 	if len(kind) == 0 {
 		panic("empty kind string when registering a config parser")
@@ -231,7 +430,9 @@ func GetConfig() (*Config, error) {
 
 // GetConfigFrom gets the Config instance with configuration taken
 // from given paths. Subsequent paths override settings from the
-// previous paths.
+// previous paths. Every call builds a new Config, so it is safe to
+// call it concurrently, e.g. to reload the configuration while the
+// previous one is still in use.
 func GetConfigFrom(dirs ...string) (*Config, error) {
 	cfg := newConfig()
 	for _, cd := range dirs {
@@ -241,6 +442,9 @@ func GetConfigFrom(dirs ...string) (*Config, error) {
 		}
 		mergeConfigs(cfg, subcfg)
 	}
+	if err := checkPaths(cfg.Paths); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
@@ -263,6 +467,8 @@ func newConfig() *Config {
 	return &Config{
 		AuthPerHost:                  make(map[string]Headerer),
 		DockerCredentialsPerRegistry: make(map[string]BasicCredentials),
+		ProxyPerHost:                 make(map[string]*url.URL),
+		RateLimitPerHost:             make(map[string]float64),
 		Paths: ConfigurablePaths{
 			DataDir: "",
 		},
@@ -277,6 +483,12 @@ func readConfigDir(config *Config, dir string) error {
 		} else if !valid {
 			continue
 		}
+		if disabled, err := disabledDir(d); err != nil {
+			return err
+		} else if disabled {
+			stderr.Printf("skipping %q, it contains a %q file", d, disabledMarker)
+			continue
+		}
 		configWalker := getConfigWalker(config, kinds, d)
 		if err := filepath.Walk(d, configWalker); err != nil {
 			return err
@@ -285,6 +497,18 @@ func readConfigDir(config *Config, dir string) error {
 	return nil
 }
 
+// disabledDir checks if the configuration subdirectory contains the
+// disabledMarker file.
+func disabledDir(dir string) (bool, error) {
+	if _, err := os.Lstat(filepath.Join(dir, disabledMarker)); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
 func validDir(path string) (bool, error) {
 	fi, err := os.Stat(path)
 	if err != nil {
@@ -343,14 +567,37 @@ type configHeader struct {
 	RktKind    string `json:"rktKind"`
 }
 
+// GetConfigFromBytes gets the Config instance with configuration
+// taken from the passed contents of a single configuration file, as
+// if it was read from the given configuration subdirectory
+// (e.g. "auth.d").
+func GetConfigFromBytes(subdir string, raw []byte) (*Config, error) {
+	kinds, ok := configSubDirs[subdir]
+	if !ok {
+		return nil, fmt.Errorf("unknown configuration subdirectory %q", subdir)
+	}
+	cfg := newConfig()
+	if err := parseConfigBytes(cfg, raw, filepath.Join(subdir, "<bytes>"), kinds); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
 func parseConfigFile(config *Config, path string, kinds []string) error {
 	raw, err := ioutil.ReadFile(path)
 	if err != nil {
 		return err
 	}
+	return parseConfigBytes(config, raw, path, kinds)
+}
+
+// parseConfigBytes parses the raw contents of a configuration file,
+// path is used only for error messages.
+func parseConfigBytes(config *Config, raw []byte, path string, kinds []string) error {
+	raw = bytes.TrimPrefix(raw, utf8BOM)
 	var header configHeader
 	if err := json.Unmarshal(raw, &header); err != nil {
-		return err
+		return describeJSONError(raw, path, err)
 	}
 	if len(header.RktKind) == 0 {
 		return fmt.Errorf("no rktKind specified in %q", path)
@@ -381,6 +628,29 @@ func parseConfigFile(config *Config, path string, kinds []string) error {
 	return nil
 }
 
+// describeJSONError turns a JSON syntax error into one pointing at
+// the line and column of the problem, with a hint for the common
+// mistake of a trailing comma.
+func describeJSONError(raw []byte, path string, err error) error {
+	syntaxErr, ok := err.(*json.SyntaxError)
+	if !ok {
+		return errwrap.Wrap(fmt.Errorf("failed to parse %q", path), err)
+	}
+	offset := int(syntaxErr.Offset)
+	if offset > len(raw) {
+		offset = len(raw)
+	}
+	line := 1 + bytes.Count(raw[:offset], []byte("\n"))
+	column := offset - bytes.LastIndex(raw[:offset], []byte("\n")) - 1
+	hint := ""
+	if offset > 0 && (raw[offset-1] == '}' || raw[offset-1] == ']') {
+		if prev := bytes.TrimRight(raw[:offset-1], " \t\r\n"); len(prev) > 0 && prev[len(prev)-1] == ',' {
+			hint = " (trailing commas are not allowed)"
+		}
+	}
+	return fmt.Errorf("invalid JSON in %q at line %d, column %d: %v%s", path, line, column, syntaxErr, hint)
+}
+
 func getParser(kind, version string) (configParser, error) {
 	parsers, ok := parsersForKind[kind]
 	if !ok {
@@ -397,6 +667,9 @@ func mergeConfigs(config *Config, subconfig *Config) {
 	for host, headerer := range subconfig.AuthPerHost {
 		config.AuthPerHost[host] = headerer
 	}
+	if subconfig.DefaultAuth != nil {
+		config.DefaultAuth = subconfig.DefaultAuth
+	}
 	for registry, creds := range subconfig.DockerCredentialsPerRegistry {
 		config.DockerCredentialsPerRegistry[registry] = creds
 	}
@@ -406,6 +679,9 @@ func mergeConfigs(config *Config, subconfig *Config) {
 	if subconfig.Paths.Stage1ImagesDir != "" {
 		config.Paths.Stage1ImagesDir = subconfig.Paths.Stage1ImagesDir
 	}
+	if subconfig.Paths.Check != "" {
+		config.Paths.Check = subconfig.Paths.Check
+	}
 	if subconfig.Stage1.Name != "" {
 		config.Stage1.Name = subconfig.Stage1.Name
 		config.Stage1.Version = subconfig.Stage1.Version
@@ -413,4 +689,29 @@ func mergeConfigs(config *Config, subconfig *Config) {
 	if subconfig.Stage1.Location != "" {
 		config.Stage1.Location = subconfig.Stage1.Location
 	}
+	if subconfig.Stage1.fallbackSet {
+		config.Stage1.FallbackToBuiltin = subconfig.Stage1.FallbackToBuiltin
+		config.Stage1.fallbackSet = true
+	}
+	for host, proxyURL := range subconfig.ProxyPerHost {
+		config.ProxyPerHost[host] = proxyURL
+	}
+	for host, rate := range subconfig.RateLimitPerHost {
+		config.RateLimitPerHost[host] = rate
+	}
+	if len(subconfig.DefaultInsecureOptions) > 0 {
+		config.DefaultInsecureOptions = subconfig.DefaultInsecureOptions
+	}
+	// the prefixes are added up, so a directory can not lift the
+	// requirements of the other ones
+	for _, prefix := range subconfig.RequireSignature {
+		config.RequireSignature = appendPrefix(config.RequireSignature, prefix)
+	}
+	if subconfig.FetchPolicy.Timeout > 0 {
+		config.FetchPolicy.Timeout = subconfig.FetchPolicy.Timeout
+	}
+	if subconfig.FetchPolicy.Retries > 0 {
+		config.FetchPolicy.Retries = subconfig.FetchPolicy.Retries
+	}
+	config.DefaultVolumes = mergeDefaultVolumes(config.DefaultVolumes, subconfig.DefaultVolumes)
 }
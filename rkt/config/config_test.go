@@ -15,14 +15,22 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/hashicorp/errwrap"
+	"github.com/rkt/rkt/pkg/log"
 )
 
 const tstprefix = "config-test"
@@ -92,6 +100,76 @@ func TestAuthConfigFormat(t *testing.T) {
 	}
 }
 
+func TestDefaultAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create temporary directory: %v", err))
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		filepath.Join("auth.d", "default.json"): `{"rktKind": "auth", "rktVersion": "v1", "domains": ["*"], "type": "oauth", "credentials": {"token": "defaulttoken"}}`,
+		filepath.Join("auth.d", "coreos.json"):  `{"rktKind": "auth", "rktVersion": "v1", "domains": ["coreos.com"], "type": "basic", "credentials": {"user": "bar", "password": "baz"}}`,
+	}
+	for file, contents := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			panic(fmt.Sprintf("Failed to create directory %q: %v", filepath.Dir(path), err))
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			panic(fmt.Sprintf("Failed to write file %q: %v", path, err))
+		}
+	}
+
+	cfg, err := GetConfigFrom(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, ok := cfg.AuthPerHost["*"]; ok {
+		t.Errorf("Expected the default auth not to be stored per host")
+	}
+
+	tests := []struct {
+		host     string
+		expected string
+	}{
+		{"example.com", "Bearer defaulttoken"},
+		{"coreos.com", "Basic YmFyOmJheg=="},
+	}
+	for _, tt := range tests {
+		headerer := HeadererForHost(cfg.AuthPerHost, cfg.DefaultAuth, tt.host)
+		if headerer == nil {
+			t.Errorf("Expected auth for host %q, got none", tt.host)
+			continue
+		}
+		if result := headerer.GetHeader().Get("Authorization"); result != tt.expected {
+			t.Errorf("Expected %q auth for host %q, got %q", tt.expected, tt.host, result)
+		}
+	}
+
+	hostHeaders := ResolveAuthForApp(cfg.AuthPerHost, cfg.DefaultAuth, "example.com/app")
+	if result := hostHeaders["example.com"].Get("Authorization"); result != "Bearer defaulttoken" {
+		t.Errorf("Expected the default auth for the app host, got %q", result)
+	}
+
+	if HeadererForHost(cfg.AuthPerHost, nil, "example.com") != nil {
+		t.Errorf("Expected no auth for an unlisted host without a default")
+	}
+
+	raw, err := json.Marshal(cfg)
+	if err != nil {
+		t.Fatalf("Unexpected error marshaling config: %v", err)
+	}
+	if !bytes.Contains(raw, []byte(`"domains":["*"]`)) {
+		t.Errorf("Expected the default auth in the marshaled config, got %s", raw)
+	}
+
+	duplicate := `{"rktKind": "auth", "rktVersion": "v1", "domains": ["*", "*"], "type": "oauth", "credentials": {"token": "sometoken"}}`
+	if _, err := getConfigFromContents(duplicate, "auth"); err == nil {
+		t.Errorf("Expected an error for the default auth specified twice")
+	}
+}
+
 func TestDockerAuthConfigFormat(t *testing.T) {
 	tests := []struct {
 		contents string
@@ -156,6 +234,115 @@ func TestPathsConfigFormat(t *testing.T) {
 	}
 }
 
+func TestPathsConfigVariables(t *testing.T) {
+	for name, value := range map[string]string{"HOME": "/home/rkt-test", "USER": "rkt-test"} {
+		old, set := os.LookupEnv(name)
+		os.Setenv(name, value)
+		if set {
+			defer os.Setenv(name, old)
+		} else {
+			defer os.Unsetenv(name)
+		}
+	}
+
+	tests := []struct {
+		contents string
+		expected ConfigurablePaths
+		fail     bool
+	}{
+		{`{"rktKind": "paths", "rktVersion": "v1", "data": "${HOME}/.rkt"}`, ConfigurablePaths{DataDir: "/home/rkt-test/.rkt"}, false},
+		{`{"rktKind": "paths", "rktVersion": "v1", "data": "/var/lib/rkt/${USER}", "stage1-images": "${HOME}/stage1"}`, ConfigurablePaths{DataDir: "/var/lib/rkt/rkt-test", Stage1ImagesDir: "/home/rkt-test/stage1"}, false},
+		{`{"rktKind": "paths", "rktVersion": "v1", "data": "/var/lib/$rkt"}`, ConfigurablePaths{DataDir: "/var/lib/$rkt"}, false},
+		{`{"rktKind": "paths", "rktVersion": "v1", "data": "${USER}/.rkt"}`, ConfigurablePaths{}, true},
+		{`{"rktKind": "paths", "rktVersion": "v1", "data": "${PWD}/.rkt"}`, ConfigurablePaths{}, true},
+		{`{"rktKind": "paths", "rktVersion": "v1", "stage1-images": "${HOME/stage1"}`, ConfigurablePaths{}, true},
+	}
+	for _, tt := range tests {
+		cfg, err := getConfigFromContents(tt.contents, "paths")
+		if vErr := verifyFailure(tt.fail, tt.contents, err); vErr != nil {
+			t.Errorf("%v", vErr)
+		} else if !tt.fail {
+			result := cfg.Paths
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Got unexpected results\nResult:\n%#v\n\nExpected:\n%#v", result, tt.expected)
+			}
+		}
+	}
+}
+
+func TestPathsCheck(t *testing.T) {
+	var warnings bytes.Buffer
+	oldStderr := stderr
+	stderr = log.New(&warnings, "config", false)
+	defer func() { stderr = oldStderr }()
+
+	tests := []struct {
+		paths    ConfigurablePaths
+		problems int
+	}{
+		{ConfigurablePaths{}, 0},
+		{ConfigurablePaths{DataDir: "/var/lib/rkt", Stage1ImagesDir: "/usr/lib/rkt/stage1-images"}, 0},
+		{ConfigurablePaths{DataDir: "/var/lib/rkt", Stage1ImagesDir: "/var/lib/rkt-stage1"}, 0},
+		// nested
+		{ConfigurablePaths{DataDir: "/var/lib/rkt", Stage1ImagesDir: "/var/lib/rkt/stage1"}, 1},
+		{ConfigurablePaths{DataDir: "/var/lib/rkt/data/", Stage1ImagesDir: "/var/lib/rkt"}, 1},
+		{ConfigurablePaths{DataDir: "/var/lib/rkt", Stage1ImagesDir: "/var/lib/rkt/"}, 1},
+		// dangerous roots
+		{ConfigurablePaths{DataDir: "/"}, 1},
+		{ConfigurablePaths{Stage1ImagesDir: "/usr/"}, 1},
+		{ConfigurablePaths{DataDir: "/", Stage1ImagesDir: "/usr"}, 3},
+	}
+	for i, tt := range tests {
+		if problems := pathsProblems(tt.paths); len(problems) != tt.problems {
+			t.Errorf("#%d: expected %d problems, got %v", i, tt.problems, problems)
+		}
+
+		warnings.Reset()
+		if err := checkPaths(tt.paths); err != nil {
+			t.Errorf("#%d: unexpected error with the default severity: %v", i, err)
+		}
+		if warned := warnings.Len() > 0; warned != (tt.problems > 0) {
+			t.Errorf("#%d: expected a warning: %v, got %q", i, tt.problems > 0, warnings.String())
+		}
+
+		tt.paths.Check = pathsCheckError
+		if err := checkPaths(tt.paths); (err != nil) != (tt.problems > 0) {
+			t.Errorf("#%d: expected an error: %v, got %v", i, tt.problems > 0, err)
+		}
+
+		warnings.Reset()
+		tt.paths.Check = pathsCheckNone
+		if err := checkPaths(tt.paths); err != nil || warnings.Len() > 0 {
+			t.Errorf("#%d: expected no error and no warning, got %v and %q", i, err, warnings.String())
+		}
+	}
+
+	// the severity and the paths may come from different directories
+	dir, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create temporary directory: %v", err))
+	}
+	defer os.RemoveAll(dir)
+	for confDir, contents := range map[string]string{
+		"system": `{"rktKind": "paths", "rktVersion": "v1", "data": "/var/lib/rkt", "paths-check": "error"}`,
+		"local":  `{"rktKind": "paths", "rktVersion": "v1", "stage1-images": "/var/lib/rkt/stage1-images"}`,
+	} {
+		d := filepath.Join(dir, confDir, "paths.d")
+		if err := os.MkdirAll(d, 0700); err != nil {
+			panic(fmt.Sprintf("Failed to create configuration directory %q: %v", d, err))
+		}
+		if err := ioutil.WriteFile(filepath.Join(d, "paths.json"), []byte(contents), 0600); err != nil {
+			panic(fmt.Sprintf("Failed to write configuration file: %v", err))
+		}
+	}
+	if _, err := GetConfigFrom(filepath.Join(dir, "system"), filepath.Join(dir, "local")); err == nil {
+		t.Errorf("Expected an error for nested paths with the error severity")
+	}
+	if _, err := getConfigFromContents(`{"rktKind": "paths", "rktVersion": "v1", "paths-check": "fatal"}`, "paths"); err == nil {
+		t.Errorf("Expected an error for an invalid paths check")
+	}
+}
+
 func TestStage1ConfigFormat(t *testing.T) {
 	tests := []struct {
 		contents string
@@ -174,6 +361,9 @@ func TestStage1ConfigFormat(t *testing.T) {
 		{`{"rktKind": "stage1", "rktVersion": "v1", "name": "example.com/stage1", "location": "/image.aci"}`, Stage1Data{}, true},
 		{`{"rktKind": "stage1", "rktVersion": "v1", "version": "1.2.3", "location": "/image.aci"}`, Stage1Data{}, true},
 		{`{"rktKind": "stage1", "rktVersion": "v1", "name": "example.com/stage1", "version": "1.2.3", "location": "/image.aci"}`, Stage1Data{Name: "example.com/stage1", Version: "1.2.3", Location: "/image.aci"}, false},
+		{`{"rktKind": "stage1", "rktVersion": "v1", "fallbackToBuiltin": "yes"}`, Stage1Data{}, true},
+		{`{"rktKind": "stage1", "rktVersion": "v1", "location": "/image.aci", "fallbackToBuiltin": true}`, Stage1Data{Location: "/image.aci", FallbackToBuiltin: true, fallbackSet: true}, false},
+		{`{"rktKind": "stage1", "rktVersion": "v1", "fallbackToBuiltin": false}`, Stage1Data{fallbackSet: true}, false},
 	}
 	for _, tt := range tests {
 		cfg, err := getConfigFromContents(tt.contents, "stage1")
@@ -188,6 +378,648 @@ func TestStage1ConfigFormat(t *testing.T) {
 	}
 }
 
+func TestImagesConfigFormat(t *testing.T) {
+	tests := []struct {
+		contents string
+		expected []string
+		fail     bool
+	}{
+		{`{"rktKind": "images", "rktVersion": "foo"}`, nil, true},
+		{`{"rktKind": "images", "rktVersion": "v1"}`, nil, false},
+		{`{"rktKind": "images", "rktVersion": "v1", "insecureOptions": ["image"]}`, []string{"image"}, false},
+		{`{"rktKind": "images", "rktVersion": "v1", "insecureOptions": ["image", "tls"]}`, []string{"image", "tls"}, false},
+		{`{"rktKind": "images", "rktVersion": "v1", "insecureOptions": ["bogus"]}`, nil, true},
+		{`{"rktKind": "images", "rktVersion": "v1", "insecureOptions": "image"}`, nil, true},
+	}
+	for _, tt := range tests {
+		cfg, err := getConfigFromContents(tt.contents, "images")
+		if vErr := verifyFailure(tt.fail, tt.contents, err); vErr != nil {
+			t.Errorf("%v", vErr)
+		} else if !tt.fail {
+			result := cfg.DefaultInsecureOptions
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Got unexpected results\nResult:\n%#v\n\nExpected:\n%#v", result, tt.expected)
+			}
+		}
+	}
+}
+
+func TestRequireSignatureConfig(t *testing.T) {
+	tests := []struct {
+		contents string
+		expected []string
+		fail     bool
+	}{
+		{`{"rktKind": "images", "rktVersion": "v1", "requireSignature": ["example.com/secure"]}`, []string{"example.com/secure"}, false},
+		{`{"rktKind": "images", "rktVersion": "v1", "requireSignature": ["example.com/a", "example.com/b", "example.com/a"]}`, []string{"example.com/a", "example.com/b"}, false},
+		{`{"rktKind": "images", "rktVersion": "v1", "requireSignature": ["Not a prefix!"]}`, nil, true},
+		{`{"rktKind": "images", "rktVersion": "v1", "requireSignature": "example.com/secure"}`, nil, true},
+	}
+	for _, tt := range tests {
+		cfg, err := getConfigFromContents(tt.contents, "images")
+		if vErr := verifyFailure(tt.fail, tt.contents, err); vErr != nil {
+			t.Errorf("%v", vErr)
+		} else if !tt.fail {
+			result := cfg.RequireSignature
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Got unexpected results\nResult:\n%#v\n\nExpected:\n%#v", result, tt.expected)
+			}
+		}
+	}
+
+	dir, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create temporary directory: %v", err))
+	}
+	defer os.RemoveAll(dir)
+
+	for confDir, contents := range map[string]string{
+		"system": `{"rktKind": "images", "rktVersion": "v1", "requireSignature": ["example.com/system"]}`,
+		"user":   `{"rktKind": "images", "rktVersion": "v1", "insecureOptions": ["image"], "requireSignature": ["example.com/user"]}`,
+	} {
+		d := filepath.Join(dir, confDir, "images.d")
+		if err := os.MkdirAll(d, 0700); err != nil {
+			panic(fmt.Sprintf("Failed to create configuration directory %q: %v", d, err))
+		}
+		if err := ioutil.WriteFile(filepath.Join(d, "images.json"), []byte(contents), 0600); err != nil {
+			panic(fmt.Sprintf("Failed to write configuration file: %v", err))
+		}
+	}
+	// the user configuration can not lift the requirement from
+	// the system one
+	cfg, err := GetConfigFrom(filepath.Join(dir, "system"), filepath.Join(dir, "user"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if expected := []string{"example.com/system", "example.com/user"}; !reflect.DeepEqual(cfg.RequireSignature, expected) {
+		t.Errorf("Expected prefixes %v, got %v", expected, cfg.RequireSignature)
+	}
+}
+
+func TestImagesConfigMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create temporary directory: %v", err))
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(confDir, contents string) {
+		d := filepath.Join(dir, confDir, "images.d")
+		if err := os.MkdirAll(d, 0700); err != nil {
+			panic(fmt.Sprintf("Failed to create configuration directory %q: %v", d, err))
+		}
+		if err := ioutil.WriteFile(filepath.Join(d, "images.json"), []byte(contents), 0600); err != nil {
+			panic(fmt.Sprintf("Failed to write configuration file: %v", err))
+		}
+	}
+	write("system", `{"rktKind": "images", "rktVersion": "v1", "insecureOptions": ["image"]}`)
+	write("local", `{"rktKind": "images", "rktVersion": "v1"}`)
+	write("user", `{"rktKind": "images", "rktVersion": "v1", "insecureOptions": ["image", "http"]}`)
+
+	tests := []struct {
+		dirs     []string
+		expected []string
+	}{
+		{[]string{"system"}, []string{"image"}},
+		{[]string{"system", "local"}, []string{"image"}},
+		{[]string{"system", "local", "user"}, []string{"image", "http"}},
+	}
+	for _, tt := range tests {
+		var dirs []string
+		for _, d := range tt.dirs {
+			dirs = append(dirs, filepath.Join(dir, d))
+		}
+		cfg, err := GetConfigFrom(dirs...)
+		if err != nil {
+			t.Errorf("Failed to get configuration from %v: %v", tt.dirs, err)
+			continue
+		}
+		if !reflect.DeepEqual(cfg.DefaultInsecureOptions, tt.expected) {
+			t.Errorf("Got unexpected results for %v\nResult:\n%#v\n\nExpected:\n%#v", tt.dirs, cfg.DefaultInsecureOptions, tt.expected)
+		}
+	}
+}
+
+func TestProxyConfigFormat(t *testing.T) {
+	tests := []struct {
+		contents string
+		expected map[string]string
+		fail     bool
+	}{
+		{`{"rktKind": "proxy", "rktVersion": "foo"}`, nil, true},
+		{`{"rktKind": "proxy", "rktVersion": "v1"}`, nil, true},
+		{`{"rktKind": "proxy", "rktVersion": "v1", "domains": ["example.com"]}`, nil, true},
+		{`{"rktKind": "proxy", "rktVersion": "v1", "proxy": "http://proxy.example.com:3128"}`, nil, true},
+		{`{"rktKind": "proxy", "rktVersion": "v1", "domains": ["example.com"], "proxy": "ftp://proxy.example.com"}`, nil, true},
+		{`{"rktKind": "proxy", "rktVersion": "v1", "domains": ["example.com"], "proxy": "http://"}`, nil, true},
+		{`{"rktKind": "proxy", "rktVersion": "v1", "domains": ["example.com"], "proxy": "proxy.example.com:3128"}`, nil, true},
+		{`{"rktKind": "proxy", "rktVersion": "v1", "domains": ["example.com", "coreos.com"], "proxy": "http://proxy.example.com:3128"}`, map[string]string{"example.com": "http://proxy.example.com:3128", "coreos.com": "http://proxy.example.com:3128"}, false},
+		{`{"rktKind": "proxy", "rktVersion": "v1", "domains": ["internal.example.com"], "proxy": "direct"}`, map[string]string{"internal.example.com": "direct"}, false},
+	}
+	for _, tt := range tests {
+		cfg, err := getConfigFromContents(tt.contents, "proxy")
+		if vErr := verifyFailure(tt.fail, tt.contents, err); vErr != nil {
+			t.Errorf("%v", vErr)
+		} else if !tt.fail {
+			result := proxiesToStrings(cfg.ProxyPerHost)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Got unexpected results\nResult:\n%#v\n\nExpected:\n%#v", result, tt.expected)
+			}
+		}
+	}
+}
+
+func TestProxyConfigMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create temporary directory: %v", err))
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(confDir, file, contents string) {
+		d := filepath.Join(dir, confDir, "images.d")
+		if err := os.MkdirAll(d, 0700); err != nil {
+			panic(fmt.Sprintf("Failed to create configuration directory %q: %v", d, err))
+		}
+		if err := ioutil.WriteFile(filepath.Join(d, file), []byte(contents), 0600); err != nil {
+			panic(fmt.Sprintf("Failed to write configuration file: %v", err))
+		}
+	}
+	write("system", "proxy.json", `{"rktKind": "proxy", "rktVersion": "v1", "domains": ["example.com", "internal.example.com"], "proxy": "http://proxy.example.com:3128"}`)
+	write("local", "internal.json", `{"rktKind": "proxy", "rktVersion": "v1", "domains": ["internal.example.com"], "proxy": "direct"}`)
+
+	cfg, err := GetConfigFrom(filepath.Join(dir, "system"), filepath.Join(dir, "local"))
+	if err != nil {
+		panic(fmt.Sprintf("Failed to get configuration: %v", err))
+	}
+	expected := map[string]string{
+		"example.com":          "http://proxy.example.com:3128",
+		"internal.example.com": "direct",
+	}
+	if result := proxiesToStrings(cfg.ProxyPerHost); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Got unexpected results\nResult:\n%#v\n\nExpected:\n%#v", result, expected)
+	}
+
+	proxyFunc := ProxyFunc(cfg.ProxyPerHost)
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"https://example.com/image.aci", "http://proxy.example.com:3128"},
+		{"https://example.com:8443/image.aci", "http://proxy.example.com:3128"},
+		{"https://internal.example.com/image.aci", ""},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest("GET", tt.url, nil)
+		if err != nil {
+			panic(fmt.Sprintf("Failed to create request: %v", err))
+		}
+		proxyURL, err := proxyFunc(req)
+		if err != nil {
+			t.Errorf("Unexpected error getting proxy for %q: %v", tt.url, err)
+			continue
+		}
+		result := ""
+		if proxyURL != nil {
+			result = proxyURL.String()
+		}
+		if result != tt.expected {
+			t.Errorf("Expected proxy %q for %q, got %q", tt.expected, tt.url, result)
+		}
+	}
+}
+
+func TestRateLimitConfigFormat(t *testing.T) {
+	tests := []struct {
+		contents string
+		expected map[string]float64
+		fail     bool
+	}{
+		{`{"rktKind": "rateLimit", "rktVersion": "foo"}`, nil, true},
+		{`{"rktKind": "rateLimit", "rktVersion": "v1"}`, nil, true},
+		{`{"rktKind": "rateLimit", "rktVersion": "v1", "domains": ["example.com"]}`, nil, true},
+		{`{"rktKind": "rateLimit", "rktVersion": "v1", "requestsPerSecond": 2}`, nil, true},
+		{`{"rktKind": "rateLimit", "rktVersion": "v1", "domains": [], "requestsPerSecond": 2}`, nil, true},
+		{`{"rktKind": "rateLimit", "rktVersion": "v1", "domains": ["example.com"], "requestsPerSecond": 0}`, nil, true},
+		{`{"rktKind": "rateLimit", "rktVersion": "v1", "domains": ["example.com"], "requestsPerSecond": -1}`, nil, true},
+		{`{"rktKind": "rateLimit", "rktVersion": "v1", "domains": ["example.com"], "requestsPerSecond": "2"}`, nil, true},
+		{`{"rktKind": "rateLimit", "rktVersion": "v1", "domains": ["example.com", "coreos.com"], "requestsPerSecond": 2}`, map[string]float64{"example.com": 2, "coreos.com": 2}, false},
+		{`{"rktKind": "rateLimit", "rktVersion": "v1", "domains": ["example.com"], "requestsPerSecond": 0.5}`, map[string]float64{"example.com": 0.5}, false},
+	}
+	for _, tt := range tests {
+		cfg, err := getConfigFromContents(tt.contents, "rateLimit")
+		if vErr := verifyFailure(tt.fail, tt.contents, err); vErr != nil {
+			t.Errorf("%v", vErr)
+		} else if !tt.fail {
+			if !reflect.DeepEqual(cfg.RateLimitPerHost, tt.expected) {
+				t.Errorf("Got unexpected results\nResult:\n%#v\n\nExpected:\n%#v", cfg.RateLimitPerHost, tt.expected)
+			}
+		}
+	}
+}
+
+func TestRateLimitConfigMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create temporary directory: %v", err))
+	}
+	defer os.RemoveAll(dir)
+
+	write := func(confDir, file, contents string) {
+		d := filepath.Join(dir, confDir, "images.d")
+		if err := os.MkdirAll(d, 0700); err != nil {
+			panic(fmt.Sprintf("Failed to create configuration directory %q: %v", d, err))
+		}
+		if err := ioutil.WriteFile(filepath.Join(d, file), []byte(contents), 0600); err != nil {
+			panic(fmt.Sprintf("Failed to write configuration file: %v", err))
+		}
+	}
+	write("system", "ratelimit.json", `{"rktKind": "rateLimit", "rktVersion": "v1", "domains": ["example.com", "quay.io"], "requestsPerSecond": 2}`)
+	write("local", "quay.json", `{"rktKind": "rateLimit", "rktVersion": "v1", "domains": ["quay.io"], "requestsPerSecond": 10}`)
+
+	cfg, err := GetConfigFrom(filepath.Join(dir, "system"), filepath.Join(dir, "local"))
+	if err != nil {
+		panic(fmt.Sprintf("Failed to get configuration: %v", err))
+	}
+	expected := map[string]float64{
+		"example.com": 2,
+		"quay.io":     10,
+	}
+	if !reflect.DeepEqual(cfg.RateLimitPerHost, expected) {
+		t.Errorf("Got unexpected results\nResult:\n%#v\n\nExpected:\n%#v", cfg.RateLimitPerHost, expected)
+	}
+}
+
+func TestFetchConfigFormat(t *testing.T) {
+	tests := []struct {
+		contents string
+		expected FetchPolicy
+		fail     bool
+	}{
+		{`{"rktKind": "fetch", "rktVersion": "foo"}`, FetchPolicy{}, true},
+		{`{"rktKind": "fetch", "rktVersion": "v1"}`, FetchPolicy{}, true},
+		{`{"rktKind": "fetch", "rktVersion": "v1", "timeout": "soon"}`, FetchPolicy{}, true},
+		{`{"rktKind": "fetch", "rktVersion": "v1", "timeout": "-5s"}`, FetchPolicy{}, true},
+		{`{"rktKind": "fetch", "rktVersion": "v1", "retries": -1}`, FetchPolicy{}, true},
+		{`{"rktKind": "fetch", "rktVersion": "v1", "retries": "3"}`, FetchPolicy{}, true},
+		{`{"rktKind": "fetch", "rktVersion": "v1", "timeout": "30s"}`, FetchPolicy{Timeout: 30 * time.Second}, false},
+		{`{"rktKind": "fetch", "rktVersion": "v1", "retries": 3}`, FetchPolicy{Retries: 3}, false},
+		{`{"rktKind": "fetch", "rktVersion": "v1", "timeout": "1m", "retries": 5}`, FetchPolicy{Timeout: time.Minute, Retries: 5}, false},
+	}
+	for _, tt := range tests {
+		cfg, err := getConfigFromContents(tt.contents, "fetch")
+		if vErr := verifyFailure(tt.fail, tt.contents, err); vErr != nil {
+			t.Errorf("%v", vErr)
+		} else if !tt.fail && cfg.FetchPolicy != tt.expected {
+			t.Errorf("Got unexpected results\nResult:\n%#v\n\nExpected:\n%#v", cfg.FetchPolicy, tt.expected)
+		}
+	}
+}
+
+func TestFetchConfigMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create temporary directory: %v", err))
+	}
+	defer os.RemoveAll(dir)
+
+	for confDir, contents := range map[string]string{
+		"system": `{"rktKind": "fetch", "rktVersion": "v1", "timeout": "30s", "retries": 2}`,
+		"local":  `{"rktKind": "fetch", "rktVersion": "v1", "retries": 5}`,
+	} {
+		d := filepath.Join(dir, confDir, "images.d")
+		if err := os.MkdirAll(d, 0700); err != nil {
+			panic(fmt.Sprintf("Failed to create configuration directory %q: %v", d, err))
+		}
+		if err := ioutil.WriteFile(filepath.Join(d, "fetch.json"), []byte(contents), 0600); err != nil {
+			panic(fmt.Sprintf("Failed to write configuration file: %v", err))
+		}
+	}
+
+	cfg, err := GetConfigFrom(filepath.Join(dir, "system"), filepath.Join(dir, "local"))
+	if err != nil {
+		panic(fmt.Sprintf("Failed to get configuration: %v", err))
+	}
+	expected := FetchPolicy{Timeout: 30 * time.Second, Retries: 5}
+	if cfg.FetchPolicy != expected {
+		t.Errorf("Got unexpected results\nResult:\n%#v\n\nExpected:\n%#v", cfg.FetchPolicy, expected)
+	}
+}
+
+func TestVolumesConfigFormat(t *testing.T) {
+	source, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create temporary directory: %v", err))
+	}
+	defer os.RemoveAll(source)
+	missing := filepath.Join(source, "missing")
+
+	tests := []struct {
+		contents string
+		expected []DefaultVolume
+		fail     bool
+	}{
+		{`{"rktKind": "volumes", "rktVersion": "foo", "volumes": []}`, nil, true},
+		{`{"rktKind": "volumes", "rktVersion": "v1", "volumes": []}`, nil, true},
+		{fmt.Sprintf(`{"rktKind": "volumes", "rktVersion": "v1", "volumes": [{"name": "Certs", "source": %q, "target": "/certs"}]}`, source), nil, true},
+		{`{"rktKind": "volumes", "rktVersion": "v1", "volumes": [{"name": "certs", "source": "certs", "target": "/certs"}]}`, nil, true},
+		{fmt.Sprintf(`{"rktKind": "volumes", "rktVersion": "v1", "volumes": [{"name": "certs", "source": %q, "target": "/certs"}]}`, missing), nil, true},
+		{fmt.Sprintf(`{"rktKind": "volumes", "rktVersion": "v1", "volumes": [{"name": "certs", "source": %q, "target": "certs"}]}`, source), nil, true},
+		{fmt.Sprintf(`{"rktKind": "volumes", "rktVersion": "v1", "volumes": [{"name": "certs", "source": %q, "target": "/certs"}, {"name": "certs", "source": %q, "target": "/other"}]}`, source, source), nil, true},
+		{fmt.Sprintf(`{"rktKind": "volumes", "rktVersion": "v1", "volumes": [{"name": "certs", "source": %q, "target": "/certs"}, {"name": "other", "source": %q, "target": "/certs/"}]}`, source, source), nil, true},
+		{
+			fmt.Sprintf(`{"rktKind": "volumes", "rktVersion": "v1", "volumes": [{"name": "certs", "source": %q, "target": "/certs", "readOnly": true}, {"name": "tz", "source": %q, "target": "/tz"}]}`, source, source),
+			[]DefaultVolume{
+				{Name: "certs", Source: source, Target: "/certs", ReadOnly: true},
+				{Name: "tz", Source: source, Target: "/tz"},
+			},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		cfg, err := getConfigFromContents(tt.contents, "volumes")
+		if vErr := verifyFailure(tt.fail, tt.contents, err); vErr != nil {
+			t.Errorf("%v", vErr)
+		} else if !tt.fail && !reflect.DeepEqual(cfg.DefaultVolumes, tt.expected) {
+			t.Errorf("Got unexpected results\nResult:\n%#v\n\nExpected:\n%#v", cfg.DefaultVolumes, tt.expected)
+		}
+	}
+}
+
+func TestVolumesConfigMerge(t *testing.T) {
+	dir, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create temporary directory: %v", err))
+	}
+	defer os.RemoveAll(dir)
+
+	for confDir, contents := range map[string]string{
+		"system": fmt.Sprintf(`{"rktKind": "volumes", "rktVersion": "v1", "volumes": [{"name": "certs", "source": %q, "target": "/certs"}, {"name": "tz", "source": %q, "target": "/tz"}]}`, dir, dir),
+		"local":  fmt.Sprintf(`{"rktKind": "volumes", "rktVersion": "v1", "volumes": [{"name": "certs", "source": %q, "target": "/etc/certs", "readOnly": true}]}`, dir),
+	} {
+		d := filepath.Join(dir, confDir, "volumes.d")
+		if err := os.MkdirAll(d, 0700); err != nil {
+			panic(fmt.Sprintf("Failed to create configuration directory %q: %v", d, err))
+		}
+		if err := ioutil.WriteFile(filepath.Join(d, "volumes.json"), []byte(contents), 0600); err != nil {
+			panic(fmt.Sprintf("Failed to write configuration file: %v", err))
+		}
+	}
+
+	cfg, err := GetConfigFrom(filepath.Join(dir, "system"), filepath.Join(dir, "local"))
+	if err != nil {
+		panic(fmt.Sprintf("Failed to get configuration: %v", err))
+	}
+	expected := []DefaultVolume{
+		{Name: "certs", Source: dir, Target: "/etc/certs", ReadOnly: true},
+		{Name: "tz", Source: dir, Target: "/tz"},
+	}
+	if !reflect.DeepEqual(cfg.DefaultVolumes, expected) {
+		t.Errorf("Got unexpected results\nResult:\n%#v\n\nExpected:\n%#v", cfg.DefaultVolumes, expected)
+	}
+}
+
+func TestConfigSchema(t *testing.T) {
+	tests := []struct {
+		kind     string
+		contents string
+		err      string
+	}{
+		{"images", `{"rktKind": "images", "rktVersion": "v1", "insecureOptions": ["image"]}`, ""},
+		{"images", `{"rktKind": "images", "rktVersion": "v1", "insecureOption": ["image"]}`, `unknown field "insecureOption", did you mean "insecureOptions"?`},
+		{"images", `{"rktKind": "images", "rktVersion": "v1", "InsecureOptions": ["image"]}`, `unknown field "InsecureOptions", did you mean "insecureOptions"?`},
+		{"images", `{"rktKind": "images", "rktVersion": "v1", "insecureOptions": "image"}`, `invalid field "insecureOptions": expected array, got string`},
+		{"fetch", `{"rktKind": "fetch", "rktVersion": "v1", "retries": 3, "backoff": "1s"}`, `unknown field "backoff"`},
+		{"fetch", `{"rktKind": "fetch", "rktVersion": "v1", "retries": 1.5}`, `invalid field "retries": expected integer, got number`},
+		{"proxy", `{"rktKind": "proxy", "rktVersion": "v1", "domains": ["example.com"]}`, `missing required field "proxy"`},
+	}
+	for _, tt := range tests {
+		_, err := getConfigFromContents(tt.contents, tt.kind)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("Unexpected error for %s: %v", tt.contents, err)
+		case tt.err != "" && err == nil:
+			t.Errorf("Expected error %q for %s", tt.err, tt.contents)
+		case tt.err != "" && !errwrap.Contains(err, tt.err):
+			t.Errorf("Expected error %q for %s, got %v", tt.err, tt.contents, err)
+		}
+	}
+}
+
+func TestDisabledConfigSubDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create temporary directory: %v", err))
+	}
+	defer os.RemoveAll(dir)
+
+	oldStderr := stderr
+	stderr = log.New(ioutil.Discard, "config", false)
+	defer func() { stderr = oldStderr }()
+
+	files := map[string]string{
+		filepath.Join("auth.d", "auth.json"):   `{"rktKind": "auth", "rktVersion": "v1", "domains": ["coreos.com"], "type": "basic", "credentials": {"user": "bar", "password": "baz"}}`,
+		filepath.Join("auth.d", ".disabled"):   "",
+		filepath.Join("paths.d", "paths.json"): `{"rktKind": "paths", "rktVersion": "v1", "data": "/home/me/rkt/data"}`,
+	}
+	for file, contents := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			panic(fmt.Sprintf("Failed to create directory %q: %v", filepath.Dir(path), err))
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			panic(fmt.Sprintf("Failed to write file %q: %v", path, err))
+		}
+	}
+
+	cfg, err := GetConfigFrom(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(cfg.AuthPerHost) != 0 {
+		t.Errorf("Expected the disabled auth.d to be skipped, got auth for %v", cfg.AuthPerHost)
+	}
+	if cfg.Paths.DataDir != "/home/me/rkt/data" {
+		t.Errorf("Expected paths.d to be parsed, got data dir %q", cfg.Paths.DataDir)
+	}
+}
+
+func TestGetConfigFromBytes(t *testing.T) {
+	cfg, err := GetConfigFromBytes("auth.d", []byte(`{"rktKind": "auth", "rktVersion": "v1", "domains": ["coreos.com"], "type": "basic", "credentials": {"user": "bar", "password": "baz"}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing auth: %v", err)
+	}
+	expectedHeaders := map[string]http.Header{
+		"coreos.com": {
+			"Authorization": []string{"Basic YmFyOmJheg=="},
+		},
+	}
+	if result := ResolveAuthPerHost(cfg.AuthPerHost); !reflect.DeepEqual(result, expectedHeaders) {
+		t.Errorf("Got unexpected auth headers\nResult:\n%#v\n\nExpected:\n%#v", result, expectedHeaders)
+	}
+
+	cfg, err = GetConfigFromBytes("paths.d", []byte(`{"rktKind": "paths", "rktVersion": "v1", "data": "/home/me/rkt/data", "stage1-images": "/home/me/rkt/stage1-images"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing paths: %v", err)
+	}
+	expectedPaths := ConfigurablePaths{
+		DataDir:         "/home/me/rkt/data",
+		Stage1ImagesDir: "/home/me/rkt/stage1-images",
+	}
+	if cfg.Paths != expectedPaths {
+		t.Errorf("Got unexpected paths\nResult:\n%#v\n\nExpected:\n%#v", cfg.Paths, expectedPaths)
+	}
+
+	cfg, err = GetConfigFromBytes("stage1.d", []byte(`{"rktKind": "stage1", "rktVersion": "v1", "name": "example.com/stage1", "version": "1.2.3", "location": "https://example.com/stage1.aci"}`))
+	if err != nil {
+		t.Fatalf("Unexpected error parsing stage1: %v", err)
+	}
+	expectedStage1 := Stage1Data{
+		Name:     "example.com/stage1",
+		Version:  "1.2.3",
+		Location: "https://example.com/stage1.aci",
+	}
+	if cfg.Stage1 != expectedStage1 {
+		t.Errorf("Got unexpected stage1\nResult:\n%#v\n\nExpected:\n%#v", cfg.Stage1, expectedStage1)
+	}
+
+	failures := []struct {
+		subdir   string
+		contents string
+	}{
+		{"paths.d", `{"rktKind": "stage1", "rktVersion": "v1", "name": "example.com/stage1", "version": "1.2.3"}`},
+		{"paths.d", `{"rktKind": "paths", "rktVersion": "v2", "data": "/home/me/rkt/data"}`},
+		{"paths.d", `{"rktKind": "paths", "data": "/home/me/rkt/data"}`},
+		{"foo.d", `{"rktKind": "paths", "rktVersion": "v1", "data": "/home/me/rkt/data"}`},
+	}
+	for _, tt := range failures {
+		if _, err := GetConfigFromBytes(tt.subdir, []byte(tt.contents)); err == nil {
+			t.Errorf("Expected an error parsing %s in %q", tt.contents, tt.subdir)
+		}
+	}
+}
+
+func TestGetConfigSources(t *testing.T) {
+	dir, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create temporary directory: %v", err))
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		filepath.Join("auth.d", "auth.json"):    `{"rktKind": "auth", "rktVersion": "v1", "domains": ["coreos.com"], "type": "basic", "credentials": {"user": "bar", "password": "baz"}}`,
+		filepath.Join("auth.d", "README"):       "auth configuration",
+		filepath.Join("auth.d", "future.json"):  `{"rktKind": "auth", "rktVersion": "v2"}`,
+		filepath.Join("paths.d", "stage1.json"): `{"rktKind": "stage1", "rktVersion": "v1", "name": "example.com/stage1"}`,
+		filepath.Join("stage1.d", ".disabled"):  "",
+		filepath.Join("foo.d", "foo.json"):      "{}",
+	}
+	for file, contents := range files {
+		path := filepath.Join(dir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			panic(fmt.Sprintf("Failed to create directory %q: %v", filepath.Dir(path), err))
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			panic(fmt.Sprintf("Failed to write file %q: %v", path, err))
+		}
+	}
+
+	sources, err := GetConfigSources(dir, filepath.Join(dir, "nonexistent"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		path   string
+		status SourceStatus
+		detail string
+	}{
+		{filepath.Join("auth.d", "README"), SourceIgnored, "no .json extension"},
+		{filepath.Join("auth.d", "auth.json"), SourceParsed, "auth v1"},
+		{filepath.Join("auth.d", "future.json"), SourceInvalid, `version "v2"`},
+		{"foo.d", SourceIgnored, "not a configuration subdirectory"},
+		{filepath.Join("paths.d", "stage1.json"), SourceInvalid, "expects to have configuration files of kinds"},
+		{"stage1.d", SourceIgnored, "disabled"},
+	}
+	if len(sources) != len(tests) {
+		t.Fatalf("Expected %d sources, got %d: %#v", len(tests), len(sources), sources)
+	}
+	for i, tt := range tests {
+		s := sources[i]
+		if s.Path != filepath.Join(dir, tt.path) {
+			t.Errorf("#%d: expected path %q, got %q", i, filepath.Join(dir, tt.path), s.Path)
+		}
+		if s.Status != tt.status {
+			t.Errorf("#%d: expected status %q, got %q", i, tt.status, s.Status)
+		}
+		detail := s.Reason
+		if s.Status == SourceParsed {
+			detail = s.Kind + " " + s.Version
+		}
+		if !strings.Contains(detail, tt.detail) {
+			t.Errorf("#%d: expected %q in the details, got %q", i, tt.detail, detail)
+		}
+	}
+}
+
+func TestConfigBOMAndSyntaxErrors(t *testing.T) {
+	bom := "\xef\xbb\xbf"
+	cfg, err := getConfigFromContents(bom+`{"rktKind": "auth", "rktVersion": "v1", "domains": ["coreos.com"], "type": "basic", "credentials": {"user": "bar", "password": "baz"}}`, "auth")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing a file with BOM: %v", err)
+	}
+	if _, ok := cfg.AuthPerHost["coreos.com"]; !ok {
+		t.Errorf("Expected auth for coreos.com, got %v", cfg.AuthPerHost)
+	}
+
+	tests := []struct {
+		contents string
+		position string
+		hint     bool
+	}{
+		{
+			"{\n\t\"rktKind\": \"paths\",\n\t\"rktVersion\": \"v1\",\n\t\"data\": \"/home/me/rkt/data\",\n}",
+			"line 5, column 1",
+			true,
+		},
+		{
+			`{"rktKind": "auth", "rktVersion": "v1", "domains": ["coreos.com",], "type": "basic"}`,
+			"line 1, column 66",
+			true,
+		},
+		{
+			"{\n\t\"rktKind\": \"paths\"\n\t\"rktVersion\": \"v1\"\n}",
+			"line 3, column 2",
+			false,
+		},
+	}
+	for _, tt := range tests {
+		_, err := getConfigFromContents(tt.contents, "paths")
+		if err == nil {
+			t.Errorf("Expected an error for %q", tt.contents)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.position) {
+			t.Errorf("Expected the error for %q to contain %q, got %v", tt.contents, tt.position, err)
+		}
+		if hint := strings.Contains(err.Error(), "trailing commas are not allowed"); hint != tt.hint {
+			t.Errorf("Expected the trailing comma hint to be %v for %q, got %v", tt.hint, tt.contents, err)
+		}
+	}
+}
+
+func proxiesToStrings(proxyPerHost map[string]*url.URL) map[string]string {
+	result := make(map[string]string)
+	for host, proxyURL := range proxyPerHost {
+		if proxyURL == nil {
+			result[host] = proxyDirect
+		} else {
+			result[host] = proxyURL.String()
+		}
+	}
+	return result
+}
+
 func verifyFailure(shouldFail bool, contents string, err error) error {
 	var vErr error = nil
 	if err != nil {
@@ -220,6 +1052,125 @@ func getConfigFromContents(contents, kind string) (*Config, error) {
 	return cfg, readFile(cfg, fi, f.Name(), []string{kind})
 }
 
+func TestConcurrentGetConfigFrom(t *testing.T) {
+	var dirs []string
+	for i := 0; i < 2; i++ {
+		dir, err := ioutil.TempDir("", tstprefix)
+		if err != nil {
+			panic(fmt.Sprintf("Failed to create temporary directory: %v", err))
+		}
+		defer os.RemoveAll(dir)
+		files := map[string]string{
+			filepath.Join("auth.d", "auth.json"):   fmt.Sprintf(`{"rktKind": "auth", "rktVersion": "v1", "domains": ["coreos.com"], "type": "oauth", "credentials": {"token": "token%d"}}`, i),
+			filepath.Join("paths.d", "paths.json"): fmt.Sprintf(`{"rktKind": "paths", "rktVersion": "v1", "data": "/home/me/rkt/data%d"}`, i),
+		}
+		for file, contents := range files {
+			path := filepath.Join(dir, file)
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				panic(fmt.Sprintf("Failed to create directory %q: %v", filepath.Dir(path), err))
+			}
+			if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+				panic(fmt.Sprintf("Failed to write file %q: %v", path, err))
+			}
+		}
+		dirs = append(dirs, dir)
+	}
+
+	const loops = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*loops)
+	for i, dir := range dirs {
+		for j := 0; j < loops; j++ {
+			wg.Add(1)
+			go func(i int, dir string) {
+				defer wg.Done()
+				cfg, err := GetConfigFrom(dir)
+				if err != nil {
+					errs <- err
+					return
+				}
+				expectedData := fmt.Sprintf("/home/me/rkt/data%d", i)
+				if cfg.Paths.DataDir != expectedData {
+					errs <- fmt.Errorf("expected data dir %q, got %q", expectedData, cfg.Paths.DataDir)
+				}
+				expectedAuth := fmt.Sprintf("Bearer token%d", i)
+				if auth := cfg.AuthPerHost["coreos.com"].GetHeader().Get("Authorization"); auth != expectedAuth {
+					errs <- fmt.Errorf("expected auth %q, got %q", expectedAuth, auth)
+				}
+			}(i, dir)
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestUsingDeprecatedLayout(t *testing.T) {
+	auth := `{"rktKind": "auth", "rktVersion": "v1", "domains": ["coreos.com"], "type": "basic", "credentials": {"user": "bar", "password": "baz"}}`
+	tests := []struct {
+		name       string
+		files      map[string]string
+		deprecated bool
+	}{
+		{
+			name:       "deprecated-only",
+			files:      map[string]string{"auth.json": auth},
+			deprecated: true,
+		},
+		{
+			name:       "new-only",
+			files:      map[string]string{filepath.Join("auth.d", "auth.json"): auth, "README.json": `{"readme": "not a configuration file"}`},
+			deprecated: false,
+		},
+		{
+			name:       "mixed",
+			files:      map[string]string{"auth.json": auth, filepath.Join("auth.d", "auth.json"): auth},
+			deprecated: true,
+		},
+	}
+
+	root, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create temporary directory: %v", err))
+	}
+	defer os.RemoveAll(root)
+
+	var dirs, expected []string
+	for _, tt := range tests {
+		dir := filepath.Join(root, tt.name)
+		for file, contents := range tt.files {
+			path := filepath.Join(dir, file)
+			if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+				panic(fmt.Sprintf("Failed to create directory %q: %v", filepath.Dir(path), err))
+			}
+			if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+				panic(fmt.Sprintf("Failed to write file %q: %v", path, err))
+			}
+		}
+		dirs = append(dirs, dir)
+		if tt.deprecated {
+			expected = append(expected, dir)
+		}
+
+		result, err := UsingDeprecatedLayout(dir)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		} else if (len(result) > 0) != tt.deprecated {
+			t.Errorf("%s: expected deprecated layout to be %v, got %v", tt.name, tt.deprecated, result)
+		}
+	}
+
+	result, err := UsingDeprecatedLayout(append(dirs, filepath.Join(root, "nonexistent"))...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected directories %v, got %v", expected, result)
+	}
+}
+
 func TestConfigLoading(t *testing.T) {
 	dir, err := ioutil.TempDir("", tstprefix)
 	if err != nil {
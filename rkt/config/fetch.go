@@ -0,0 +1,71 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type fetchV1JsonParser struct{}
+
+type fetchV1 struct {
+	Timeout string `json:"timeout"`
+	Retries int    `json:"retries"`
+}
+
+var fetchV1Schema = &configSchema{
+	Properties: map[string]schemaType{
+		"timeout": schemaString,
+		"retries": schemaInteger,
+	},
+}
+
+func init() {
+	addParserWithSchema("fetch", "v1", fetchV1Schema, &fetchV1JsonParser{})
+	registerSubDir("images.d", []string{"fetch"})
+}
+
+func (p *fetchV1JsonParser) parse(config *Config, raw []byte) error {
+	var fetch fetchV1
+	if err := json.Unmarshal(raw, &fetch); err != nil {
+		return err
+	}
+	var policy FetchPolicy
+	if fetch.Timeout != "" {
+		timeout, err := time.ParseDuration(fetch.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid fetch timeout %q: %v", fetch.Timeout, err)
+		}
+		if timeout < 0 {
+			return fmt.Errorf("fetch timeout must not be negative, got %q", fetch.Timeout)
+		}
+		policy.Timeout = timeout
+	}
+	if fetch.Retries < 0 {
+		return fmt.Errorf("fetch retries must not be negative, got %d", fetch.Retries)
+	}
+	policy.Retries = fetch.Retries
+	if policy == (FetchPolicy{}) {
+		return errors.New("no fetch timeout or retries specified")
+	}
+	if config.FetchPolicy != (FetchPolicy{}) {
+		return errors.New("fetch policy is already specified")
+	}
+	config.FetchPolicy = policy
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/appc/spec/schema/types"
+	rktflag "github.com/rkt/rkt/rkt/flag"
+)
+
+type imagesV1JsonParser struct{}
+
+type imagesV1 struct {
+	InsecureOptions  []string `json:"insecureOptions"`
+	RequireSignature []string `json:"requireSignature"`
+}
+
+var imagesV1Schema = &configSchema{
+	Properties: map[string]schemaType{
+		"insecureOptions":  schemaArray,
+		"requireSignature": schemaArray,
+	},
+}
+
+func init() {
+	addParserWithSchema("images", "v1", imagesV1Schema, &imagesV1JsonParser{})
+	registerSubDir("images.d", []string{"images"})
+}
+
+func (p *imagesV1JsonParser) parse(config *Config, raw []byte) error {
+	var images imagesV1
+	if err := json.Unmarshal(raw, &images); err != nil {
+		return err
+	}
+	if len(images.InsecureOptions) > 0 {
+		if len(config.DefaultInsecureOptions) > 0 {
+			return fmt.Errorf("default insecure options are already specified")
+		}
+		if _, err := rktflag.NewSecFlags(strings.Join(images.InsecureOptions, ",")); err != nil {
+			return fmt.Errorf("invalid default insecure options: %v", err)
+		}
+		config.DefaultInsecureOptions = images.InsecureOptions
+	}
+	for _, prefix := range images.RequireSignature {
+		if _, err := types.NewACIdentifier(prefix); err != nil {
+			return fmt.Errorf("invalid prefix %q requiring a signature: %v", prefix, err)
+		}
+		config.RequireSignature = appendPrefix(config.RequireSignature, prefix)
+	}
+	return nil
+}
+
+func appendPrefix(prefixes []string, prefix string) []string {
+	for _, p := range prefixes {
+		if p == prefix {
+			return prefixes
+		}
+	}
+	return append(prefixes, prefix)
+}
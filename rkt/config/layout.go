@@ -0,0 +1,72 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// UsingDeprecatedLayout returns the directories from the passed ones
+// that have configuration files directly in them instead of in the
+// subdirectories for their kinds (e.g. "auth.d"). rkt does not read
+// such files, so they likely come from a configuration that was not
+// migrated to the subdirectory layout.
+func UsingDeprecatedLayout(dirs ...string) ([]string, error) {
+	var deprecated []string
+	for _, dir := range dirs {
+		found, err := hasFlatConfigFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			deprecated = append(deprecated, dir)
+		}
+	}
+	return deprecated, nil
+}
+
+// hasFlatConfigFiles checks whether the directory has JSON files
+// with a rktKind directly in it.
+func hasFlatConfigFiles(dir string) (bool, error) {
+	if valid, err := validDir(dir); err != nil {
+		return false, err
+	} else if !valid {
+		return false, nil
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range entries {
+		if !entry.Mode().IsRegular() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		raw, err := ioutil.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return false, err
+		}
+		var header configHeader
+		if err := json.Unmarshal(bytes.TrimPrefix(raw, utf8BOM), &header); err != nil {
+			continue
+		}
+		if header.RktKind != "" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -17,14 +17,30 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"os/user"
 	"path/filepath"
+	"regexp"
+	"strings"
 )
 
 type configurablePathsV1 struct {
 	Data         string `json:"data"`
 	Stage1Images string `json:"stage1-images"`
+	PathsCheck   string `json:"paths-check"`
 }
 
+const (
+	pathsCheckWarn  = "warn"
+	pathsCheckError = "error"
+	pathsCheckNone  = "none"
+)
+
+// dangerousPaths are the system directories which should never be used
+// as rkt directories, as rkt removes files from them during garbage
+// collection.
+var dangerousPaths = []string{"/", "/bin", "/boot", "/dev", "/etc", "/lib", "/proc", "/sbin", "/sys", "/usr"}
+
 func init() {
 	addParser("paths", "v1", &configurablePathsV1{})
 	// Look in 'paths.d' subdir for configs of type paths
@@ -40,6 +56,11 @@ func (p *configurablePathsV1) parse(config *Config, raw []byte) error {
 		if config.Paths.DataDir != "" {
 			return fmt.Errorf("data directory is already specified")
 		}
+		data, err := expandPathVariables(dirs.Data)
+		if err != nil {
+			return fmt.Errorf("invalid data directory: %v", err)
+		}
+		dirs.Data = data
 		if !filepath.IsAbs(dirs.Data) {
 			return fmt.Errorf("data directory must be an absolute path")
 		}
@@ -49,11 +70,130 @@ func (p *configurablePathsV1) parse(config *Config, raw []byte) error {
 		if config.Paths.Stage1ImagesDir != "" {
 			return fmt.Errorf("stage1 images directory is already specified")
 		}
+		stage1Images, err := expandPathVariables(dirs.Stage1Images)
+		if err != nil {
+			return fmt.Errorf("invalid stage1 images directory: %v", err)
+		}
+		dirs.Stage1Images = stage1Images
 		if !filepath.IsAbs(dirs.Stage1Images) {
 			return fmt.Errorf("stage1 images directory must be an absolute path")
 		}
 		config.Paths.Stage1ImagesDir = dirs.Stage1Images
 	}
+	if dirs.PathsCheck != "" {
+		if config.Paths.Check != "" {
+			return fmt.Errorf("paths check is already specified")
+		}
+		switch dirs.PathsCheck {
+		case pathsCheckWarn, pathsCheckError, pathsCheckNone:
+		default:
+			return fmt.Errorf("invalid paths check %q, expected %q, %q or %q", dirs.PathsCheck, pathsCheckWarn, pathsCheckError, pathsCheckNone)
+		}
+		config.Paths.Check = dirs.PathsCheck
+	}
 
 	return nil
 }
+
+// checkPaths looks for the configured directories being nested in
+// each other or pointing to system directories, which could make
+// rkt remove unrelated files. The problems are reported according to
+// paths.Check.
+func checkPaths(paths ConfigurablePaths) error {
+	if paths.Check == pathsCheckNone {
+		return nil
+	}
+	problems := pathsProblems(paths)
+	if len(problems) == 0 {
+		return nil
+	}
+	if paths.Check == pathsCheckError {
+		return fmt.Errorf("invalid paths configuration: %s", strings.Join(problems, "; "))
+	}
+	for _, problem := range problems {
+		stderr.Printf("warning: %s", problem)
+	}
+	return nil
+}
+
+func pathsProblems(paths ConfigurablePaths) []string {
+	var problems []string
+	dirs := []struct {
+		name string
+		path string
+	}{
+		{"data directory", paths.DataDir},
+		{"stage1 images directory", paths.Stage1ImagesDir},
+	}
+	for _, dir := range dirs {
+		if dir.path == "" {
+			continue
+		}
+		for _, dangerous := range dangerousPaths {
+			if filepath.Clean(dir.path) == dangerous {
+				problems = append(problems, fmt.Sprintf("%s %q is a system directory", dir.name, dir.path))
+			}
+		}
+	}
+	if paths.DataDir != "" && paths.Stage1ImagesDir != "" {
+		data := filepath.Clean(paths.DataDir)
+		stage1Images := filepath.Clean(paths.Stage1ImagesDir)
+		switch {
+		case data == stage1Images:
+			problems = append(problems, fmt.Sprintf("data directory and stage1 images directory are the same directory %q", data))
+		case isSubdir(data, stage1Images):
+			problems = append(problems, fmt.Sprintf("stage1 images directory %q is inside the data directory %q", stage1Images, data))
+		case isSubdir(stage1Images, data):
+			problems = append(problems, fmt.Sprintf("data directory %q is inside the stage1 images directory %q", data, stage1Images))
+		}
+	}
+	return problems
+}
+
+// isSubdir checks if the cleaned path is below the cleaned dir.
+func isSubdir(dir, path string) bool {
+	if dir == "/" {
+		return path != "/"
+	}
+	return strings.HasPrefix(path, dir+"/")
+}
+
+var pathVariableRegexp = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// expandPathVariables replaces ${USER} and ${HOME} in the path with
+// the name and the home directory of the user invoking rkt. Other
+// variables are not supported.
+func expandPathVariables(path string) (string, error) {
+	var expandErr error
+	expanded := pathVariableRegexp.ReplaceAllStringFunc(path, func(variable string) string {
+		name := pathVariableRegexp.FindStringSubmatch(variable)[1]
+		value, err := getPathVariable(name)
+		if err != nil && expandErr == nil {
+			expandErr = err
+		}
+		return value
+	})
+	if expandErr != nil {
+		return "", expandErr
+	}
+	return expanded, nil
+}
+
+func getPathVariable(name string) (string, error) {
+	switch name {
+	case "USER", "HOME":
+	default:
+		return "", fmt.Errorf("unsupported variable ${%s}, only ${USER} and ${HOME} are allowed", name)
+	}
+	if value := os.Getenv(name); value != "" {
+		return value, nil
+	}
+	u, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("failed to get the current user for ${%s}: %v", name, err)
+	}
+	if name == "USER" {
+		return u.Username, nil
+	}
+	return u.HomeDir, nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// proxyDirect is a special proxy value meaning that the hosts should
+// be reached without any proxy.
+const proxyDirect = "direct"
+
+type proxyV1JsonParser struct{}
+
+type proxyV1 struct {
+	Domains []string `json:"domains"`
+	Proxy   string   `json:"proxy"`
+}
+
+var allowedProxySchemes = map[string]struct{}{
+	"http":   {},
+	"https":  {},
+	"socks5": {},
+}
+
+var proxyV1Schema = &configSchema{
+	Properties: map[string]schemaType{
+		"domains": schemaArray,
+		"proxy":   schemaString,
+	},
+	Required: []string{"domains", "proxy"},
+}
+
+func init() {
+	addParserWithSchema("proxy", "v1", proxyV1Schema, &proxyV1JsonParser{})
+	registerSubDir("images.d", []string{"proxy"})
+}
+
+func (p *proxyV1JsonParser) parse(config *Config, raw []byte) error {
+	var proxy proxyV1
+	if err := json.Unmarshal(raw, &proxy); err != nil {
+		return err
+	}
+	if len(proxy.Domains) == 0 {
+		return fmt.Errorf("no domains specified")
+	}
+	if len(proxy.Proxy) == 0 {
+		return fmt.Errorf("no proxy specified")
+	}
+	proxyURL, err := parseProxyV1(proxy.Proxy)
+	if err != nil {
+		return err
+	}
+	for _, domain := range proxy.Domains {
+		if _, ok := config.ProxyPerHost[domain]; ok {
+			return fmt.Errorf("proxy for domain %q is already specified", domain)
+		}
+		config.ProxyPerHost[domain] = proxyURL
+	}
+	return nil
+}
+
+// parseProxyV1 parses the proxy value, it returns nil for the direct
+// connection.
+func parseProxyV1(proxy string) (*url.URL, error) {
+	if proxy == proxyDirect {
+		return nil, nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %v", proxy, err)
+	}
+	if _, ok := allowedProxySchemes[u.Scheme]; !ok {
+		return nil, fmt.Errorf("invalid scheme %q in proxy URL %q", u.Scheme, proxy)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("no host in proxy URL %q", proxy)
+	}
+	return u, nil
+}
+
+// ProxyFunc returns a function suitable for the Proxy field of
+// http.Transport. The proxy for the host of a request is taken from
+// proxyPerHost, a nil URL there meaning a direct connection. Requests
+// to other hosts use the proxy from the environment, like
+// http.ProxyFromEnvironment.
+func ProxyFunc(proxyPerHost map[string]*url.URL) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		if proxyURL, ok := proxyPerHost[req.URL.Host]; ok {
+			return proxyURL, nil
+		}
+		if proxyURL, ok := proxyPerHost[req.URL.Hostname()]; ok {
+			return proxyURL, nil
+		}
+		return http.ProxyFromEnvironment(req)
+	}
+}
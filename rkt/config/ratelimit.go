@@ -0,0 +1,60 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type rateLimitV1JsonParser struct{}
+
+type rateLimitV1 struct {
+	Domains           []string `json:"domains"`
+	RequestsPerSecond float64  `json:"requestsPerSecond"`
+}
+
+var rateLimitV1Schema = &configSchema{
+	Properties: map[string]schemaType{
+		"domains":           schemaArray,
+		"requestsPerSecond": schemaNumber,
+	},
+	Required: []string{"domains", "requestsPerSecond"},
+}
+
+func init() {
+	addParserWithSchema("rateLimit", "v1", rateLimitV1Schema, &rateLimitV1JsonParser{})
+	registerSubDir("images.d", []string{"rateLimit"})
+}
+
+func (p *rateLimitV1JsonParser) parse(config *Config, raw []byte) error {
+	var rateLimit rateLimitV1
+	if err := json.Unmarshal(raw, &rateLimit); err != nil {
+		return err
+	}
+	if len(rateLimit.Domains) == 0 {
+		return fmt.Errorf("no domains specified")
+	}
+	if rateLimit.RequestsPerSecond <= 0 {
+		return fmt.Errorf("requests per second must be positive, got %v", rateLimit.RequestsPerSecond)
+	}
+	for _, domain := range rateLimit.Domains {
+		if _, ok := config.RateLimitPerHost[domain]; ok {
+			return fmt.Errorf("rate limit for domain %q is already specified", domain)
+		}
+		config.RateLimitPerHost[domain] = rateLimit.RequestsPerSecond
+	}
+	return nil
+}
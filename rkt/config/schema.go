@@ -0,0 +1,187 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// schemaType is a JSON type of a field, named after the JSON Schema
+// types.
+type schemaType string
+
+const (
+	schemaString  schemaType = "string"
+	schemaInteger schemaType = "integer"
+	schemaNumber  schemaType = "number"
+	schemaBoolean schemaType = "boolean"
+	schemaArray   schemaType = "array"
+	schemaObject  schemaType = "object"
+)
+
+// configSchema describes the fields allowed in a configuration file
+// of some kind and version. It is a small subset of JSON Schema,
+// enough to catch misspelled or mistyped fields, which the parsers
+// silently ignore.
+type configSchema struct {
+	// Properties maps the allowed fields to their types. The
+	// rktKind and rktVersion fields are always allowed.
+	Properties map[string]schemaType
+	// Required lists the fields that must be present.
+	Required []string
+}
+
+// schemaParser is a configParser validating the raw configuration
+// against a schema before passing it to the wrapped parser.
+type schemaParser struct {
+	schema *configSchema
+	parser configParser
+}
+
+func (p *schemaParser) parse(config *Config, raw []byte) error {
+	if err := p.schema.validate(raw); err != nil {
+		return err
+	}
+	return p.parser.parse(config, raw)
+}
+
+// addParserWithSchema registers a parser, which will only get
+// configuration files valid according to the passed schema.
+func addParserWithSchema(kind, version string, schema *configSchema, parser configParser) {
+	if schema == nil {
+		panic("trying to register a nil schema")
+	}
+	addParser(kind, version, &schemaParser{
+		schema: schema,
+		parser: parser,
+	})
+}
+
+func (s *configSchema) validate(raw []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "rktKind" || name == "rktVersion" {
+			continue
+		}
+		typ, ok := s.Properties[name]
+		if !ok {
+			if suggestion := s.suggest(name); suggestion != "" {
+				return fmt.Errorf("unknown field %q, did you mean %q?", name, suggestion)
+			}
+			return fmt.Errorf("unknown field %q", name)
+		}
+		actual := jsonType(fields[name])
+		if typ == schemaNumber && actual == string(schemaInteger) {
+			// integers are numbers too
+			actual = string(schemaNumber)
+		}
+		if actual != string(typ) {
+			return fmt.Errorf("invalid field %q: expected %s, got %s", name, typ, actual)
+		}
+	}
+	for _, name := range s.Required {
+		if _, ok := fields[name]; !ok {
+			return fmt.Errorf("missing required field %q", name)
+		}
+	}
+	return nil
+}
+
+// suggest returns the allowed field closest to the passed unknown
+// one, if it is likely a typo.
+func (s *configSchema) suggest(name string) string {
+	best := ""
+	bestDistance := 3
+	for property := range s.Properties {
+		if strings.EqualFold(property, name) {
+			return property
+		}
+		if d := editDistance(property, name); d < bestDistance || d == bestDistance && property < best {
+			best, bestDistance = property, d
+		}
+	}
+	if bestDistance > 2 {
+		return ""
+	}
+	return best
+}
+
+func jsonType(raw json.RawMessage) string {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "invalid"
+	}
+	switch value := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return string(schemaString)
+	case bool:
+		return string(schemaBoolean)
+	case float64:
+		if value == float64(int64(value)) {
+			return string(schemaInteger)
+		}
+		return string(schemaNumber)
+	case []interface{}:
+		return string(schemaArray)
+	case map[string]interface{}:
+		return string(schemaObject)
+	}
+	return "unknown"
+}
+
+// editDistance computes the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}
@@ -0,0 +1,171 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/errwrap"
+)
+
+// SourceStatus tells what rkt does with a file found in a
+// configuration directory.
+type SourceStatus string
+
+const (
+	// SourceParsed means that the file is a valid configuration
+	// file.
+	SourceParsed SourceStatus = "parsed"
+	// SourceIgnored means that rkt skips the file.
+	SourceIgnored SourceStatus = "ignored"
+	// SourceInvalid means that rkt fails when reading the file.
+	SourceInvalid SourceStatus = "invalid"
+)
+
+// Source describes a file or a directory found in a configuration
+// directory.
+type Source struct {
+	Path   string       `json:"path"`
+	Status SourceStatus `json:"status"`
+	// Kind and Version are set for the parsed files.
+	Kind    string `json:"kind,omitempty"`
+	Version string `json:"version,omitempty"`
+	// Reason says why the file was ignored or is invalid.
+	Reason string `json:"reason,omitempty"`
+}
+
+// GetConfigSources lists the files in the given configuration
+// directories, with the decision rkt makes for each of them when
+// reading the configuration. Unlike GetConfigFrom, it does not stop
+// at the first invalid file.
+func GetConfigSources(dirs ...string) ([]Source, error) {
+	var sources []Source
+	for _, dir := range dirs {
+		dirSources, err := getDirSources(dir)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, dirSources...)
+	}
+	return sources, nil
+}
+
+func getDirSources(dir string) ([]Source, error) {
+	if valid, err := validDir(dir); err != nil {
+		return nil, err
+	} else if !valid {
+		return nil, nil
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []Source
+	// parse the files into a single config, like readConfigDir
+	// does, to catch the settings specified multiple times
+	config := newConfig()
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		kinds, ok := configSubDirs[entry.Name()]
+		switch {
+		case !ok:
+			sources = append(sources, ignoredSource(path, "not a configuration subdirectory"))
+		case !entry.IsDir():
+			sources = append(sources, ignoredSource(path, "not a directory"))
+		default:
+			subdirSources, err := getSubDirSources(config, path, kinds)
+			if err != nil {
+				return nil, err
+			}
+			sources = append(sources, subdirSources...)
+		}
+	}
+	return sources, nil
+}
+
+func getSubDirSources(config *Config, dir string, kinds []string) ([]Source, error) {
+	if disabled, err := disabledDir(dir); err != nil {
+		return nil, err
+	} else if disabled {
+		return []Source{ignoredSource(dir, fmt.Sprintf("disabled with a %q file", disabledMarker))}, nil
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []Source
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		mode := entry.Mode()
+		switch {
+		case mode.IsDir():
+			sources = append(sources, ignoredSource(path, "subdirectories are not read"))
+		case !mode.IsRegular():
+			sources = append(sources, ignoredSource(path, "not a regular file"))
+		case filepath.Ext(entry.Name()) != ".json":
+			sources = append(sources, ignoredSource(path, "no .json extension"))
+		default:
+			sources = append(sources, getFileSource(config, path, kinds))
+		}
+	}
+	return sources, nil
+}
+
+func getFileSource(config *Config, path string, kinds []string) Source {
+	raw, err := ioutil.ReadFile(path)
+	if err == nil {
+		err = parseConfigBytes(config, raw, path, kinds)
+	}
+	if err != nil {
+		return Source{
+			Path:   path,
+			Status: SourceInvalid,
+			Reason: errorChain(err),
+		}
+	}
+	var header configHeader
+	// parseConfigBytes succeeded, so this cannot fail
+	json.Unmarshal(bytes.TrimPrefix(raw, utf8BOM), &header)
+	return Source{
+		Path:    path,
+		Status:  SourceParsed,
+		Kind:    header.RktKind,
+		Version: header.RktVersion,
+	}
+}
+
+func ignoredSource(path, reason string) Source {
+	return Source{
+		Path:   path,
+		Status: SourceIgnored,
+		Reason: reason,
+	}
+}
+
+// errorChain joins the messages of the wrapped errors.
+func errorChain(err error) string {
+	var msgs []string
+	errwrap.Walk(err, func(e error) {
+		msgs = append(msgs, e.Error())
+	})
+	return strings.Join(msgs, ": ")
+}
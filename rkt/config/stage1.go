@@ -29,6 +29,9 @@ type stage1V1 struct {
 	Name     string `json:"name"`
 	Version  string `json:"version"`
 	Location string `json:"location"`
+	// FallbackToBuiltin is a pointer to tell an omitted value
+	// from false
+	FallbackToBuiltin *bool `json:"fallbackToBuiltin"`
 }
 
 var (
@@ -68,6 +71,13 @@ func (p *stage1V1JsonParser) parse(config *Config, raw []byte) error {
 		}
 		config.Stage1.Location = stage1.Location
 	}
+	if stage1.FallbackToBuiltin != nil {
+		if config.Stage1.fallbackSet {
+			return fmt.Errorf("falling back to the built-in stage1 image is already specified")
+		}
+		config.Stage1.FallbackToBuiltin = *stage1.FallbackToBuiltin
+		config.Stage1.fallbackSet = true
+	}
 	return nil
 }
 
@@ -0,0 +1,108 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/appc/spec/schema/types"
+)
+
+type volumesV1JsonParser struct{}
+
+type volumesV1 struct {
+	Volumes []volumeV1 `json:"volumes"`
+}
+
+type volumeV1 struct {
+	Name     string `json:"name"`
+	Source   string `json:"source"`
+	Target   string `json:"target"`
+	ReadOnly bool   `json:"readOnly"`
+}
+
+var volumesV1Schema = &configSchema{
+	Properties: map[string]schemaType{
+		"volumes": schemaArray,
+	},
+	Required: []string{"volumes"},
+}
+
+func init() {
+	addParserWithSchema("volumes", "v1", volumesV1Schema, &volumesV1JsonParser{})
+	registerSubDir("volumes.d", []string{"volumes"})
+}
+
+func (p *volumesV1JsonParser) parse(config *Config, raw []byte) error {
+	var volumes volumesV1
+	if err := json.Unmarshal(raw, &volumes); err != nil {
+		return err
+	}
+	if len(volumes.Volumes) == 0 {
+		return errors.New("no volumes specified")
+	}
+	for _, v := range volumes.Volumes {
+		if _, err := types.NewACName(v.Name); err != nil {
+			return fmt.Errorf("invalid volume name %q: %v", v.Name, err)
+		}
+		if !filepath.IsAbs(v.Source) {
+			return fmt.Errorf("source of volume %q must be an absolute path", v.Name)
+		}
+		if _, err := os.Stat(v.Source); err != nil {
+			return fmt.Errorf("invalid source of volume %q: %v", v.Name, err)
+		}
+		if !filepath.IsAbs(v.Target) {
+			return fmt.Errorf("target of volume %q must be an absolute path", v.Name)
+		}
+		for _, dv := range config.DefaultVolumes {
+			if dv.Name == v.Name {
+				return fmt.Errorf("volume %q is already specified", v.Name)
+			}
+			if filepath.Clean(dv.Target) == filepath.Clean(v.Target) {
+				return fmt.Errorf("volumes %q and %q have the same target %q", dv.Name, v.Name, v.Target)
+			}
+		}
+		config.DefaultVolumes = append(config.DefaultVolumes, DefaultVolume{
+			Name:     v.Name,
+			Source:   v.Source,
+			Target:   v.Target,
+			ReadOnly: v.ReadOnly,
+		})
+	}
+	return nil
+}
+
+// mergeDefaultVolumes replaces the default volumes in vols with the
+// ones of the same name in subvols and appends the others.
+func mergeDefaultVolumes(vols, subvols []DefaultVolume) []DefaultVolume {
+	for _, sv := range subvols {
+		replaced := false
+		for i, v := range vols {
+			if v.Name == sv.Name {
+				vols[i] = sv
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			vols = append(vols, sv)
+		}
+	}
+	return vols
+}
@@ -0,0 +1,56 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+
+	"github.com/rkt/rkt/rkt/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	cmdConfigSources = &cobra.Command{
+		Use:   "sources",
+		Short: "List the files in the configuration directories and whether they are used",
+		Long: `Each file found in the system, local and user configuration directories is listed
+with its status: "parsed" for the configuration files used by rkt, "ignored" for the
+files rkt skips and "invalid" for the files rkt fails to read, with the reason.`,
+		Run: runWrapper(runConfigSources),
+	}
+)
+
+func init() {
+	cmdConfig.AddCommand(cmdConfigSources)
+}
+
+func runConfigSources(cmd *cobra.Command, args []string) int {
+	sources, err := config.GetConfigSources(getConfigDirs()...)
+	if err != nil {
+		stderr.PrintE("cannot get configuration sources", err)
+		return 254
+	}
+
+	fmt.Fprintf(tabOut, "STATUS\tPATH\tDETAILS\n")
+	for _, s := range sources {
+		details := s.Reason
+		if s.Status == config.SourceParsed {
+			details = fmt.Sprintf("%s %s", s.Kind, s.Version)
+		}
+		fmt.Fprintf(tabOut, "%s\t%s\t%s\n", s.Status, s.Path, details)
+	}
+	tabOut.Flush()
+	return 0
+}
@@ -49,7 +49,7 @@ func init() {
 	// This is needed to correctly handle multiple IMAGE --signature=sigfile options
 	cmdFetch.Flags().SetInterspersed(false)
 
-	cmdFetch.Flags().Var((*appAsc)(&rktApps), "signature", "local signature file to use in validating the preceding image")
+	cmdFetch.Flags().Var((*appAsc)(&rktApps), "signature", "local signature file to use in validating the preceding image, can be specified multiple times")
 	cmdFetch.Flags().BoolVar(&flagStoreOnly, "store-only", false, "use only available images in the store (do not discover or download from remote URLs)")
 	cmdFetch.Flags().MarkDeprecated("store-only", "please use --pull-policy=never")
 	cmdFetch.Flags().BoolVar(&flagNoStore, "no-store", false, "fetch images ignoring the local store")
@@ -66,11 +66,16 @@ func init() {
 }
 
 func runFetch(cmd *cobra.Command, args []string) (exit int) {
-	if err := parseApps(&rktApps, args, cmd.Flags(), false); err != nil {
+	if err := parseApps(&rktApps, args, cmd.Flags(), false, false); err != nil {
 		stderr.PrintE("unable to parse arguments", err)
 		return 254
 	}
 
+	if err := applyConfigInsecureOptions(); err != nil {
+		stderr.PrintE("cannot apply default insecure options", err)
+		return 254
+	}
+
 	if rktApps.Count() < 1 {
 		stderr.Print("must provide at least one image")
 		return 254
@@ -114,6 +119,11 @@ func runFetch(cmd *cobra.Command, args []string) (exit int) {
 		Ts:                 ts,
 		Ks:                 ks,
 		Headers:            config.AuthPerHost,
+		DefaultHeaders:     config.DefaultAuth,
+		ProxyPerHost:       config.ProxyPerHost,
+		RateLimitPerHost:   config.RateLimitPerHost,
+		FetchPolicy:        config.FetchPolicy,
+		RequireSignature:   config.RequireSignature,
 		DockerAuth:         config.DockerCredentialsPerRegistry,
 		InsecureFlags:      globalFlags.InsecureFlags,
 		Debug:              globalFlags.Debug,
@@ -292,7 +292,7 @@ func TestDownloading(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unexpected error %v", err)
 		}
-		_, err = ft.FetchImage(d, tt.aciURL, "")
+		_, err = ft.FetchImage(d, tt.aciURL, nil)
 		if err != nil && !tt.authFail {
 			t.Fatalf("expected download to succeed, it failed: %v (server: %q, headers: `%v`)", err, urlToName[tt.aciURL], tt.options)
 		}
@@ -374,12 +374,105 @@ func TestFetchImage(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error %v", err)
 	}
-	_, err = ft.FetchImage(d, u.String(), "")
+	_, err = ft.FetchImage(d, u.String(), nil)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
 
+func TestFetchImageMultipleSignatures(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fetch-image-signatures")
+	if err != nil {
+		t.Fatalf("error creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := imagestore.NewStore(dir)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer s.Dump(false)
+
+	ks, ksPath, err := keystore.NewTestKeystore()
+	if err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	defer os.RemoveAll(ksPath)
+
+	key := keystoretest.KeyMap["example.com/app"]
+	if _, err := ks.StoreTrustedKeyPrefix("example.com/app", bytes.NewBufferString(key.ArmoredPublicKey)); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	a, err := aci.NewBasicACI(dir, "example.com/app")
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	defer a.Close()
+
+	writeSignature := func(name, armoredPrivateKey string) string {
+		if _, err := a.Seek(0, 0); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		sig, err := aci.NewDetachedSignature(armoredPrivateKey, a)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		sigData, err := ioutil.ReadAll(sig)
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, sigData, 0644); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		return path
+	}
+	// signed with a key that is not trusted for example.com/app
+	badAsc := writeSignature("bad.asc", keystoretest.KeyMap["acme.com"].ArmoredPrivateKey)
+	goodAsc := writeSignature("good.asc", key.ArmoredPrivateKey)
+
+	// the ACI file is already unlinked, save it for the file fetcher
+	if _, err := a.Seek(0, 0); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	aciData, err := ioutil.ReadAll(a)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	aciPath := filepath.Join(dir, "app.aci")
+	if err := ioutil.WriteFile(aciPath, aciData, 0644); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	u := &url.URL{Scheme: "file", Path: aciPath}
+	d, err := dist.NewACIArchiveFromTransportURL(u)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	tests := []struct {
+		ascs []string
+		werr bool
+	}{
+		{[]string{goodAsc}, false},
+		{[]string{badAsc}, true},
+		{[]string{badAsc, goodAsc}, false},
+		{[]string{goodAsc, badAsc}, false},
+		{[]string{badAsc, badAsc}, true},
+	}
+
+	for i, tt := range tests {
+		ft := &image.Fetcher{
+			S:             s,
+			Ks:            ks,
+			InsecureFlags: secureFlags,
+		}
+		_, err := ft.FetchImage(d, aciPath, tt.ascs)
+		if gerr := (err != nil); gerr != tt.werr {
+			t.Errorf("#%d: err==%v, want errstate %t", i, err, tt.werr)
+		}
+	}
+}
+
 func TestGetStoreKeyFromApp(t *testing.T) {
 	dir, err := ioutil.TempDir("", "fetch-image")
 	if err != nil {
@@ -571,7 +664,7 @@ func TestFetchImageCache(t *testing.T) {
 		if err != nil {
 			t.Fatalf("unexpected error %v", err)
 		}
-		_, err = ft.FetchImage(d, u.String(), "")
+		_, err = ft.FetchImage(d, u.String(), nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
@@ -587,7 +680,7 @@ func TestFetchImageCache(t *testing.T) {
 		}
 
 		downloadTime := rem.DownloadTime
-		_, err = ft.FetchImage(d, u.String(), "")
+		_, err = ft.FetchImage(d, u.String(), nil)
 		if err != nil {
 			t.Fatalf("unexpected error: %v", err)
 		}
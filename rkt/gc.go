@@ -26,6 +26,7 @@ import (
 
 	"github.com/hashicorp/errwrap"
 	"github.com/rkt/rkt/common"
+	rkt "github.com/rkt/rkt/lib"
 	"github.com/rkt/rkt/pkg/mountinfo"
 	pkgPod "github.com/rkt/rkt/pkg/pod"
 	"github.com/rkt/rkt/stage0"
@@ -95,6 +96,11 @@ func runGC(cmd *cobra.Command, args []string) (exit int) {
 		return 254
 	}
 
+	if err := unmountOrphaned(); err != nil {
+		stderr.PrintE("failed to clean up orphaned mounts", err)
+		return 254
+	}
+
 	return
 }
 
@@ -198,6 +204,24 @@ func emptyGarbage() error {
 	return nil
 }
 
+// unmountOrphaned unmounts the mounts left under the pods tree by pods
+// whose directory does not exist anymore
+func unmountOrphaned() error {
+	mnts, err := rkt.FindOrphanedOverlayMounts(getDataDir())
+	if err != nil {
+		return err
+	}
+
+	for _, mnt := range mnts {
+		stderr.Printf("unmounting orphaned mount %q", mnt)
+		if err := syscall.Unmount(mnt, syscall.MNT_DETACH); err != nil {
+			stderr.PrintE(fmt.Sprintf("error unmounting %q", mnt), err)
+		}
+	}
+
+	return nil
+}
+
 // mountPodStage1 tries to remount stage1 image overlay in case
 // it is not anymore available in place (e.g. a reboot happened
 // in-between). If an overlay mount is already there, we assume
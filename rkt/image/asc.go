@@ -16,6 +16,7 @@ package image
 
 import (
 	"errors"
+	"io"
 	"net/url"
 	"os"
 
@@ -77,18 +78,62 @@ func (f *remoteAscFetcher) Get(location string) (readSeekCloser, error) {
 
 // asc is an abstraction for getting signature files.
 type asc struct {
-	// Location is a string passed to the Fetcher.
-	Location string
+	// Locations are strings passed to the Fetcher, one for each
+	// signature file. An image is accepted if any of the
+	// signatures is valid.
+	Locations []string
 	// Fetcher (if available) does the actual fetching of a
 	// signature key.
 	Fetcher ascFetcher
 }
 
-// Get fetches a signature file. It returns nil and no error if there
-// was no fetcher set.
-func (a *asc) Get() (readSeekCloser, error) {
-	if a.Fetcher != nil {
-		return a.Fetcher.Get(a.Location)
+// Location returns the location of the first signature file, or an
+// empty string if there is none.
+func (a *asc) Location() string {
+	if len(a.Locations) == 0 {
+		return ""
 	}
-	return NopReadSeekCloser(nil), nil
+	return a.Locations[0]
+}
+
+// Get fetches the signature files. It returns a single empty
+// signature and no error if there was no fetcher set.
+func (a *asc) Get() (signatures, error) {
+	if a.Fetcher == nil {
+		return signatures{NopReadSeekCloser(nil)}, nil
+	}
+	sigs := make(signatures, 0, len(a.Locations))
+	for _, location := range a.Locations {
+		sig, err := a.Fetcher.Get(location)
+		if err != nil {
+			sigs.Close()
+			return nil, err
+		}
+		sigs = append(sigs, sig)
+	}
+	return sigs, nil
+}
+
+// signatures is a list of fetched signature files.
+type signatures []readSeekCloser
+
+// Close closes all the signature files, it returns the first error
+// encountered.
+func (s signatures) Close() error {
+	var firstErr error
+	for _, sig := range s {
+		if err := sig.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// ReadSeekers returns the signature files as a list of io.ReadSeeker.
+func (s signatures) ReadSeekers() []io.ReadSeeker {
+	rs := make([]io.ReadSeeker, 0, len(s))
+	for _, sig := range s {
+		rs = append(rs, sig)
+	}
+	return rs
 }
@@ -59,6 +59,24 @@ type action struct {
 	// Headers is a map of headers which might be used for
 	// downloading via https protocol.
 	Headers map[string]config.Headerer
+	// DefaultHeaders is used for the hosts without an entry in
+	// Headers.
+	DefaultHeaders config.Headerer
+	// ProxyPerHost is a map of proxies which might be used for
+	// downloading via http or https protocol, a nil URL means a
+	// direct connection.
+	ProxyPerHost map[string]*url.URL
+	// RateLimitPerHost is a map of the maximum number of requests
+	// per second sent to specific hosts when downloading via http
+	// or https protocol.
+	RateLimitPerHost map[string]float64
+	// FetchPolicy holds the timeout and retry settings used for
+	// downloading via http or https protocol.
+	FetchPolicy config.FetchPolicy
+	// RequireSignature are the prefixes of the image names that
+	// are always verified, even if the image check is disabled
+	// with the insecure flags.
+	RequireSignature []string
 	// DockerAuth is used for authenticating when fetching docker
 	// images.
 	DockerAuth map[string]config.BasicCredentials
@@ -19,10 +19,17 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/hashicorp/errwrap"
+	"github.com/rkt/rkt/common"
+	"golang.org/x/net/context"
 )
 
+// retryBackoff is the time to wait before the first retry of a
+// failed download. It is doubled after every subsequent failure.
+var retryBackoff = time.Second
+
 // downloadSession is an interface used by downloader for controlling
 // the downloading process.
 type downloadSession interface {
@@ -47,11 +54,48 @@ type downloadSession interface {
 type downloader struct {
 	// Session controls the download process
 	Session downloadSession
+	// Retries is the number of times the download is retried
+	// after a transient failure (a connection error or a 5xx HTTP
+	// status).
+	Retries int
+}
+
+// transientError wraps an error after which it makes sense to retry
+// the download.
+type transientError struct {
+	err error
+}
+
+func (e *transientError) Error() string {
+	return e.err.Error()
 }
 
 // Download tries to fetch the passed URL and write the contents into
-// a given writeSyncer instance.
+// a given writeSyncer instance. Transient failures are retried with
+// an exponential backoff up to d.Retries times.
 func (d *downloader) Download(u *url.URL, out writeSyncer) error {
+	b := common.Backoff{
+		Initial: retryBackoff,
+		Retryable: func(err error) bool {
+			_, ok := err.(*transientError)
+			return ok
+		},
+		Notify: func(err error, delay time.Duration) {
+			if log != nil {
+				log.Printf("downloading %q failed, retrying in %v: %v", u.String(), delay, err)
+			}
+		},
+	}
+	err := b.Retry(context.Background(), d.Retries+1, func() error {
+		return d.download(u, out)
+	})
+	if tErr, ok := err.(*transientError); ok {
+		return tErr.err
+	}
+	return err
+}
+
+func (d *downloader) download(u *url.URL, out writeSyncer) error {
 	client, err := d.Session.Client()
 	if err != nil {
 		return err
@@ -62,10 +106,14 @@ func (d *downloader) Download(u *url.URL, out writeSyncer) error {
 	}
 	res, err := client.Do(req)
 	if err != nil {
-		return err
+		return &transientError{err}
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode >= http.StatusInternalServerError {
+		return &transientError{fmt.Errorf("bad HTTP status code: %d", res.StatusCode)}
+	}
+
 	if stopNow, err := d.Session.HandleStatus(res); stopNow || err != nil {
 		return err
 	}
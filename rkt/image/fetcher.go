@@ -59,17 +59,18 @@ func (f *Fetcher) FetchImages(al *apps.Apps) error {
 }
 
 // FetchImage will take an image as either a path, a URL or a name
-// string and import it into the store if found. If ascPath is not "",
-// it must exist as a local file and will be used as the signature
-// file for verification, unless verification is disabled. If
-// f.WithDeps is true also image dependencies are fetched.
-func (f *Fetcher) FetchImage(d dist.Distribution, image, ascPath string) (*types.Hash, error) {
+// string and import it into the store if found. If ascPaths is not
+// empty, each of them must exist as a local file and they will be
+// used as the signature files for verification, unless verification
+// is disabled. The image is accepted if any of the signatures is
+// valid. If f.WithDeps is true also image dependencies are fetched.
+func (f *Fetcher) FetchImage(d dist.Distribution, image string, ascPaths []string) (*types.Hash, error) {
 	ensureLogger(f.Debug)
 	db := &distBundle{
 		dist:  d,
 		image: image,
 	}
-	a := f.getAsc(ascPath)
+	a := f.getAsc(ascPaths)
 	hash, err := f.fetchSingleImage(db, a)
 	if err != nil {
 		return nil, err
@@ -98,11 +99,11 @@ func (f *Fetcher) FetchImage(d dist.Distribution, image, ascPath string) (*types
 	return h, nil
 }
 
-func (f *Fetcher) getAsc(ascPath string) *asc {
-	if ascPath != "" {
+func (f *Fetcher) getAsc(ascPaths []string) *asc {
+	if len(ascPaths) > 0 {
 		return &asc{
-			Location: ascPath,
-			Fetcher:  &localAscFetcher{},
+			Locations: ascPaths,
+			Fetcher:   &localAscFetcher{},
 		}
 	}
 	return &asc{}
@@ -243,12 +244,17 @@ func (f *Fetcher) maybeFetchHTTPURLFromRemote(rem *imagestore.Remote, u *url.URL
 	if f.PullPolicy != PullPolicyNever {
 		diag.Printf("remote fetching from URL %q", u.String())
 		hf := &httpFetcher{
-			InsecureFlags: f.InsecureFlags,
-			S:             f.S,
-			Ks:            f.Ks,
-			Rem:           rem,
-			Debug:         f.Debug,
-			Headers:       f.Headers,
+			InsecureFlags:    f.InsecureFlags,
+			S:                f.S,
+			Ks:               f.Ks,
+			Rem:              rem,
+			Debug:            f.Debug,
+			Headers:          f.Headers,
+			DefaultHeaders:   f.DefaultHeaders,
+			ProxyPerHost:     f.ProxyPerHost,
+			RateLimitPerHost: f.RateLimitPerHost,
+			FetchPolicy:      f.FetchPolicy,
+			RequireSignature: f.RequireSignature,
 		}
 		return hf.Hash(u, a)
 	}
@@ -272,10 +278,11 @@ func (f *Fetcher) maybeFetchDockerURLFromRemote(u *url.URL) (string, error) {
 func (f *Fetcher) fetchSingleImageByPath(path string, a *asc) (string, error) {
 	diag.Printf("using image from file %s", path)
 	ff := &fileFetcher{
-		InsecureFlags: f.InsecureFlags,
-		S:             f.S,
-		Ks:            f.Ks,
-		Debug:         f.Debug,
+		InsecureFlags:    f.InsecureFlags,
+		S:                f.S,
+		Ks:               f.Ks,
+		Debug:            f.Debug,
+		RequireSignature: f.RequireSignature,
 	}
 	return ff.Hash(path, a)
 }
@@ -356,7 +363,12 @@ func (f *Fetcher) maybeFetchImageFromRemote(db *distBundle, a *asc) (string, err
 			NoCache:            f.NoCache,
 			Debug:              f.Debug,
 			Headers:            f.Headers,
+			DefaultHeaders:     f.DefaultHeaders,
+			ProxyPerHost:       f.ProxyPerHost,
+			RateLimitPerHost:   f.RateLimitPerHost,
+			FetchPolicy:        f.FetchPolicy,
 			TrustKeysFromHTTPS: f.TrustKeysFromHTTPS,
+			RequireSignature:   f.RequireSignature,
 		}
 		return nf.Hash(app, a)
 	}
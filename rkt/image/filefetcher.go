@@ -32,6 +32,9 @@ type fileFetcher struct {
 	S             *imagestore.Store
 	Ks            *keystore.Keystore
 	Debug         bool
+	// RequireSignature are the prefixes of the image names
+	// verified despite the insecure flags.
+	RequireSignature []string
 }
 
 // Hash opens a file, optionally verifies it against passed asc,
@@ -69,7 +72,16 @@ func (f *fileFetcher) getFile(aciPath string, a *asc) (*os.File, error) {
 		if err != nil {
 			return nil, errwrap.Wrap(errors.New("error opening ACI file"), err)
 		}
-		return aciFile, nil
+		if f.Ks == nil {
+			return aciFile, nil
+		}
+		if required, err := imageRequiresSignature(f.RequireSignature, aciFile); err != nil {
+			aciFile.Close()
+			return nil, err
+		} else if !required {
+			return aciFile, nil
+		}
+		aciFile.Close()
 	}
 	aciFile, err := f.getVerifiedFile(aciPath, a)
 	if err != nil {
@@ -106,7 +118,7 @@ func (f *fileFetcher) getVerifiedFile(aciPath string, a *asc) (*os.File, error)
 		return nil, errClose
 	}
 
-	entity, errClose := validator.ValidateWithSignature(f.Ks, ascFile)
+	entity, errClose := validator.ValidateWithSignature(f.Ks, ascFile.ReadSeekers()...)
 	if errClose != nil {
 		return nil, errwrap.Wrap(fmt.Errorf("image %q verification failed", validator.ImageName()), errClose)
 	}
@@ -119,6 +131,6 @@ func (f *fileFetcher) maybeOverrideAsc(aciPath string, a *asc) {
 	if a.Fetcher != nil {
 		return
 	}
-	a.Location = ascPathFromImgPath(aciPath)
+	a.Locations = []string{ascPathFromImgPath(aciPath)}
 	a.Fetcher = &localAscFetcher{}
 }
@@ -0,0 +1,95 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rkt/rkt/pkg/aci"
+	"github.com/rkt/rkt/pkg/keystore"
+	rktflag "github.com/rkt/rkt/rkt/flag"
+)
+
+func TestFileFetcherRequireSignature(t *testing.T) {
+	ensureLogger(false)
+
+	dir, err := ioutil.TempDir("", "fetch-image")
+	if err != nil {
+		t.Fatalf("error creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	ks, ksPath, err := keystore.NewTestKeystore()
+	if err != nil {
+		t.Fatalf("error creating keystore: %v", err)
+	}
+	defer os.RemoveAll(ksPath)
+
+	insecureFlags, err := rktflag.NewSecFlags("image")
+	if err != nil {
+		t.Fatalf("unexpected error creating insecure flags: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		prefixes []string
+		fail     bool
+	}{
+		{"example.com/app", nil, false},
+		{"example.com/app", []string{"example.com/secure"}, false},
+		{"example.com/secure", []string{"example.com/secure"}, true},
+		{"example.com/secure/app", []string{"example.com/secure"}, true},
+		{"example.com/secureapp", []string{"example.com/secure"}, false},
+	}
+	for _, tt := range tests {
+		// NewBasicACI removes the file, keep its contents
+		aciFile, err := aci.NewBasicACI(dir, tt.name)
+		if err != nil {
+			t.Fatalf("error creating test ACI: %v", err)
+		}
+		aciPath := filepath.Join(dir, "image.aci")
+		if _, err := aciFile.Seek(0, 0); err != nil {
+			t.Fatalf("error seeking test ACI: %v", err)
+		}
+		contents, err := ioutil.ReadAll(aciFile)
+		aciFile.Close()
+		if err != nil {
+			t.Fatalf("error reading test ACI: %v", err)
+		}
+		if err := ioutil.WriteFile(aciPath, contents, 0644); err != nil {
+			t.Fatalf("error writing test ACI: %v", err)
+		}
+
+		f := &fileFetcher{
+			InsecureFlags:    insecureFlags,
+			Ks:               ks,
+			RequireSignature: tt.prefixes,
+		}
+		// the image has no signature, so it can only be used
+		// without verification
+		file, err := f.getFile(aciPath, &asc{})
+		if file != nil {
+			file.Close()
+		}
+		if tt.fail && err == nil {
+			t.Errorf("expected unsigned image %q to be rejected with prefixes %v", tt.name, tt.prefixes)
+		} else if !tt.fail && err != nil {
+			t.Errorf("unexpected error for image %q with prefixes %v: %v", tt.name, tt.prefixes, err)
+		}
+	}
+}
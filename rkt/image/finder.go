@@ -41,8 +41,8 @@ func (f *Finder) FindImages(al *apps.Apps) error {
 
 // FindImage tries to get a hash of a passed image, ideally from
 // store. Otherwise this might involve fetching it from remote with
-// the Fetcher.
-func (f *Finder) FindImage(img string, asc string) (*types.Hash, error) {
+// the Fetcher, using the optional local signature files in ascs.
+func (f *Finder) FindImage(img string, ascs []string) (*types.Hash, error) {
 	ensureLogger(f.Debug)
 
 	// Check if it's an hash
@@ -61,7 +61,7 @@ func (f *Finder) FindImage(img string, asc string) (*types.Hash, error) {
 
 	// urls, names, paths have to be fetched, potentially remotely
 	ft := (*Fetcher)(f)
-	h, err := ft.FetchImage(d, img, asc)
+	h, err := ft.FetchImage(d, img, ascs)
 	if err != nil {
 		return nil, err
 	}
@@ -30,13 +30,20 @@ import (
 
 // httpFetcher is used to download images from http or https URLs.
 type httpFetcher struct {
-	InsecureFlags *rktflag.SecFlags
-	S             *imagestore.Store
-	Ks            *keystore.Keystore
-	Rem           *imagestore.Remote
-	NoCache       bool
-	Debug         bool
-	Headers       map[string]config.Headerer
+	InsecureFlags    *rktflag.SecFlags
+	S                *imagestore.Store
+	Ks               *keystore.Keystore
+	Rem              *imagestore.Remote
+	NoCache          bool
+	Debug            bool
+	Headers          map[string]config.Headerer
+	DefaultHeaders   config.Headerer
+	ProxyPerHost     map[string]*url.URL
+	RateLimitPerHost map[string]float64
+	FetchPolicy      config.FetchPolicy
+	// RequireSignature are the prefixes of the image names
+	// verified despite the insecure flags.
+	RequireSignature []string
 }
 
 // Hash fetches the URL, optionally verifies it against passed asc,
@@ -77,7 +84,7 @@ func (f *httpFetcher) Hash(u *url.URL, a *asc) (string, error) {
 	// TODO(krnowak): What's the point of the second parameter?
 	// The SigURL field in imagestore.Remote seems to be completely
 	// unused.
-	newRem := imagestore.NewRemote(urlStr, a.Location)
+	newRem := imagestore.NewRemote(urlStr, a.Location())
 	newRem.BlobKey = key
 	newRem.DownloadTime = time.Now()
 	if cd != nil {
@@ -106,7 +113,16 @@ func (f *httpFetcher) fetchURL(u *url.URL, a *asc, etag string) (readSeekCloser,
 		if err != nil {
 			return nil, nil, err
 		}
-		return aciFile, cd, err
+		if f.Ks == nil || cd.UseCached {
+			return aciFile, cd, nil
+		}
+		if required, err := imageRequiresSignature(f.RequireSignature, aciFile); err != nil {
+			aciFile.Close()
+			return nil, nil, err
+		} else if !required {
+			return aciFile, cd, nil
+		}
+		aciFile.Close()
 	}
 
 	return f.fetchVerifiedURL(u, a, etag)
@@ -144,12 +160,12 @@ func (f *httpFetcher) fetchVerifiedURL(u *url.URL, a *asc, etag string) (readSee
 		ascFile.Close()
 		ascFile, errClose = o.DownloadSignatureAgain(a)
 		if errClose != nil {
-			ascFile = NopReadSeekCloser(nil)
+			ascFile = nil
 			return nil, nil, errClose
 		}
 	}
 
-	errClose = f.validate(aciFile, ascFile)
+	errClose = f.validate(aciFile, ascFile.ReadSeekers())
 	if errClose != nil {
 		return nil, nil, errClose
 	}
@@ -160,18 +176,22 @@ func (f *httpFetcher) fetchVerifiedURL(u *url.URL, a *asc, etag string) (readSee
 func (f *httpFetcher) httpOps() *httpOps {
 	return &httpOps{
 		InsecureSkipTLSVerify: f.InsecureFlags.SkipTLSCheck(),
-		S:       f.S,
-		Headers: f.Headers,
-		Debug:   f.Debug,
+		S:                     f.S,
+		Headers:               f.Headers,
+		DefaultHeaders:        f.DefaultHeaders,
+		ProxyPerHost:          f.ProxyPerHost,
+		RateLimitPerHost:      f.RateLimitPerHost,
+		FetchPolicy:           f.FetchPolicy,
+		Debug:                 f.Debug,
 	}
 }
 
-func (f *httpFetcher) validate(aciFile, ascFile io.ReadSeeker) error {
+func (f *httpFetcher) validate(aciFile io.ReadSeeker, ascFiles []io.ReadSeeker) error {
 	v, err := newValidator(aciFile)
 	if err != nil {
 		return err
 	}
-	entity, err := v.ValidateWithSignature(f.Ks, ascFile)
+	entity, err := v.ValidateWithSignature(f.Ks, ascFiles...)
 	if err != nil {
 		return err
 	}
@@ -188,6 +208,6 @@ func (f *httpFetcher) maybeOverrideAscFetcherWithRemote(o *httpOps, u *url.URL,
 		return
 	}
 	u2 := ascURLFromImgURL(u)
-	a.Location = u2.String()
+	a.Locations = []string{u2.String()}
 	a.Fetcher = o.AscRemoteFetcher()
 }
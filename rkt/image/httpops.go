@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/rkt/rkt/rkt/config"
@@ -34,22 +35,26 @@ type httpOps struct {
 	InsecureSkipTLSVerify bool
 	S                     *imagestore.Store
 	Headers               map[string]config.Headerer
+	DefaultHeaders        config.Headerer
+	ProxyPerHost          map[string]*url.URL
+	RateLimitPerHost      map[string]float64
+	FetchPolicy           config.FetchPolicy
 	Debug                 bool
 }
 
 // DownloadSignature takes an asc instance and tries to get the
-// signature. If the remote server asked to to defer the download,
+// signatures. If the remote server asked to to defer the download,
 // this function will return true and no error and no file.
-func (o *httpOps) DownloadSignature(a *asc) (readSeekCloser, bool, error) {
+func (o *httpOps) DownloadSignature(a *asc) (signatures, bool, error) {
 	ensureLogger(o.Debug)
-	diag.Printf("downloading signature from %v", a.Location)
+	diag.Printf("downloading signature from %v", strings.Join(a.Locations, ", "))
 	ascFile, err := a.Get()
 	if err == nil {
 		return ascFile, false, nil
 	}
 	if _, ok := err.(*statusAcceptedError); ok {
 		log.Printf("server requested deferring the signature download")
-		return NopReadSeekCloser(nil), true, nil
+		return signatures{NopReadSeekCloser(nil)}, true, nil
 	}
 	return nil, false, errwrap.Wrap(errors.New("error downloading the signature file"), err)
 }
@@ -57,7 +62,7 @@ func (o *httpOps) DownloadSignature(a *asc) (readSeekCloser, bool, error) {
 // DownloadSignatureAgain does a similar thing to DownloadSignature,
 // but it expects the signature to be actually provided, that is - no
 // deferring this time.
-func (o *httpOps) DownloadSignatureAgain(a *asc) (readSeekCloser, error) {
+func (o *httpOps) DownloadSignatureAgain(a *asc) (signatures, error) {
 	ensureLogger(o.Debug)
 	ascFile, retry, err := o.DownloadSignature(a)
 	if err != nil {
@@ -148,6 +153,10 @@ func (o *httpOps) getSession(u *url.URL, file *os.File, label, etag string) *res
 		InsecureSkipTLSVerify: o.InsecureSkipTLSVerify,
 		Headers:               o.getHeaders(u, etag),
 		Headerers:             o.Headers,
+		DefaultHeaderer:       o.DefaultHeaders,
+		ProxyPerHost:          o.ProxyPerHost,
+		RateLimitPerHost:      o.RateLimitPerHost,
+		Timeout:               o.FetchPolicy.Timeout,
 		File:                  file,
 		ETagFilePath:          eTagFilePath,
 		Label:                 label,
@@ -157,6 +166,7 @@ func (o *httpOps) getSession(u *url.URL, file *os.File, label, etag string) *res
 func (o *httpOps) getDownloader(session downloadSession) *downloader {
 	return &downloader{
 		Session: session,
+		Retries: o.FetchPolicy.Retries,
 	}
 }
 
@@ -0,0 +1,129 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rkt/rkt/rkt/config"
+)
+
+func fetchSignatureWithPolicy(t *testing.T, serverURL string, policy config.FetchPolicy) error {
+	f, err := ioutil.TempFile("", "rkt-httpops-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	defer os.Remove(f.Name() + ".etag")
+	defer f.Close()
+
+	u, err := url.Parse(serverURL + "/image.aci.asc")
+	if err != nil {
+		t.Fatalf("failed to parse URL %q: %v", serverURL, err)
+	}
+	o := &httpOps{
+		FetchPolicy: policy,
+	}
+	return o.AscRemoteFetcher().F(u, f)
+}
+
+func TestHTTPOpsRetries(t *testing.T) {
+	oldBackoff := retryBackoff
+	retryBackoff = time.Millisecond
+	defer func() { retryBackoff = oldBackoff }()
+
+	tests := []struct {
+		failures int32
+		retries  int
+		fail     bool
+	}{
+		{0, 0, false},
+		{1, 0, true},
+		{2, 2, false},
+		{3, 2, true},
+	}
+	for i, tt := range tests {
+		var requests int32
+		failures := tt.failures
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&requests, 1) <= failures {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte("signature"))
+		}))
+		err := fetchSignatureWithPolicy(t, ts.URL, config.FetchPolicy{Retries: tt.retries})
+		ts.Close()
+
+		if tt.fail && err == nil {
+			t.Errorf("#%d: expected the download to fail", i)
+		} else if !tt.fail && err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+		}
+		expected := int32(tt.retries + 1)
+		if !tt.fail {
+			expected = tt.failures + 1
+		}
+		if requests != expected {
+			t.Errorf("#%d: expected %d requests, got %d", i, expected, requests)
+		}
+	}
+}
+
+func TestHTTPOpsNoRetryOnClientError(t *testing.T) {
+	oldBackoff := retryBackoff
+	retryBackoff = time.Millisecond
+	defer func() { retryBackoff = oldBackoff }()
+
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+
+	if err := fetchSignatureWithPolicy(t, ts.URL, config.FetchPolicy{Retries: 3}); err == nil {
+		t.Errorf("expected the download to fail")
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+}
+
+func TestHTTPOpsTimeout(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.Write([]byte("signature"))
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	start := time.Now()
+	err := fetchSignatureWithPolicy(t, ts.URL, config.FetchPolicy{Timeout: 100 * time.Millisecond})
+	if err == nil {
+		t.Fatalf("expected the download to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("expected the download to time out after 100ms, took %v", elapsed)
+	}
+}
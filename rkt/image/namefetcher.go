@@ -42,7 +42,14 @@ type nameFetcher struct {
 	NoCache            bool
 	Debug              bool
 	Headers            map[string]config.Headerer
+	DefaultHeaders     config.Headerer
+	ProxyPerHost       map[string]*url.URL
+	RateLimitPerHost   map[string]float64
+	FetchPolicy        config.FetchPolicy
 	TrustKeysFromHTTPS bool
+	// RequireSignature are the prefixes of the image names
+	// verified despite the insecure flags.
+	RequireSignature []string
 }
 
 // Hash runs the discovery, fetches the image, optionally verifies
@@ -71,7 +78,7 @@ func (f *nameFetcher) discoverApp(app *discovery.App) (discovery.ACIEndpoints, e
 	if f.InsecureFlags.AllowHTTP() {
 		insecure = insecure | discovery.InsecureHTTP
 	}
-	hostHeaders := config.ResolveAuthPerHost(f.Headers)
+	hostHeaders := config.ResolveAuthForApp(f.Headers, f.DefaultHeaders, app.Name.String())
 	ep, attempts, err := discovery.DiscoverACIEndpoints(*app, hostHeaders, insecure, 0)
 	if f.Debug {
 		for _, a := range attempts {
@@ -133,7 +140,7 @@ func (f *nameFetcher) fetchImageFromSingleEndpoint(app *discovery.App, aciURL st
 		return "", err
 	}
 
-	newRem := imagestore.NewRemote(aciURL, a.Location)
+	newRem := imagestore.NewRemote(aciURL, a.Location())
 	newRem.BlobKey = key
 	newRem.DownloadTime = time.Now()
 	if cd != nil {
@@ -161,7 +168,9 @@ func (f *nameFetcher) fetch(app *discovery.App, aciURL string, a *asc, etag stri
 		return nil, nil, errwrap.Wrap(errors.New("error parsing ACI url"), err)
 	}
 
-	if f.InsecureFlags.SkipImageCheck() || f.Ks == nil {
+	if f.Ks != nil && f.InsecureFlags.SkipImageCheck() && requiresSignature(f.RequireSignature, app.Name.String()) {
+		log.Printf("image %q requires a valid signature, verifying it despite the insecure options", app.Name)
+	} else if f.InsecureFlags.SkipImageCheck() || f.Ks == nil {
 		o := f.httpOps()
 		aciFile, cd, err := o.DownloadImageWithETag(u, etag)
 		if err != nil {
@@ -188,7 +197,7 @@ func (f *nameFetcher) fetchVerifiedURL(app *discovery.App, u *url.URL, a *asc, e
 	defer ascFile.Close()
 
 	if !retry {
-		if err := f.checkIdentity(appName, ascFile); err != nil {
+		if err := f.checkIdentity(appName, ascFile.ReadSeekers()); err != nil {
 			return nil, nil, err
 		}
 	}
@@ -213,12 +222,12 @@ func (f *nameFetcher) fetchVerifiedURL(app *discovery.App, u *url.URL, a *asc, e
 		ascFile.Close()
 		ascFile, errClose = o.DownloadSignatureAgain(a)
 		if errClose != nil {
-			ascFile = NopReadSeekCloser(nil)
+			ascFile = nil
 			return nil, nil, errClose
 		}
 	}
 
-	errClose = f.validate(app, aciFile, ascFile)
+	errClose = f.validate(app, aciFile, ascFile.ReadSeekers())
 	if errClose != nil {
 		return nil, nil, errClose
 	}
@@ -244,6 +253,7 @@ func (f *nameFetcher) maybeFetchPubKeys(appName string) {
 	if !f.InsecureFlags.SkipTLSCheck() || f.InsecureFlags.ConsiderInsecurePubKeys() {
 		m := &pubkey.Manager{
 			AuthPerHost:          f.Headers,
+			DefaultAuth:          f.DefaultHeaders,
 			InsecureAllowHTTP:    allowHTTP,
 			InsecureSkipTLSCheck: f.InsecureFlags.SkipTLSCheck(),
 			TrustKeysFromHTTPS:   f.TrustKeysFromHTTPS,
@@ -269,16 +279,28 @@ func (f *nameFetcher) maybeFetchPubKeys(appName string) {
 	}
 }
 
-func (f *nameFetcher) checkIdentity(appName string, ascFile io.ReadSeeker) error {
+// checkIdentity checks that at least one of the signature files was
+// made by a key trusted for the app name.
+func (f *nameFetcher) checkIdentity(appName string, ascFiles []io.ReadSeeker) error {
+	var err error
+	for _, ascFile := range ascFiles {
+		if err = f.checkSingleIdentity(appName, ascFile); err == nil {
+			return nil
+		}
+	}
+	if err == pgperrors.ErrUnknownIssuer {
+		log.Printf("if you expected the signing key to change, try running:")
+		log.Printf("    rkt trust --prefix %q", appName)
+	}
+	return err
+}
+
+func (f *nameFetcher) checkSingleIdentity(appName string, ascFile io.ReadSeeker) error {
 	if _, err := ascFile.Seek(0, 0); err != nil {
 		return errwrap.Wrap(errors.New("error seeking signature file"), err)
 	}
 	empty := bytes.NewReader([]byte{})
 	if _, err := f.Ks.CheckSignature(appName, empty, ascFile); err != nil {
-		if err == pgperrors.ErrUnknownIssuer {
-			log.Printf("if you expected the signing key to change, try running:")
-			log.Printf("    rkt trust --prefix %q", appName)
-		}
 		if _, ok := err.(pgperrors.SignatureError); !ok {
 			return err
 		}
@@ -286,7 +308,7 @@ func (f *nameFetcher) checkIdentity(appName string, ascFile io.ReadSeeker) error
 	return nil
 }
 
-func (f *nameFetcher) validate(app *discovery.App, aciFile, ascFile io.ReadSeeker) error {
+func (f *nameFetcher) validate(app *discovery.App, aciFile io.ReadSeeker, ascFiles []io.ReadSeeker) error {
 	v, err := newValidator(aciFile)
 	if err != nil {
 		return err
@@ -300,7 +322,7 @@ func (f *nameFetcher) validate(app *discovery.App, aciFile, ascFile io.ReadSeeke
 		return err
 	}
 
-	entity, err := v.ValidateWithSignature(f.Ks, ascFile)
+	entity, err := v.ValidateWithSignature(f.Ks, ascFiles...)
 	if err != nil {
 		return err
 	}
@@ -317,15 +339,19 @@ func (f *nameFetcher) maybeOverrideAscFetcherWithRemote(ascURL string, a *asc) {
 	if a.Fetcher != nil {
 		return
 	}
-	a.Location = ascURL
+	a.Locations = []string{ascURL}
 	a.Fetcher = f.httpOps().AscRemoteFetcher()
 }
 
 func (f *nameFetcher) httpOps() *httpOps {
 	return &httpOps{
 		InsecureSkipTLSVerify: f.InsecureFlags.SkipTLSCheck(),
-		S:       f.S,
-		Headers: f.Headers,
-		Debug:   f.Debug,
+		S:                     f.S,
+		Headers:               f.Headers,
+		DefaultHeaders:        f.DefaultHeaders,
+		ProxyPerHost:          f.ProxyPerHost,
+		RateLimitPerHost:      f.RateLimitPerHost,
+		FetchPolicy:           f.FetchPolicy,
+		Debug:                 f.Debug,
 	}
 }
@@ -0,0 +1,87 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// defaultHostLimiter is shared by all the sessions, so the requests
+// of different downloads from the same host are spaced too.
+var defaultHostLimiter = newHostLimiter()
+
+// hostLimiter spaces the requests sent to a host, so no more than the
+// given number of requests per second is sent to it.
+type hostLimiter struct {
+	mu sync.Mutex
+	// next holds the earliest time the next request to a host
+	// may be sent at.
+	next map[string]time.Time
+
+	// now and sleep are replaced in tests.
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+func newHostLimiter() *hostLimiter {
+	return &hostLimiter{
+		next:  make(map[string]time.Time),
+		now:   time.Now,
+		sleep: time.Sleep,
+	}
+}
+
+// wait blocks until a request to the host may be sent without
+// exceeding rate requests per second.
+func (l *hostLimiter) wait(host string, rate float64) {
+	interval := time.Duration(float64(time.Second) / rate)
+
+	l.mu.Lock()
+	now := l.now()
+	at := l.next[host]
+	if at.Before(now) {
+		at = now
+	}
+	l.next[host] = at.Add(interval)
+	l.mu.Unlock()
+
+	if d := at.Sub(now); d > 0 {
+		l.sleep(d)
+	}
+}
+
+// rateLimitedTransport is an http.RoundTripper which waits for the
+// limiter before sending requests to the hosts with a configured
+// rate.
+type rateLimitedTransport struct {
+	transport   http.RoundTripper
+	ratePerHost map[string]float64
+	limiter     *hostLimiter
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	rate, ok := t.ratePerHost[host]
+	if !ok {
+		host = req.URL.Hostname()
+		rate, ok = t.ratePerHost[host]
+	}
+	if ok {
+		t.limiter.wait(host, rate)
+	}
+	return t.transport.RoundTrip(req)
+}
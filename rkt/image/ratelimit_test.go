@@ -0,0 +1,83 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package image
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type recordingTransport struct {
+	now  func() time.Time
+	sent map[string][]time.Time
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.sent[req.URL.Host] = append(t.sent[req.URL.Host], t.now())
+	return &http.Response{StatusCode: http.StatusOK, Request: req}, nil
+}
+
+func TestRateLimitedTransport(t *testing.T) {
+	start := time.Unix(1000, 0)
+	clock := start
+	limiter := newHostLimiter()
+	limiter.now = func() time.Time { return clock }
+	limiter.sleep = func(d time.Duration) { clock = clock.Add(d) }
+
+	recorder := &recordingTransport{
+		now:  func() time.Time { return clock },
+		sent: make(map[string][]time.Time),
+	}
+	transport := &rateLimitedTransport{
+		transport: recorder,
+		ratePerHost: map[string]float64{
+			"example.com": 4,
+			"quay.io":     2,
+		},
+		limiter: limiter,
+	}
+
+	urls := []string{
+		"https://example.com/a.aci",
+		"https://example.com/a.aci.asc",
+		"https://quay.io/b.aci",
+		"https://coreos.com/c.aci",
+		"https://example.com:443/d.aci",
+		"https://quay.io/e.aci",
+		"https://coreos.com/f.aci",
+	}
+	for _, u := range urls {
+		req, err := http.NewRequest("GET", u, nil)
+		if err != nil {
+			t.Fatalf("failed to create request for %q: %v", u, err)
+		}
+		if _, err := transport.RoundTrip(req); err != nil {
+			t.Fatalf("unexpected error for %q: %v", u, err)
+		}
+	}
+
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	expected := map[string][]time.Time{
+		"example.com":     {at(0), at(250 * time.Millisecond)},
+		"example.com:443": {at(500 * time.Millisecond)},
+		"quay.io":         {at(250 * time.Millisecond), at(750 * time.Millisecond)},
+		"coreos.com":      {at(250 * time.Millisecond), at(750 * time.Millisecond)},
+	}
+	if !reflect.DeepEqual(recorder.sent, expected) {
+		t.Errorf("unexpected request times\ngot:\n%v\nexpected:\n%v", recorder.sent, expected)
+	}
+}
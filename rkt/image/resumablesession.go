@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -63,6 +64,20 @@ type resumableSession struct {
 	Headers http.Header
 	// Headerers used for authentication.
 	Headerers map[string]config.Headerer
+	// DefaultHeaderer is used for authentication with the hosts
+	// not in Headerers.
+	DefaultHeaderer config.Headerer
+	// ProxyPerHost holds the proxies to use for specific hosts, a
+	// nil URL means a direct connection. Other hosts use the
+	// proxy from the environment.
+	ProxyPerHost map[string]*url.URL
+	// RateLimitPerHost holds the maximum number of requests per
+	// second sent to specific hosts. Other hosts are not limited.
+	RateLimitPerHost map[string]float64
+	// Timeout limits the time spent on establishing a connection
+	// and on waiting for the response headers. Zero means no
+	// timeout.
+	Timeout time.Duration
 	// File possibly holds the downloaded data - it is used for
 	// resuming interrupted downloads.
 	File *os.File
@@ -276,10 +291,29 @@ func (s *resumableSession) reset() error {
 
 func (s *resumableSession) getClient() *http.Client {
 	transport := http.DefaultTransport
-	if s.InsecureSkipTLSVerify {
-		transport = &http.Transport{
-			Proxy:           http.ProxyFromEnvironment,
-			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	if s.InsecureSkipTLSVerify || len(s.ProxyPerHost) > 0 || s.Timeout > 0 {
+		tr := &http.Transport{
+			Proxy: config.ProxyFunc(s.ProxyPerHost),
+		}
+		if s.InsecureSkipTLSVerify {
+			tr.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+		}
+		if s.Timeout > 0 {
+			dialer := &net.Dialer{
+				Timeout:   s.Timeout,
+				KeepAlive: 30 * time.Second,
+			}
+			tr.DialContext = dialer.DialContext
+			tr.TLSHandshakeTimeout = s.Timeout
+			tr.ResponseHeaderTimeout = s.Timeout
+		}
+		transport = tr
+	}
+	if len(s.RateLimitPerHost) > 0 {
+		transport = &rateLimitedTransport{
+			transport:   transport,
+			ratePerHost: s.RateLimitPerHost,
+			limiter:     defaultHostLimiter,
 		}
 	}
 
@@ -322,7 +356,7 @@ func (s *resumableSession) httpRequest(method string, u *url.URL) *http.Request
 		return req
 	}
 
-	if hostOpts, ok := s.Headerers[req.URL.Host]; ok {
+	if hostOpts := config.HeadererForHost(s.Headerers, s.DefaultHeaderer, req.URL.Host); hostOpts != nil {
 		req = hostOpts.SignRequest(req)
 		if req == nil {
 			panic("Req is nil!")
@@ -15,7 +15,10 @@
 package image
 
 import (
+	"net/url"
 	"testing"
+
+	"github.com/rkt/rkt/rkt/config"
 )
 
 func TestGetMaxAge(t *testing.T) {
@@ -45,3 +48,37 @@ func TestGetMaxAge(t *testing.T) {
 		}
 	}
 }
+
+func TestHTTPRequestAuth(t *testing.T) {
+	cfg, err := config.GetConfigFromBytes("auth.d", []byte(`{"rktKind": "auth", "rktVersion": "v1", "domains": ["*"], "type": "oauth", "credentials": {"token": "defaulttoken"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error parsing the default auth: %v", err)
+	}
+	specific, err := config.GetConfigFromBytes("auth.d", []byte(`{"rktKind": "auth", "rktVersion": "v1", "domains": ["coreos.com"], "type": "oauth", "credentials": {"token": "coreostoken"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error parsing the auth: %v", err)
+	}
+	s := &resumableSession{
+		Headerers:       specific.AuthPerHost,
+		DefaultHeaderer: cfg.DefaultAuth,
+	}
+	tests := []struct {
+		url           string
+		authorization string
+	}{
+		{"https://coreos.com/image.aci", "Bearer coreostoken"},
+		{"https://example.com/image.aci", "Bearer defaulttoken"},
+		// credentials are sent only over https
+		{"http://example.com/image.aci", ""},
+	}
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatalf("unexpected error parsing %q: %v", tt.url, err)
+		}
+		req := s.httpRequest("GET", u)
+		if got := req.Header.Get("Authorization"); got != tt.authorization {
+			t.Errorf("expected authorization %q for %q, got %q", tt.authorization, tt.url, got)
+		}
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/hashicorp/errwrap"
 	"github.com/rkt/rkt/pkg/keystore"
@@ -80,25 +81,73 @@ func (v *validator) ValidateLabels(labels map[types.ACIdentifier]string) error {
 	return nil
 }
 
-// ValidateWithSignature verifies the image against a given signature
-// file.
-func (v *validator) ValidateWithSignature(ks *keystore.Keystore, sig io.ReadSeeker) (*openpgp.Entity, error) {
+// ValidateWithSignature verifies the image against the given
+// signature files. The image is accepted if any of the signatures is
+// valid, the entity of the first valid one is returned.
+func (v *validator) ValidateWithSignature(ks *keystore.Keystore, sigs ...io.ReadSeeker) (*openpgp.Entity, error) {
 	if ks == nil {
 		return nil, nil
 	}
+	if len(sigs) == 0 {
+		return nil, errors.New("no signature file to validate the image with")
+	}
+	var err error
+	for _, sig := range sigs {
+		var entity *openpgp.Entity
+		if entity, err = v.validateWithSingleSignature(ks, sig); err == nil {
+			return entity, nil
+		}
+		if len(sigs) > 1 {
+			diag.PrintE("signature not valid, trying the next one", err)
+		}
+	}
+	if err == pgperrors.ErrUnknownIssuer {
+		log.Print("If you expected the signing key to change, try running:")
+		log.Print("    rkt trust --prefix <image>")
+	}
+	if len(sigs) > 1 {
+		return nil, errwrap.Wrap(fmt.Errorf("none of the %d signatures is valid", len(sigs)), err)
+	}
+	return nil, err
+}
+
+func (v *validator) validateWithSingleSignature(ks *keystore.Keystore, sig io.ReadSeeker) (*openpgp.Entity, error) {
 	if _, err := v.image.Seek(0, 0); err != nil {
 		return nil, errwrap.Wrap(errors.New("error seeking ACI file"), err)
 	}
 	if _, err := sig.Seek(0, 0); err != nil {
 		return nil, errwrap.Wrap(errors.New("error seeking signature file"), err)
 	}
-	entity, err := ks.CheckSignature(v.ImageName(), v.image, sig)
-	if err == pgperrors.ErrUnknownIssuer {
-		log.Print("If you expected the signing key to change, try running:")
-		log.Print("    rkt trust --prefix <image>")
+	return ks.CheckSignature(v.ImageName(), v.image, sig)
+}
+
+// requiresSignature checks if the image name is one of the prefixes
+// or is below one of them.
+func requiresSignature(prefixes []string, name string) bool {
+	for _, prefix := range prefixes {
+		if name == prefix || strings.HasPrefix(name, prefix+"/") {
+			return true
+		}
 	}
+	return false
+}
+
+// imageRequiresSignature checks if the name in the manifest of the
+// image requires the image to be verified.
+func imageRequiresSignature(prefixes []string, image io.ReadSeeker) (bool, error) {
+	if len(prefixes) == 0 {
+		return false, nil
+	}
+	v, err := newValidator(image)
 	if err != nil {
-		return nil, err
+		return false, err
+	}
+	if _, err := image.Seek(0, 0); err != nil {
+		return false, errwrap.Wrap(errors.New("error seeking ACI file"), err)
+	}
+	if !requiresSignature(prefixes, v.ImageName()) {
+		return false, nil
 	}
-	return entity, nil
+	log.Printf("image %q requires a valid signature, verifying it despite the insecure options", v.ImageName())
+	return true, nil
 }
@@ -40,15 +40,19 @@ var (
 		Long: `This is intended to be run periodically from a timer or cron job.
 
 The default grace period is 24h. Use --grace-period=0s to effectively disable
-the grace-period.`,
+the grace-period.
+
+Use --dry-run to list what would be removed without removing anything.`,
 		Run: runWrapper(runGCImage),
 	}
 	flagImageGracePeriod time.Duration
+	flagImageGCDryRun    bool
 )
 
 func init() {
 	cmdImage.AddCommand(cmdImageGC)
 	cmdImageGC.Flags().DurationVar(&flagImageGracePeriod, "grace-period", defaultImageGracePeriod, "duration to wait since an image was last used before removing it")
+	cmdImageGC.Flags().BoolVar(&flagImageGCDryRun, "dry-run", false, "list the treestores and images that would be removed without removing them")
 }
 
 func runGCImage(cmd *cobra.Command, args []string) (exit int) {
@@ -64,12 +68,12 @@ func runGCImage(cmd *cobra.Command, args []string) (exit int) {
 		return
 	}
 
-	if err := gcTreeStore(ts); err != nil {
+	if err := gcTreeStore(ts, flagImageGCDryRun); err != nil {
 		stderr.PrintE("failed to remove unreferenced treestores", err)
 		return 254
 	}
 
-	if err := gcStore(s, flagImageGracePeriod); err != nil {
+	if err := gcStore(s, flagImageGracePeriod, flagImageGCDryRun); err != nil {
 		stderr.Error(err)
 		return 254
 	}
@@ -78,8 +82,8 @@ func runGCImage(cmd *cobra.Command, args []string) (exit int) {
 }
 
 // gcTreeStore removes all treeStoreIDs not referenced by any non garbage
-// collected pod from the store.
-func gcTreeStore(ts *treestore.Store) error {
+// collected pod from the store. If dryRun is true, they are only listed.
+func gcTreeStore(ts *treestore.Store, dryRun bool) error {
 	// Take an exclusive lock to block other pods being created.
 	// This is needed to avoid races between the below steps (getting the
 	// list of referenced treeStoreIDs, getting the list of treeStoreIDs
@@ -100,6 +104,10 @@ func gcTreeStore(ts *treestore.Store) error {
 	}
 	for _, treeStoreID := range treeStoreIDs {
 		if _, ok := referencedTreeStoreIDs[treeStoreID]; !ok {
+			if dryRun {
+				stdout.Printf("would remove treestore %q", treeStoreID)
+				continue
+			}
 			if err := ts.Remove(treeStoreID); err != nil {
 				stderr.PrintE(fmt.Sprintf("error removing treestore %q", treeStoreID), err)
 			} else {
@@ -135,23 +143,25 @@ func getReferencedTreeStoreIDs() (map[string]struct{}, error) {
 	return treeStoreIDs, nil
 }
 
-func gcStore(s *imagestore.Store, gracePeriod time.Duration) error {
-	var imagesToRemove []string
-	aciinfos, err := s.GetAllACIInfos([]string{"lastused"}, true)
-	if err != nil {
-		return errwrap.Wrap(errors.New("failed to get aciinfos"), err)
-	}
-	runningImages, err := getRunningImages()
+// gcStore removes all the images not used since gracePeriod and not
+// referenced by any running pod. If dryRun is true, they are only
+// listed.
+func gcStore(s *imagestore.Store, gracePeriod time.Duration, dryRun bool) error {
+	candidates, err := getImageGCCandidates(s, gracePeriod)
 	if err != nil {
-		return errwrap.Wrap(errors.New("failed to get list of images for running pods"), err)
+		return err
 	}
-	for _, ai := range aciinfos {
-		if time.Now().Sub(ai.LastUsed) <= gracePeriod {
-			break
-		}
-		if isInSet(ai.BlobKey, runningImages) {
-			continue
+
+	if dryRun {
+		for _, ai := range candidates {
+			stdout.Printf("would remove aci for image: %q (%q)", ai.BlobKey, ai.Name)
 		}
+		stderr.Printf("%d image(s) would be removed", len(candidates))
+		return nil
+	}
+
+	var imagesToRemove []string
+	for _, ai := range candidates {
 		imagesToRemove = append(imagesToRemove, ai.BlobKey)
 	}
 
@@ -162,6 +172,20 @@ func gcStore(s *imagestore.Store, gracePeriod time.Duration) error {
 	return nil
 }
 
+// getImageGCCandidates returns the images not used since gracePeriod
+// and not referenced by any running pod.
+func getImageGCCandidates(s *imagestore.Store, gracePeriod time.Duration) ([]*imagestore.ACIInfo, error) {
+	referenced, err := getRunningImagesSet()
+	if err != nil {
+		return nil, err
+	}
+	aciinfos, err := s.GetUnreferencedACIInfos(referenced, time.Now().Add(-gracePeriod))
+	if err != nil {
+		return nil, errwrap.Wrap(errors.New("failed to get aciinfos"), err)
+	}
+	return aciinfos, nil
+}
+
 // getRunningImages will return the image IDs used to create any of the
 // currently running pods
 func getRunningImages() ([]string, error) {
@@ -194,11 +218,16 @@ func getRunningImages() ([]string, error) {
 	return runningImages, nil
 }
 
-func isInSet(item string, set []string) bool {
-	for _, elem := range set {
-		if item == elem {
-			return true
-		}
+// getRunningImagesSet is like getRunningImages, but returns the image IDs
+// as a set.
+func getRunningImagesSet() (map[string]struct{}, error) {
+	runningImages, err := getRunningImages()
+	if err != nil {
+		return nil, errwrap.Wrap(errors.New("failed to get list of images for running pods"), err)
+	}
+	set := make(map[string]struct{}, len(runningImages))
+	for _, img := range runningImages {
+		set[img] = struct{}{}
 	}
-	return false
+	return set, nil
 }
@@ -23,15 +23,20 @@ import (
 
 var (
 	cmdImageRm = &cobra.Command{
-		Use:   "rm IMAGE...",
+		Use:   "rm [--pattern=PATTERN] IMAGE...",
 		Short: "Remove one or more images with the given IDs or image names from the local store",
-		Long:  `Unlike image gc, image rm allows users to remove specific images.`,
-		Run:   runWrapper(runRmImage),
+		Long: `Unlike image gc, image rm allows users to remove specific images.
+
+With --pattern, all the images with a name matching the given glob pattern
+are removed, except the ones used by running pods.`,
+		Run: runWrapper(runRmImage),
 	}
+	flagImageRmPattern string
 )
 
 func init() {
 	cmdImage.AddCommand(cmdImageRm)
+	cmdImageRm.Flags().StringVar(&flagImageRmPattern, "pattern", "", "remove all the images with a name matching the given glob pattern, skipping the ones used by running pods")
 }
 
 func rmImages(s *imagestore.Store, images []string) error {
@@ -90,9 +95,53 @@ func rmImages(s *imagestore.Store, images []string) error {
 	return nil
 }
 
+// rmImagesByPattern removes all the images with a name matching the
+// given glob pattern, skipping the ones referenced by running pods.
+// An error is returned only if the pattern is invalid or a
+// non-referenced image cannot be removed.
+func rmImagesByPattern(s *imagestore.Store, pattern string) error {
+	referenced, err := getRunningImagesSet()
+	if err != nil {
+		return err
+	}
+
+	results, err := s.RemoveACIsByPattern(pattern, referenced)
+	if err != nil {
+		return err
+	}
+
+	done := 0
+	skipped := 0
+	failed := 0
+	for _, res := range results {
+		switch res.Status {
+		case imagestore.ACIRemoved:
+			if res.Err != nil {
+				stderr.PrintE(fmt.Sprintf("some files cannot be removed for image %q (%q)", res.Key, res.Name), res.Err)
+			}
+			stdout.Printf("successfully removed aci for image: %q", res.Key)
+			done++
+		case imagestore.ACIReferenced:
+			stderr.Printf("skipping image %q (%q): used by a running pod", res.Key, res.Name)
+			skipped++
+		case imagestore.ACIRemovalFailed:
+			stderr.PrintE(fmt.Sprintf("error removing aci for image %q (%q)", res.Key, res.Name), res.Err)
+			failed++
+		}
+	}
+
+	stderr.Printf("%d image(s) matching %q: %d removed, %d skipped, %d failed", len(results), pattern, done, skipped, failed)
+
+	if failed > 0 {
+		return fmt.Errorf("error(s) found while removing images")
+	}
+
+	return nil
+}
+
 func runRmImage(cmd *cobra.Command, args []string) (exit int) {
-	if len(args) < 1 {
-		stderr.Print("must provide at least one image ID")
+	if len(args) < 1 && flagImageRmPattern == "" {
+		stderr.Print("must provide at least one image ID or a pattern")
 		return 254
 	}
 
@@ -102,10 +151,19 @@ func runRmImage(cmd *cobra.Command, args []string) (exit int) {
 		return 254
 	}
 
-	if err := rmImages(s, args); err != nil {
-		stderr.Error(err)
-		return 254
+	if flagImageRmPattern != "" {
+		if err := rmImagesByPattern(s, flagImageRmPattern); err != nil {
+			stderr.Error(err)
+			exit = 254
+		}
+	}
+
+	if len(args) > 0 {
+		if err := rmImages(s, args); err != nil {
+			stderr.Error(err)
+			exit = 254
+		}
 	}
 
-	return 0
+	return
 }
@@ -72,7 +72,7 @@ func init() {
 	cmdPrepare.Flags().Var((*appsVolume)(&rktApps), "volume", "volumes to make available in the pod")
 
 	// per-app flags
-	cmdPrepare.Flags().Var((*appAsc)(&rktApps), "signature", "local signature file to use in validating the preceding image")
+	cmdPrepare.Flags().Var((*appAsc)(&rktApps), "signature", "local signature file to use in validating the preceding image, can be specified multiple times")
 	addAppFlags(cmdPrepare)
 	addIsolatorFlags(cmdPrepare, true)
 
@@ -93,6 +93,11 @@ func runPrepare(cmd *cobra.Command, args []string) (exit int) {
 		}
 	}
 
+	if err = applyConfigInsecureOptions(); err != nil {
+		stderr.PrintE("cannot apply default insecure options", err)
+		return 254
+	}
+
 	if flagStoreOnly && flagNoStore {
 		stderr.Print("both --store-only and --no-store specified")
 		return 254
@@ -112,7 +117,7 @@ func runPrepare(cmd *cobra.Command, args []string) (exit int) {
 		privateUsers.SetRandomUidRange(user.DefaultRangeCount)
 	}
 
-	if err = parseApps(&rktApps, args, cmd.Flags(), true); err != nil {
+	if err = parseApps(&rktApps, args, cmd.Flags(), true, false); err != nil {
 		stderr.PrintE("error parsing app image arguments", err)
 		return 254
 	}
@@ -160,6 +165,11 @@ func runPrepare(cmd *cobra.Command, args []string) (exit int) {
 		Ts:                 ts,
 		Ks:                 getKeystore(),
 		Headers:            config.AuthPerHost,
+		DefaultHeaders:     config.DefaultAuth,
+		ProxyPerHost:       config.ProxyPerHost,
+		RateLimitPerHost:   config.RateLimitPerHost,
+		FetchPolicy:        config.FetchPolicy,
+		RequireSignature:   config.RequireSignature,
 		DockerAuth:         config.DockerCredentialsPerRegistry,
 		InsecureFlags:      globalFlags.InsecureFlags,
 		Debug:              globalFlags.Debug,
@@ -212,6 +222,10 @@ func runPrepare(cmd *cobra.Command, args []string) (exit int) {
 		pcfg.InheritEnv = flagInheritEnv
 		pcfg.ExplicitEnv = flagExplicitEnv.Strings()
 		pcfg.EnvFromFile = flagEnvFromFile.Strings()
+		if err := addDefaultVolumes(&rktApps, config.DefaultVolumes); err != nil {
+			stderr.PrintE("error adding the default volumes", err)
+			return 254
+		}
 		pcfg.Apps = &rktApps
 	}
 
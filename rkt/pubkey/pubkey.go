@@ -31,6 +31,7 @@ import (
 	"github.com/hashicorp/errwrap"
 	"github.com/rkt/rkt/pkg/keystore"
 	rktlog "github.com/rkt/rkt/pkg/log"
+	"github.com/rkt/rkt/pkg/trust"
 	"github.com/rkt/rkt/rkt/config"
 
 	"github.com/appc/spec/discovery"
@@ -40,6 +41,7 @@ import (
 
 type Manager struct {
 	AuthPerHost          map[string]config.Headerer
+	DefaultAuth          config.Headerer
 	InsecureAllowHTTP    bool
 	InsecureSkipTLSCheck bool
 	TrustKeysFromHTTPS   bool
@@ -137,17 +139,13 @@ func (m *Manager) AddKeys(pkls []string, prefix string, accept AcceptOption) err
 			stdout.Printf("Trusting %q for prefix %q after fingerprint review.", pkl, prefix)
 		}
 
+		path, err := trust.TrustKey(m.Ks, prefix, pk)
+		if err != nil {
+			return err
+		}
 		if prefix == "" {
-			path, err := m.Ks.StoreTrustedKeyRoot(pk)
-			if err != nil {
-				return errwrap.Wrap(errors.New("error adding root key"), err)
-			}
 			stdout.Printf("Added root key at %q", path)
 		} else {
-			path, err := m.Ks.StoreTrustedKeyPrefix(prefix, pk)
-			if err != nil {
-				return errwrap.Wrap(fmt.Errorf("error adding key for prefix %q", prefix), err)
-			}
 			stdout.Printf("Added key for prefix %q at %q", prefix, path)
 		}
 	}
@@ -161,7 +159,7 @@ func (m *Manager) metaDiscoverPubKeyLocations(prefix string) ([]string, error) {
 		return nil, err
 	}
 
-	hostHeaders := config.ResolveAuthPerHost(m.AuthPerHost)
+	hostHeaders := config.ResolveAuthForApp(m.AuthPerHost, m.DefaultAuth, app.Name.String())
 	insecure := discovery.InsecureNone
 	if m.InsecureAllowHTTP {
 		insecure = insecure | discovery.InsecureHTTP
@@ -15,13 +15,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"runtime/pprof"
+	"strings"
 	"text/tabwriter"
 
+	"github.com/hashicorp/errwrap"
 	"github.com/rkt/rkt/common"
 	"github.com/rkt/rkt/pkg/keystore"
 	"github.com/rkt/rkt/pkg/log"
@@ -311,6 +314,28 @@ func getConfig() (*config.Config, error) {
 		return cachedConfig, nil
 	}
 
+	dirs := getConfigDirs()
+	cfg, err := config.GetConfigFrom(dirs...)
+	if err != nil {
+		return nil, err
+	}
+
+	if deprecated, err := config.UsingDeprecatedLayout(dirs...); err != nil {
+		stderr.PrintE("cannot check the configuration layout", err)
+	} else {
+		for _, dir := range deprecated {
+			stderr.Printf("configuration files directly in %q are ignored, move them to the subdirectories of their kinds (e.g. %q)", dir, filepath.Join(dir, "auth.d"))
+		}
+	}
+
+	cachedConfig = cfg
+
+	return cfg, nil
+}
+
+// getConfigDirs returns the configuration directories in the order
+// they override each other.
+func getConfigDirs() []string {
 	dirs := []string{
 		globalFlags.SystemConfigDir,
 		globalFlags.LocalConfigDir,
@@ -320,14 +345,32 @@ func getConfig() (*config.Config, error) {
 		dirs = append(dirs, globalFlags.UserConfigDir)
 	}
 
-	cfg, err := config.GetConfigFrom(dirs...)
-	if err != nil {
-		return nil, err
+	return dirs
+}
+
+// applyConfigInsecureOptions sets the insecure options to the default
+// ones from the configuration, unless the --insecure-options flag was
+// passed. Options from the flag are not merged with the configured
+// ones, the flag always wins.
+func applyConfigInsecureOptions() error {
+	insecureFlag := cmdRkt.PersistentFlags().Lookup("insecure-options")
+	if insecureFlag == nil {
+		// should not happen
+		panic(`"--insecure-options" flag not found`)
+	}
+	if insecureFlag.Changed {
+		return nil
 	}
 
-	cachedConfig = cfg
+	config, err := getConfig()
+	if err != nil {
+		return errwrap.Wrap(errors.New("cannot get configuration"), err)
+	}
+	if len(config.DefaultInsecureOptions) == 0 {
+		return nil
+	}
 
-	return cfg, nil
+	return globalFlags.InsecureFlags.Set(strings.Join(config.DefaultInsecureOptions, ","))
 }
 
 func lockDir() string {
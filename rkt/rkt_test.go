@@ -100,3 +100,52 @@ func TestCalculateDataDir(t *testing.T) {
 		resetConfigState()
 	}
 }
+
+func TestApplyConfigInsecureOptions(t *testing.T) {
+	if _, err := getConfig(); err != nil {
+		panic(fmt.Errorf("getConfig() got error %q", err))
+	}
+
+	insecureFlag := cmdRkt.PersistentFlags().Lookup("insecure-options")
+	defInsecureFlagVal := insecureFlag.Value.String()
+	defCfgInsecureOptions := cachedConfig.DefaultInsecureOptions
+	defer func() {
+		globalFlags.InsecureFlags.Set(defInsecureFlagVal)
+		insecureFlag.Changed = false
+		cachedConfig.DefaultInsecureOptions = defCfgInsecureOptions
+	}()
+
+	testCases := []struct {
+		flagOptions   string
+		configOptions []string
+		skipImage     bool
+		skipTLS       bool
+	}{
+		{"", nil, false, false},
+		{"", []string{"image"}, true, false},
+		{"", []string{"image", "tls"}, true, true},
+		{"tls", []string{"image"}, false, true},
+		{"none", []string{"image"}, false, false},
+		{"image", nil, true, false},
+	}
+
+	for i, tc := range testCases {
+		globalFlags.InsecureFlags.Set("none")
+		insecureFlag.Changed = false
+		if tc.flagOptions != "" {
+			cmdRkt.PersistentFlags().Set("insecure-options", tc.flagOptions)
+		}
+		cachedConfig.DefaultInsecureOptions = tc.configOptions
+
+		if err := applyConfigInsecureOptions(); err != nil {
+			t.Errorf("#%d: unexpected error: %v", i, err)
+			continue
+		}
+		if skipImage := globalFlags.InsecureFlags.SkipImageCheck(); skipImage != tc.skipImage {
+			t.Errorf("#%d: expected image check skipped %t, got %t", i, tc.skipImage, skipImage)
+		}
+		if skipTLS := globalFlags.InsecureFlags.SkipTLSCheck(); skipTLS != tc.skipTLS {
+			t.Errorf("#%d: expected TLS check skipped %t, got %t", i, tc.skipTLS, skipTLS)
+		}
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
 	"strconv"
@@ -30,6 +31,7 @@ import (
 	"github.com/hashicorp/errwrap"
 	"github.com/opencontainers/selinux/go-selinux/label"
 	"github.com/rkt/rkt/common"
+	"github.com/rkt/rkt/networking"
 	"github.com/rkt/rkt/pkg/lock"
 	pkgPod "github.com/rkt/rkt/pkg/pod"
 	"github.com/rkt/rkt/pkg/user"
@@ -80,6 +82,10 @@ image arguments with a lone "---" to resume argument parsing.`,
 	flagHostsEntries flagStringList
 	flagPullPolicy   string
 	flagIPCMode      string
+
+	flagCgroupRWControllers flagStringList
+	flagEnvTmpfs            bool
+	flagNetConfOverrides    flagStringList
 )
 
 func addIsolatorFlags(cmd *cobra.Command, compat bool) {
@@ -135,6 +141,7 @@ func init() {
 	cmdRun.Flags().Var(&flagPorts, "port", "ports to expose on the host (requires contained network). Syntax: --port=NAME:[HOSTIP:]HOSTPORT")
 	cmdRun.Flags().Var(&flagNet, "net", "configure the pod's networking. Optionally, pass a list of user-configured networks to load and set arguments to pass to each network, respectively. Syntax: --net[=n[:args], ...]")
 	cmdRun.Flags().Lookup("net").NoOptDefVal = "default"
+	cmdRun.Flags().Var(&flagNetConfOverrides, "net-conf-override", "configuration overriding the one of a network for this run only, can be specified multiple times. Syntax: --net-conf-override=NAME:PATH")
 	cmdRun.Flags().BoolVar(&flagInheritEnv, "inherit-env", false, "inherit all environment variables not set by apps")
 	cmdRun.Flags().BoolVar(&flagNoOverlay, "no-overlay", false, "disable overlay filesystem")
 	cmdRun.Flags().BoolVar(&flagPrivateUsers, "private-users", false, "run within user namespaces.")
@@ -157,9 +164,11 @@ func init() {
 	cmdRun.Flags().StringVar(&flagHostname, "hostname", "", `pod's hostname. If empty, it will be "rkt-$PODUUID"`)
 	cmdRun.Flags().Var((*appsVolume)(&rktApps), "volume", "volumes to make available in the pod")
 	cmdRun.Flags().StringVar(&flagIPCMode, "ipc", "", `whether to stay in the host IPC namespace. Syntax: --ipc=[auto|private|parent]`)
+	cmdRun.Flags().Var(&flagCgroupRWControllers, "cgroup-rw-controllers", "cgroup controllers whose knobs apps are allowed to write, can be specified multiple times. All enabled controllers if not specified")
+	cmdRun.Flags().BoolVar(&flagEnvTmpfs, "env-tmpfs", false, "keep the apps' environment files on a tmpfs instead of the pod directory, so they are never written to disk")
 
 	// per-app flags
-	cmdRun.Flags().Var((*appAsc)(&rktApps), "signature", "local signature file to use in validating the preceding image")
+	cmdRun.Flags().Var((*appAsc)(&rktApps), "signature", "local signature file to use in validating the preceding image, can be specified multiple times")
 	addAppFlags(cmdRun)
 	addIsolatorFlags(cmdRun, true)
 
@@ -168,6 +177,8 @@ func init() {
 	flagDNSSearch = flagStringList{}
 	flagDNSOpt = flagStringList{}
 	flagHostsEntries = flagStringList{}
+	flagCgroupRWControllers = flagStringList{}
+	flagNetConfOverrides = flagStringList{}
 
 	// Disable interspersed flags to stop parsing after the first non flag
 	// argument. All the subsequent parsing will be done by parseApps.
@@ -177,12 +188,17 @@ func init() {
 
 func runRun(cmd *cobra.Command, args []string) (exit int) {
 	privateUsers := user.NewBlankUidRange()
-	err := parseApps(&rktApps, args, cmd.Flags(), true)
+	err := parseApps(&rktApps, args, cmd.Flags(), true, false)
 	if err != nil {
 		stderr.PrintE("error parsing app image arguments", err)
 		return 254
 	}
 
+	if err := applyConfigInsecureOptions(); err != nil {
+		stderr.PrintE("cannot apply default insecure options", err)
+		return 254
+	}
+
 	if flagStoreOnly && flagNoStore {
 		stderr.Print("both --store-only and --no-store specified")
 		return 254
@@ -264,6 +280,11 @@ func runRun(cmd *cobra.Command, args []string) (exit int) {
 		Ts:                 ts,
 		Ks:                 getKeystore(),
 		Headers:            config.AuthPerHost,
+		DefaultHeaders:     config.DefaultAuth,
+		ProxyPerHost:       config.ProxyPerHost,
+		RateLimitPerHost:   config.RateLimitPerHost,
+		FetchPolicy:        config.FetchPolicy,
+		RequireSignature:   config.RequireSignature,
 		DockerAuth:         config.DockerCredentialsPerRegistry,
 		InsecureFlags:      globalFlags.InsecureFlags,
 		Debug:              globalFlags.Debug,
@@ -337,6 +358,10 @@ func runRun(cmd *cobra.Command, args []string) (exit int) {
 		pcfg.InheritEnv = flagInheritEnv
 		pcfg.ExplicitEnv = flagExplicitEnv.Strings()
 		pcfg.EnvFromFile = flagEnvFromFile.Strings()
+		if err := addDefaultVolumes(&rktApps, config.DefaultVolumes); err != nil {
+			stderr.PrintE("error adding the default volumes", err)
+			return 254
+		}
 		pcfg.Apps = &rktApps
 	}
 
@@ -382,6 +407,12 @@ func runRun(cmd *cobra.Command, args []string) (exit int) {
 		return 254
 	}
 
+	netConfOverrides, err := parseNetConfOverrides(flagNetConfOverrides)
+	if err != nil {
+		stderr.PrintE("error with --net-conf-override", err)
+		return 254
+	}
+
 	rcfg := stage0.RunConfig{
 		CommonConfig:         &cfg,
 		Net:                  flagNet,
@@ -399,6 +430,9 @@ func runRun(cmd *cobra.Command, args []string) (exit int) {
 		UseOverlay:           useOverlay,
 		HostsEntries:         *HostsEntries,
 		IPCMode:              flagIPCMode,
+		CgroupRWControllers:  flagCgroupRWControllers,
+		EnvTmpfs:             flagEnvTmpfs,
+		NetConfOverrides:     netConfOverrides,
 	}
 
 	_, manifest, err := p.PodManifest()
@@ -682,3 +716,32 @@ func parseDNSFlags(flagHostsEntries, flagDNS, flagDNSSearch, flagDNSOpt []string
 
 	return DNSConfMode, DNSConfig, &HostsEntries, nil
 }
+
+// parseNetConfOverrides reads the network configurations passed with
+// --net-conf-override as NAME:PATH, and checks they are valid
+// configurations for the named networks.
+func parseNetConfOverrides(flagNetConfOverrides []string) (map[string][]byte, error) {
+	overrides := make(map[string][]byte)
+	for _, o := range flagNetConfOverrides {
+		parts := strings.SplitN(o, ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid network configuration override %q, expected NAME:PATH", o)
+		}
+		name, path := parts[0], parts[1]
+		if _, ok := overrides[name]; ok {
+			return nil, fmt.Errorf("network %q is overridden more than once", name)
+		}
+		if flagNet.Host() || flagNet.None() {
+			return nil, fmt.Errorf("network %q cannot be overridden without a contained network", name)
+		}
+		conf, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, errwrap.Wrap(fmt.Errorf("error reading the configuration overriding network %q", name), err)
+		}
+		if _, err := networking.ParseNetConfOverride(name, conf); err != nil {
+			return nil, err
+		}
+		overrides[name] = conf
+	}
+	return overrides, nil
+}
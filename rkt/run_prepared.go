@@ -43,6 +43,7 @@ func init() {
 
 	cmdRunPrepared.Flags().Var(&flagNet, "net", "configure the pod's networking. Optionally, pass a list of user-configured networks to load and set arguments to pass to each network, respectively. Syntax: --net[=n[:args]][,]")
 	cmdRunPrepared.Flags().Lookup("net").NoOptDefVal = "default"
+	cmdRunPrepared.Flags().Var(&flagNetConfOverrides, "net-conf-override", "configuration overriding the one of a network for this run only, can be specified multiple times. Syntax: --net-conf-override=NAME:PATH")
 	cmdRunPrepared.Flags().Var(&flagDNS, "dns", "name servers to write in /etc/resolv.conf. Pass 'host' to use host's resolv.conf. Pass 'none' to ignore CNI DNS config")
 	cmdRunPrepared.Flags().Var(&flagDNSSearch, "dns-search", "DNS search domains to write in /etc/resolv.conf")
 	cmdRunPrepared.Flags().Var(&flagDNSOpt, "dns-opt", "DNS options to write in /etc/resolv.conf")
@@ -53,6 +54,8 @@ func init() {
 	cmdRunPrepared.Flags().BoolVar(&flagMDSRegister, "mds-register", false, "register pod with metadata service")
 	cmdRunPrepared.Flags().StringVar(&flagHostname, "hostname", "", `pod's hostname. If empty, it will be "rkt-$PODUUID"`)
 	cmdRunPrepared.Flags().StringVar(&flagIPCMode, "ipc", "", `whether to stay in the host IPC namespace. Syntax: --ipc=[auto|private|parent]`)
+	cmdRunPrepared.Flags().Var(&flagCgroupRWControllers, "cgroup-rw-controllers", "cgroup controllers whose knobs apps are allowed to write, can be specified multiple times. All enabled controllers if not specified")
+	cmdRunPrepared.Flags().BoolVar(&flagEnvTmpfs, "env-tmpfs", false, "keep the apps' environment files on a tmpfs instead of the pod directory, so they are never written to disk")
 }
 
 func runRunPrepared(cmd *cobra.Command, args []string) (exit int) {
@@ -151,6 +154,12 @@ func runRunPrepared(cmd *cobra.Command, args []string) (exit int) {
 		return 254
 	}
 
+	netConfOverrides, err := parseNetConfOverrides(flagNetConfOverrides)
+	if err != nil {
+		stderr.PrintE("error with --net-conf-override", err)
+		return 254
+	}
+
 	rcfg := stage0.RunConfig{
 		CommonConfig: &stage0.CommonConfig{
 			DataDir:   getDataDir(),
@@ -173,6 +182,9 @@ func runRunPrepared(cmd *cobra.Command, args []string) (exit int) {
 		InsecurePaths:        globalFlags.InsecureFlags.SkipPaths(),
 		InsecureSeccomp:      globalFlags.InsecureFlags.SkipSeccomp(),
 		UseOverlay:           ovlPrep && ovlOk,
+		CgroupRWControllers:  flagCgroupRWControllers,
+		EnvTmpfs:             flagEnvTmpfs,
+		NetConfOverrides:     netConfOverrides,
 	}
 	if globalFlags.Debug {
 		stage0.InitDebug()
@@ -214,15 +214,73 @@ func addStage1ImageFlags(flags *pflag.FlagSet) {
 //
 // If location is an image hash then we make sure that it exists in
 // the store.
+//
+// If all of the above fails for the stage1 image from the
+// configuration and the configuration enables falling back to the
+// built-in stage1 image, we try the same steps with the name, the
+// version and the location taken from the configure script.
 func getStage1Hash(s *imagestore.Store, ts *treestore.Store, c *config.Config) (*types.Hash, error) {
-	imgDir := getStage1ImagesDirectory(c)
-	if overriddenStage1Location.kind != stage1ImageLocationUnset {
+	spec, err := resolveStage1(overriddenStage1Location, c.Stage1, getStage1ImagesDirectory(c))
+	if err != nil {
+		return nil, err
+	}
+	if spec.flag.kind != stage1ImageLocationUnset {
 		// we passed a --stage-{url,path,name,hash,from-dir} flag
-		return getStage1HashFromFlag(s, ts, overriddenStage1Location, imgDir)
+		return getStage1HashFromFlag(s, ts, spec.flag)
+	}
+
+	r := newStage1Resolver(s, ts)
+	if spec.fallbackToBuiltin {
+		return r.resolveWithFallback(spec.imgRef, spec.imgLoc, spec.imgFileName)
+	}
+	return r.resolve(spec.imgRef, spec.imgLoc, spec.imgFileName)
+}
+
+// stage1Spec is the stage1 image to use, after reconciling the
+// --stage1-* flags with the configuration and the build defaults.
+type stage1Spec struct {
+	// flag is the location passed with one of the --stage1-* flags,
+	// with --stage1-from-dir already joined with the stage1 images
+	// directory. If its kind is not stage1ImageLocationUnset, the
+	// other fields are empty.
+	flag stage1ImageLocation
+
+	// imgRef, imgLoc and imgFileName describe the image from the
+	// configuration or, if it does not specify one, the built-in
+	// image.
+	imgRef      string
+	imgLoc      string
+	imgFileName string
+	// fallbackToBuiltin tells whether the built-in image should be
+	// tried if the configured one can not be used.
+	fallbackToBuiltin bool
+}
+
+// resolveStage1 computes the stage1 image to use. An explicit
+// --stage1-* flag takes precedence over the configuration, which takes
+// precedence over the build defaults.
+func resolveStage1(flag stage1ImageLocation, cfg config.Stage1Data, imgDir string) (stage1Spec, error) {
+	if flag.kind != stage1ImageLocationUnset {
+		data, ok := stage1FlagsData[flag.kind]
+		if !ok {
+			return stage1Spec{}, fmt.Errorf("unknown stage1 location kind %d", flag.kind)
+		}
+		if flag.location == "" {
+			return stage1Spec{}, fmt.Errorf("--%s requires a non-empty value", data.flag)
+		}
+		if flag.kind == stage1ImageLocationFromDir {
+			flag.location = filepath.Join(imgDir, flag.location)
+		}
+		return stage1Spec{flag: flag}, nil
 	}
 
-	imgRef, imgLoc, imgFileName := getStage1DataFromConfig(c)
-	return getConfiguredStage1Hash(s, ts, imgRef, imgLoc, imgFileName)
+	imgRef, imgLoc, imgFileName := getStage1DataFromConfig(cfg)
+	return stage1Spec{
+		imgRef:            imgRef,
+		imgLoc:            imgLoc,
+		imgFileName:       imgFileName,
+		fallbackToBuiltin: cfg.FallbackToBuiltin,
+	}, nil
 }
 
 func getStage1ImagesDirectory(c *config.Config) string {
@@ -232,13 +290,9 @@ func getStage1ImagesDirectory(c *config.Config) string {
 	return buildDefaultStage1ImagesDir
 }
 
-func getStage1HashFromFlag(s *imagestore.Store, ts *treestore.Store, loc stage1ImageLocation, dir string) (*types.Hash, error) {
+func getStage1HashFromFlag(s *imagestore.Store, ts *treestore.Store, loc stage1ImageLocation) (*types.Hash, error) {
 	withKeystore := true
-	location := loc.location
-	if loc.kind == stage1ImageLocationFromDir {
-		location = filepath.Join(dir, loc.location)
-	}
-	trustedLocation, err := isTrustedLocation(location)
+	trustedLocation, err := isTrustedLocation(loc.location)
 	if err != nil {
 		return nil, err
 	}
@@ -251,12 +305,12 @@ func getStage1HashFromFlag(s *imagestore.Store, ts *treestore.Store, loc stage1I
 	}
 
 	fn := getStage1Finder(s, ts, withKeystore)
-	return fn.FindImage(location, "")
+	return fn.FindImage(loc.location, nil)
 }
 
-func getStage1DataFromConfig(c *config.Config) (string, string, string) {
-	imgName := c.Stage1.Name
-	imgVersion := c.Stage1.Version
+func getStage1DataFromConfig(cfg config.Stage1Data) (string, string, string) {
+	imgName := cfg.Name
+	imgVersion := cfg.Version
 	// if the name in the configuration is empty, then the version
 	// is empty too, but let's better be safe now then sorry later
 	// - if either one is empty we take build defaults for both
@@ -266,7 +320,7 @@ func getStage1DataFromConfig(c *config.Config) (string, string, string) {
 	}
 	imgRef := fmt.Sprintf("%s:%s", imgName, imgVersion)
 
-	imgLoc := c.Stage1.Location
+	imgLoc := cfg.Location
 	imgFileName := getFileNameFromLocation(imgLoc)
 	if imgLoc == "" {
 		imgLoc = buildDefaultStage1ImageLoc
@@ -295,19 +349,54 @@ func isTrustedLocation(location string) (bool, error) {
 	return false, nil
 }
 
-func getConfiguredStage1Hash(s *imagestore.Store, ts *treestore.Store, imgRef, imgLoc, imgFileName string) (*types.Hash, error) {
-	trusted, err := isTrustedLocation(imgLoc)
-	if err != nil {
-		return nil, err
+// stage1Resolver gets the hash of the stage1 image. The lookups are
+// done by the functions below, so they can be replaced in tests.
+type stage1Resolver struct {
+	// fromStore looks for the image with the given name and
+	// version only in the store.
+	fromStore func(imgRef string) (*types.Hash, error)
+	// fetch fetches the image from the given location, a URL or
+	// an absolute path, into the store.
+	fetch func(imgLoc string) (*types.Hash, error)
+	// fromRktDir fetches the image file with the given name from
+	// the directory of the rkt binary into the store.
+	fromRktDir func(imgFileName string) (*types.Hash, error)
+}
+
+func newStage1Resolver(s *imagestore.Store, ts *treestore.Store) *stage1Resolver {
+	return &stage1Resolver{
+		fromStore: func(imgRef string) (*types.Hash, error) {
+			fn := getStage1Finder(s, ts, false)
+			fn.PullPolicy = image.PullPolicyNever
+			return fn.FindImage(imgRef, nil)
+		},
+		fetch: func(imgLoc string) (*types.Hash, error) {
+			trusted, err := isTrustedLocation(imgLoc)
+			if err != nil {
+				return nil, err
+			}
+			return getStage1Finder(s, ts, !trusted).FindImage(imgLoc, nil)
+		},
+		fromRktDir: func(imgFileName string) (*types.Hash, error) {
+			exePath, err := os.Readlink("/proc/self/exe")
+			if err != nil {
+				return nil, err
+			}
+			// using stage1 image in rkt's path, don't check the signature
+			imgPath := filepath.Join(filepath.Dir(exePath), imgFileName)
+			return getStage1Finder(s, ts, false).FindImage(imgPath, nil)
+		},
 	}
-	fn := getStage1Finder(s, ts, !trusted)
+}
+
+// resolve gets the hash of the stage1 image with the given name and
+// version from the store, or fetches it from the location.
+func (r *stage1Resolver) resolve(imgRef, imgLoc, imgFileName string) (*types.Hash, error) {
 	if !strings.HasSuffix(imgRef, "-dirty") {
-		oldPolicy := fn.PullPolicy
-		fn.PullPolicy = image.PullPolicyNever
-		if hash, err := fn.FindImage(imgRef, ""); err == nil {
+		if hash, err := r.fromStore(imgRef); err == nil {
+			r.debug("using stage1 image %s from the store", imgRef)
 			return hash, nil
 		}
-		fn.PullPolicy = oldPolicy
 	}
 	if imgLoc == "" && imgFileName == "" {
 		return nil, fmt.Errorf("neither the location of the default stage1 image nor its filename are set, use --stage1-{path,url,name,hash,from-dir} flag")
@@ -315,58 +404,80 @@ func getConfiguredStage1Hash(s *imagestore.Store, ts *treestore.Store, imgRef, i
 	// If imgLoc is not an absolute path, then it is a URL
 	imgLocIsURL := imgLoc != "" && !filepath.IsAbs(imgLoc)
 	if imgLocIsURL {
-		return fn.FindImage(imgLoc, "")
+		hash, err := r.fetch(imgLoc)
+		if err == nil {
+			r.debug("using stage1 image from %s", imgLoc)
+		}
+		return hash, err
 	}
-	return getStage1HashFromPath(fn, imgLoc, imgFileName)
+	return r.resolveFromPath(imgLoc, imgFileName)
 }
 
-func getStage1Finder(s *imagestore.Store, ts *treestore.Store, withKeystore bool) *image.Finder {
-	fn := &image.Finder{
-		S:                  s,
-		Ts:                 ts,
-		Debug:              globalFlags.Debug,
-		InsecureFlags:      globalFlags.InsecureFlags,
-		TrustKeysFromHTTPS: globalFlags.TrustKeysFromHTTPS,
-
-		PullPolicy: image.PullPolicyNew,
-		WithDeps:   false,
+// resolveWithFallback is like resolve, but if the configured stage1
+// image cannot be used, it falls back to the built-in one.
+func (r *stage1Resolver) resolveWithFallback(imgRef, imgLoc, imgFileName string) (*types.Hash, error) {
+	hash, err := r.resolve(imgRef, imgLoc, imgFileName)
+	if err == nil {
+		return hash, nil
 	}
-
-	if withKeystore {
-		fn.Ks = getKeystore()
+	builtinRef := fmt.Sprintf("%s:%s", buildDefaultStage1Name, buildDefaultStage1Version)
+	if imgRef == builtinRef && imgLoc == buildDefaultStage1ImageLoc {
+		return nil, err
 	}
-	return fn
+	stderr.PrintE(fmt.Sprintf("failed to get the configured stage1 image %s, falling back to the built-in stage1 image %s", imgRef, builtinRef), err)
+	hash, fallbackErr := r.resolve(builtinRef, buildDefaultStage1ImageLoc, buildDefaultStage1ImageInRktDir)
+	if fallbackErr != nil {
+		return nil, errwrap.Wrap(errors.New("failed to fall back to the built-in stage1 image"), errwrap.Wrap(fallbackErr, err))
+	}
+	return hash, nil
 }
 
-func getStage1HashFromPath(fn *image.Finder, imgLoc, imgFileName string) (*types.Hash, error) {
+func (r *stage1Resolver) resolveFromPath(imgLoc, imgFileName string) (*types.Hash, error) {
 	var fetchErr error
 	var fallbackErr error
 	if imgLoc != "" {
-		hash, err := fn.FindImage(imgLoc, "")
+		hash, err := r.fetch(imgLoc)
 		if err == nil {
+			r.debug("using stage1 image from %s", imgLoc)
 			return hash, nil
 		}
 		fetchErr = err
 	}
 	if imgFileName != "" {
-		exePath, err := os.Readlink("/proc/self/exe")
-		if err != nil {
-			fallbackErr = err
-		} else {
-			// using stage1 image in rkt's path, don't check the signature
-			fn.Ks = nil
-			rktDir := filepath.Dir(exePath)
-			imgPath := filepath.Join(rktDir, imgFileName)
-			hash, err := fn.FindImage(imgPath, "")
-			if err == nil {
-				return hash, nil
-			}
-			fallbackErr = err
+		hash, err := r.fromRktDir(imgFileName)
+		if err == nil {
+			r.debug("using stage1 image %s from the rkt directory", imgFileName)
+			return hash, nil
 		}
+		fallbackErr = err
 	}
 	return nil, mergeStage1Errors(fetchErr, fallbackErr)
 }
 
+func (r *stage1Resolver) debug(format string, args ...interface{}) {
+	if globalFlags.Debug {
+		stderr.Printf(format, args...)
+	}
+}
+
+func getStage1Finder(s *imagestore.Store, ts *treestore.Store, withKeystore bool) *image.Finder {
+	fn := &image.Finder{
+		S:                  s,
+		Ts:                 ts,
+		Debug:              globalFlags.Debug,
+		InsecureFlags:      globalFlags.InsecureFlags,
+		TrustKeysFromHTTPS: globalFlags.TrustKeysFromHTTPS,
+
+		PullPolicy: image.PullPolicyNew,
+		WithDeps:   false,
+	}
+
+	if withKeystore {
+		fn.Ks = getKeystore()
+	}
+	return fn
+}
+
 func mergeStage1Errors(fetchErr, fallbackErr error) error {
 	if fetchErr != nil && fallbackErr != nil {
 		innerErr := errwrap.Wrap(fallbackErr, fetchErr)
@@ -0,0 +1,277 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"testing"
+
+	"github.com/appc/spec/schema/types"
+	"github.com/rkt/rkt/pkg/log"
+	"github.com/rkt/rkt/rkt/config"
+)
+
+// fakeStage1Resolver returns a stage1Resolver finding only the images
+// in available, keyed by the reference, location or file name, and
+// recording the lookups.
+func fakeStage1Resolver(available map[string]string, lookups *[]string) *stage1Resolver {
+	find := func(kind, img string) (*types.Hash, error) {
+		*lookups = append(*lookups, fmt.Sprintf("%s %s", kind, img))
+		hash, ok := available[img]
+		if !ok {
+			return nil, fmt.Errorf("%s not found", img)
+		}
+		return types.NewHash(hash)
+	}
+	return &stage1Resolver{
+		fromStore: func(imgRef string) (*types.Hash, error) {
+			return find("store", imgRef)
+		},
+		fetch: func(imgLoc string) (*types.Hash, error) {
+			return find("fetch", imgLoc)
+		},
+		fromRktDir: func(imgFileName string) (*types.Hash, error) {
+			return find("rktdir", imgFileName)
+		},
+	}
+}
+
+func TestStage1Resolver(t *testing.T) {
+	stderr = log.New(ioutil.Discard, "TestStage1Resolver", false)
+
+	oldName, oldVersion, oldLoc, oldFileName := buildDefaultStage1Name, buildDefaultStage1Version, buildDefaultStage1ImageLoc, buildDefaultStage1ImageInRktDir
+	defer func() {
+		buildDefaultStage1Name, buildDefaultStage1Version, buildDefaultStage1ImageLoc, buildDefaultStage1ImageInRktDir = oldName, oldVersion, oldLoc, oldFileName
+	}()
+	buildDefaultStage1Name = "coreos.com/rkt/stage1-coreos"
+	buildDefaultStage1Version = "1.0.0"
+	buildDefaultStage1ImageLoc = "/usr/lib/rkt/stage1-coreos.aci"
+	buildDefaultStage1ImageInRktDir = "stage1-coreos.aci"
+
+	const (
+		configuredRef  = "example.com/stage1:2.0.0"
+		configuredLoc  = "https://example.com/stage1.aci"
+		builtinRef     = "coreos.com/rkt/stage1-coreos:1.0.0"
+		configuredHash = "sha512-1111111111111111111111111111111111111111111111111111111111111111"
+		builtinHash    = "sha512-2222222222222222222222222222222222222222222222222222222222222222"
+	)
+
+	tests := []struct {
+		name      string
+		available map[string]string
+		fallback  bool
+		hash      string
+		lookups   []string
+	}{
+		{
+			name:      "store-hit",
+			available: map[string]string{configuredRef: configuredHash},
+			hash:      configuredHash,
+			lookups:   []string{"store " + configuredRef},
+		},
+		{
+			name:      "location-fetch",
+			available: map[string]string{configuredLoc: configuredHash},
+			hash:      configuredHash,
+			lookups:   []string{"store " + configuredRef, "fetch " + configuredLoc},
+		},
+		{
+			name:      "final-fallback",
+			available: map[string]string{"stage1-coreos.aci": builtinHash},
+			fallback:  true,
+			hash:      builtinHash,
+			lookups: []string{
+				"store " + configuredRef,
+				"fetch " + configuredLoc,
+				"store " + builtinRef,
+				"fetch /usr/lib/rkt/stage1-coreos.aci",
+				"rktdir stage1-coreos.aci",
+			},
+		},
+		{
+			name:      "no-fallback",
+			available: map[string]string{builtinRef: builtinHash},
+			hash:      "",
+			lookups:   []string{"store " + configuredRef, "fetch " + configuredLoc},
+		},
+		{
+			name:      "fallback-failure",
+			available: map[string]string{},
+			fallback:  true,
+			hash:      "",
+			lookups: []string{
+				"store " + configuredRef,
+				"fetch " + configuredLoc,
+				"store " + builtinRef,
+				"fetch /usr/lib/rkt/stage1-coreos.aci",
+				"rktdir stage1-coreos.aci",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		var lookups []string
+		r := fakeStage1Resolver(tt.available, &lookups)
+		resolve := r.resolve
+		if tt.fallback {
+			resolve = r.resolveWithFallback
+		}
+		hash, err := resolve(configuredRef, configuredLoc, "")
+		if tt.hash == "" {
+			if err == nil {
+				t.Errorf("%s: expected an error, got hash %v", tt.name, hash)
+			}
+		} else if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		} else if hash.String() != tt.hash {
+			t.Errorf("%s: expected hash %s, got %s", tt.name, tt.hash, hash)
+		}
+		if !reflect.DeepEqual(lookups, tt.lookups) {
+			t.Errorf("%s: expected lookups %v, got %v", tt.name, tt.lookups, lookups)
+		}
+	}
+}
+
+func TestStage1ResolverDirtyVersion(t *testing.T) {
+	stderr = log.New(ioutil.Discard, "TestStage1ResolverDirtyVersion", false)
+
+	var lookups []string
+	r := fakeStage1Resolver(map[string]string{}, &lookups)
+	r.fromStore = func(imgRef string) (*types.Hash, error) {
+		t.Errorf("expected the store not to be used for the dirty version %s", imgRef)
+		return nil, errors.New("unexpected store lookup")
+	}
+	if _, err := r.resolve("example.com/stage1:1.0.0-dirty", "", ""); err == nil {
+		t.Errorf("expected an error without a location")
+	}
+}
+
+func TestResolveStage1(t *testing.T) {
+	oldName, oldVersion, oldLoc, oldFileName := buildDefaultStage1Name, buildDefaultStage1Version, buildDefaultStage1ImageLoc, buildDefaultStage1ImageInRktDir
+	defer func() {
+		buildDefaultStage1Name, buildDefaultStage1Version, buildDefaultStage1ImageLoc, buildDefaultStage1ImageInRktDir = oldName, oldVersion, oldLoc, oldFileName
+	}()
+	buildDefaultStage1Name = "coreos.com/rkt/stage1-coreos"
+	buildDefaultStage1Version = "1.0.0"
+	buildDefaultStage1ImageLoc = "/usr/lib/rkt/stage1-coreos.aci"
+	buildDefaultStage1ImageInRktDir = "stage1-coreos.aci"
+
+	const imgDir = "/usr/lib/rkt/stage1-images"
+	configured := config.Stage1Data{
+		Name:              "example.com/stage1",
+		Version:           "2.0.0",
+		Location:          "/opt/stage1.aci",
+		FallbackToBuiltin: true,
+	}
+	builtin := stage1Spec{
+		imgRef:      "coreos.com/rkt/stage1-coreos:1.0.0",
+		imgLoc:      "/usr/lib/rkt/stage1-coreos.aci",
+		imgFileName: "stage1-coreos.aci",
+	}
+
+	tests := []struct {
+		name string
+		flag stage1ImageLocation
+		cfg  config.Stage1Data
+
+		spec stage1Spec
+		err  bool
+	}{
+		{
+			name: "builtin",
+			spec: builtin,
+		},
+		{
+			name: "config",
+			cfg:  configured,
+			spec: stage1Spec{
+				imgRef:            "example.com/stage1:2.0.0",
+				imgLoc:            "/opt/stage1.aci",
+				imgFileName:       "stage1.aci",
+				fallbackToBuiltin: true,
+			},
+		},
+		{
+			name: "config-name-without-version",
+			cfg:  config.Stage1Data{Name: "example.com/stage1"},
+			spec: builtin,
+		},
+		{
+			name: "config-location-only",
+			cfg:  config.Stage1Data{Location: "https://example.com/stage1.aci"},
+			spec: stage1Spec{
+				imgRef: "coreos.com/rkt/stage1-coreos:1.0.0",
+				imgLoc: "https://example.com/stage1.aci",
+			},
+		},
+		{
+			name: "flag-over-config",
+			flag: stage1ImageLocation{stage1ImageLocationName, "example.com/other-stage1"},
+			cfg:  configured,
+			spec: stage1Spec{flag: stage1ImageLocation{stage1ImageLocationName, "example.com/other-stage1"}},
+		},
+		{
+			name: "flag-from-dir",
+			flag: stage1ImageLocation{stage1ImageLocationFromDir, "stage1-fly.aci"},
+			spec: stage1Spec{flag: stage1ImageLocation{stage1ImageLocationFromDir, imgDir + "/stage1-fly.aci"}},
+		},
+		{
+			name: "flag-empty",
+			flag: stage1ImageLocation{stage1ImageLocationPath, ""},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		spec, err := resolveStage1(tt.flag, tt.cfg, imgDir)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %+v", tt.name, spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		} else if !reflect.DeepEqual(spec, tt.spec) {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.spec, spec)
+		}
+	}
+}
+
+func TestStage1ImageLocationFlagConflicts(t *testing.T) {
+	for _, tt := range []struct {
+		first, second stage1ImageLocationKind
+	}{
+		{stage1ImageLocationPath, stage1ImageLocationURL},
+		{stage1ImageLocationName, stage1ImageLocationHash},
+		{stage1ImageLocationFromDir, stage1ImageLocationFromDir},
+	} {
+		var loc stage1ImageLocation
+		first := &stage1ImageLocationFlag{loc: &loc, kind: tt.first}
+		second := &stage1ImageLocationFlag{loc: &loc, kind: tt.second}
+		if err := first.Set("first"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if err := second.Set("second"); err == nil {
+			t.Errorf("expected an error setting --%s after --%s", stage1FlagsData[tt.second].flag, stage1FlagsData[tt.first].flag)
+		}
+		if loc.kind != tt.first || loc.location != "first" {
+			t.Errorf("expected the first flag to be kept, got %+v", loc)
+		}
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/appc/spec/schema"
 	"github.com/appc/spec/schema/types"
 	"github.com/hashicorp/errwrap"
 	"github.com/rkt/rkt/common"
@@ -50,44 +51,113 @@ func AddApp(cfg AddConfig) error {
 		return errors.New("no image specified")
 	}
 
-	am, err := cfg.Store.GetImageManifest(cfg.Image.String())
+	pod, pm, err := lockSandbox(cfg)
 	if err != nil {
 		return err
 	}
+	defer pod.Close()
+	defer pod.UnlockManifest()
 
-	var appName *types.ACName
-	if app.Name != "" {
-		appName, err = types.NewACName(app.Name)
-		if err != nil {
-			return err
+	return addApp(cfg, pod, pm, app, cfg.Image)
+}
+
+// AddApps adds all the apps in the config to the pod, each one with its
+// resolved ImageID, while holding the pod manifest lock for the whole
+// operation. If adding an app fails, the apps already added are removed
+// again.
+func AddApps(cfg AddConfig) error {
+	if cfg.Apps.Count() == 0 {
+		return errors.New("no image specified")
+	}
+
+	pod, pm, err := lockSandbox(cfg)
+	if err != nil {
+		return err
+	}
+	defer pod.Close()
+	defer pod.UnlockManifest()
+
+	var added []types.ACName
+	err = cfg.Apps.Walk(func(app *apps.App) error {
+		if err := addApp(cfg, pod, pm, app, app.ImageID); err != nil {
+			// the app may have made it into the manifest already
+			if app.Name != "" && pm.Apps.Get(types.ACName(app.Name)) != nil {
+				added = append(added, types.ACName(app.Name))
+			}
+			return errwrap.Wrap(fmt.Errorf("error adding app from image %s", app.Image), err)
 		}
-	} else {
-		appName, err = common.ImageNameToAppName(am.Name)
-		if err != nil {
-			return err
+		added = append(added, types.ACName(app.Name))
+		return nil
+	})
+	if err == nil {
+		return nil
+	}
+
+	for i := len(added) - 1; i >= 0; i-- {
+		rcfg := RmConfig{
+			CommonConfig: cfg.CommonConfig,
+			PodPath:      cfg.PodPath,
+			UsesOverlay:  cfg.UsesOverlay,
+			AppName:      &added[i],
+			PodPID:       cfg.PodPID,
+		}
+		debug("rolling back app %q", added[i])
+		if rerr := rmApp(rcfg, pod, pm); rerr != nil {
+			return errwrap.Wrap(fmt.Errorf("error rolling back app %q after: %v", added[i], err), rerr)
 		}
-		app.Name = appName.String()
 	}
+	return err
+}
 
+// lockSandbox loads the running pod of the config and locks its manifest.
+// The caller has to unlock the manifest and close the pod.
+func lockSandbox(cfg AddConfig) (*pkgPod.Pod, *schema.PodManifest, error) {
 	pod, err := pkgPod.PodFromUUIDString(cfg.DataDir, cfg.UUID.String())
 	if err != nil {
-		return errwrap.Wrap(errors.New("error loading pod"), err)
+		return nil, nil, errwrap.Wrap(errors.New("error loading pod"), err)
 	}
-	defer pod.Close()
 
 	if pod.State() != pkgPod.Running {
-		return errors.New("pod is not running")
+		pod.Close()
+		return nil, nil, errors.New("pod is not running")
 	}
 
 	debug("locking pod manifest")
 	if err := pod.ExclusiveLockManifest(); err != nil {
-		return errwrap.Wrap(errors.New("failed to lock pod manifest"), err)
+		pod.Close()
+		return nil, nil, errwrap.Wrap(errors.New("failed to lock pod manifest"), err)
 	}
-	defer pod.UnlockManifest()
 
 	pm, err := pod.SandboxManifest()
 	if err != nil {
-		return errwrap.Wrap(errors.New("cannot add application"), err)
+		pod.UnlockManifest()
+		pod.Close()
+		return nil, nil, errwrap.Wrap(errors.New("cannot add application"), err)
+	}
+
+	return pod, pm, nil
+}
+
+// addApp adds the app with the given image to the locked pod and its
+// manifest pm.
+func addApp(cfg AddConfig, pod *pkgPod.Pod, pm *schema.PodManifest, app *apps.App, img types.Hash) error {
+	am, err := cfg.Store.GetImageManifest(img.String())
+	if err != nil {
+		return err
+	}
+
+	var appName *types.ACName
+	if app.Name != "" {
+		appName, err = types.NewACName(app.Name)
+		if err != nil {
+			return err
+		}
+	} else {
+		appName, err = common.ImageNameToAppName(am.Name)
+		if err != nil {
+			return err
+		}
+		app.Name = appName.String()
 	}
 
 	if pm.Apps.Get(*appName) != nil {
@@ -95,7 +165,7 @@ func AddApp(cfg AddConfig) error {
 	}
 
 	if am.App == nil && app.Exec == "" {
-		return fmt.Errorf("error: image %s has no app section and --exec argument is not provided", cfg.Image)
+		return fmt.Errorf("error: image %s has no app section and --exec argument is not provided", img)
 	}
 
 	appInfoDir := common.AppInfoPath(cfg.PodPath, *appName)
@@ -119,9 +189,9 @@ func AddApp(cfg AddConfig) error {
 		}
 	}
 
-	treeStoreID, err := prepareAppImage(pcfg, *appName, cfg.Image, cfg.PodPath, cfg.UsesOverlay)
+	treeStoreID, err := prepareAppImage(pcfg, *appName, img, cfg.PodPath, cfg.UsesOverlay)
 	if err != nil {
-		return errwrap.Wrap(fmt.Errorf("error preparing image %s", cfg.Image), err)
+		return errwrap.Wrap(fmt.Errorf("error preparing image %s", img), err)
 	}
 
 	rcfg := RunConfig{
@@ -130,7 +200,7 @@ func AddApp(cfg AddConfig) error {
 		RktGid:       cfg.RktGid,
 	}
 
-	if err := setupAppImage(rcfg, *appName, cfg.Image, cfg.PodPath, cfg.UsesOverlay); err != nil {
+	if err := setupAppImage(rcfg, *appName, img, cfg.PodPath, cfg.UsesOverlay); err != nil {
 		return fmt.Errorf("error setting up app image: %v", err)
 	}
 
@@ -148,12 +218,23 @@ func AddApp(cfg AddConfig) error {
 	if app.Args != nil {
 		ra.App.Exec = append(ra.App.Exec[:1], app.Args...)
 	}
+	nApps := len(pm.Apps)
 	pm.Apps = append(pm.Apps, ra)
+	updated := false
+	defer func() {
+		// keep pm in sync with the manifest on disk
+		if !updated {
+			pm.Apps = pm.Apps[:nApps]
+		}
+	}()
+	if err := common.ValidateMounts(pm); err != nil {
+		return err
+	}
 
 	env := ra.App.Environment
 
 	env.Set("AC_APP_NAME", appName.String())
-	envFilePath := filepath.Join(common.Stage1RootfsPath(cfg.PodPath), "rkt", "env", appName.String())
+	envFilePath := filepath.Join(envDirPath(common.Stage1RootfsPath(cfg.PodPath)), appName.String())
 
 	if err := common.WriteEnvFile(common.ComposeEnviron(env), pcfg.PrivateUsers, envFilePath); err != nil {
 		return err
@@ -163,6 +244,7 @@ func AddApp(cfg AddConfig) error {
 	if err := pod.UpdateManifest(pm, cfg.PodPath); err != nil {
 		return err
 	}
+	updated = true
 
 	args := []string{
 		fmt.Sprintf("--debug=%t", cfg.Debug),
@@ -0,0 +1,214 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//+build linux
+
+package stage0
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/appc/spec/schema"
+	"github.com/appc/spec/schema/types"
+	"github.com/rkt/rkt/common"
+	"github.com/rkt/rkt/pkg/aci"
+	"github.com/rkt/rkt/pkg/user"
+)
+
+const testImageManifest = `{
+	"acKind": "ImageManifest",
+	"acVersion": "0.8.11",
+	"name": "example.com/app",
+	"labels": [
+		{"name": "os", "value": "linux"},
+		{"name": "arch", "value": "amd64"}
+	]
+}`
+
+// fakeImageStore is an ImageStore keeping a single image in memory.
+type fakeImageStore struct {
+	key      string
+	manifest *schema.ImageManifest
+	aci      []byte
+}
+
+func newFakeImageStore(dir string, entries []*aci.ACIEntry) (*fakeImageStore, error) {
+	f, err := aci.NewACI(dir, testImageManifest, entries)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	contents, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	s := &fakeImageStore{
+		manifest: &schema.ImageManifest{},
+		aci:      contents,
+	}
+	if err := s.manifest.UnmarshalJSON([]byte(testImageManifest)); err != nil {
+		return nil, err
+	}
+	h := sha512.New()
+	h.Write(contents)
+	s.key = s.HashToKey(h)
+	return s, nil
+}
+
+func (s *fakeImageStore) check(key string) error {
+	if key != s.key {
+		return fmt.Errorf("no image with key %q", key)
+	}
+	return nil
+}
+
+func (s *fakeImageStore) GetImageManifest(key string) (*schema.ImageManifest, error) {
+	if err := s.check(key); err != nil {
+		return nil, err
+	}
+	return s.manifest, nil
+}
+
+func (s *fakeImageStore) GetImageManifestJSON(key string) ([]byte, error) {
+	if err := s.check(key); err != nil {
+		return nil, err
+	}
+	return []byte(testImageManifest), nil
+}
+
+func (s *fakeImageStore) ReadStream(key string) (io.ReadCloser, error) {
+	if err := s.check(key); err != nil {
+		return nil, err
+	}
+	return ioutil.NopCloser(bytes.NewReader(s.aci)), nil
+}
+
+func (s *fakeImageStore) ResolveKey(key string) (string, error) {
+	return key, s.check(key)
+}
+
+func (s *fakeImageStore) HashToKey(h hash.Hash) string {
+	return fmt.Sprintf("sha512-%x", h.Sum(nil))
+}
+
+func (s *fakeImageStore) GetACI(name types.ACIdentifier, labels types.Labels) (string, error) {
+	if name != s.manifest.Name {
+		return "", fmt.Errorf("no image named %q", name)
+	}
+	return s.key, nil
+}
+
+// fakeTreeRenderer is a TreeRenderer recording the images it renders.
+type fakeTreeRenderer struct {
+	rendered []string
+}
+
+func (r *fakeTreeRenderer) Render(key string, rebuild bool) (string, string, error) {
+	r.rendered = append(r.rendered, key)
+	return "deps-" + key, "", nil
+}
+
+func (r *fakeTreeRenderer) CheckOrRender(key string) (string, bool, error) {
+	r.rendered = append(r.rendered, key)
+	return "deps-" + key, true, nil
+}
+
+func (r *fakeTreeRenderer) GetRootFS(id string) string {
+	return filepath.Join("/nonexistent", id, "rootfs")
+}
+
+func TestPrepareAppImage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rkt-stage0-test")
+	if err != nil {
+		t.Fatalf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	entries := []*aci.ACIEntry{
+		{Header: &tar.Header{Name: "rootfs/etc", Typeflag: tar.TypeDir}},
+		{Header: &tar.Header{Name: "rootfs/etc/app.conf", Size: 5}, Contents: "hello"},
+	}
+	store, err := newFakeImageStore(dir, entries)
+	if err != nil {
+		t.Fatalf("failed to create the fake image store: %v", err)
+	}
+	img, err := types.NewHash(store.key)
+	if err != nil {
+		t.Fatalf("failed to parse the image key: %v", err)
+	}
+	appName := types.MustACName("app")
+
+	for _, useOverlay := range []bool{false, true} {
+		podDir := filepath.Join(dir, fmt.Sprintf("pod-overlay-%t", useOverlay))
+		trees := &fakeTreeRenderer{}
+		cfg := PrepareConfig{
+			CommonConfig: &CommonConfig{
+				Store:     store,
+				TreeStore: trees,
+			},
+			PrivateUsers: user.NewBlankUidRange(),
+		}
+
+		treeStoreID, err := prepareAppImage(cfg, *appName, *img, podDir, useOverlay)
+		if err != nil {
+			t.Fatalf("overlay %t: unexpected error preparing the app image: %v", useOverlay, err)
+		}
+
+		manifest, err := ioutil.ReadFile(filepath.Join(common.AppInfoPath(podDir, *appName), "manifest"))
+		if err != nil {
+			t.Errorf("overlay %t: failed to read the written image manifest: %v", useOverlay, err)
+		} else if string(manifest) != testImageManifest {
+			t.Errorf("overlay %t: expected the image manifest %q, got %q", useOverlay, testImageManifest, manifest)
+		}
+
+		renderedFile := filepath.Join(common.AppRootfsPath(podDir, *appName), "etc", "app.conf")
+		if useOverlay {
+			if len(trees.rendered) != 1 || trees.rendered[0] != store.key {
+				t.Errorf("expected the image to be rendered in the tree store, got %v", trees.rendered)
+			}
+			if treeStoreID != "deps-"+store.key {
+				t.Errorf("expected the tree store ID of the rendered image, got %q", treeStoreID)
+			}
+			if _, err := os.Stat(renderedFile); !os.IsNotExist(err) {
+				t.Errorf("expected the image not to be rendered in the pod with overlay")
+			}
+			continue
+		}
+
+		if len(trees.rendered) != 0 {
+			t.Errorf("expected the tree store not to be used without overlay, got %v", trees.rendered)
+		}
+		if treeStoreID != "" {
+			t.Errorf("expected no tree store ID without overlay, got %q", treeStoreID)
+		}
+		contents, err := ioutil.ReadFile(renderedFile)
+		if err != nil {
+			t.Errorf("failed to read the rendered file: %v", err)
+		} else if string(contents) != "hello" {
+			t.Errorf("expected the rendered file to contain %q, got %q", "hello", contents)
+		}
+	}
+}
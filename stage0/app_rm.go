@@ -24,6 +24,7 @@ import (
 	"path/filepath"
 	"syscall"
 
+	"github.com/appc/spec/schema"
 	"github.com/appc/spec/schema/types"
 	"github.com/hashicorp/errwrap"
 	"github.com/rkt/rkt/common"
@@ -56,6 +57,11 @@ func RmApp(cfg RmConfig) error {
 		return errwrap.Wrap(errors.New("cannot remove application, sandbox validation failed"), err)
 	}
 
+	return rmApp(cfg, pod, pm)
+}
+
+// rmApp removes the app from the locked pod and its manifest pm.
+func rmApp(cfg RmConfig, pod *pkgPod.Pod, pm *schema.PodManifest) error {
 	app := pm.Apps.Get(*cfg.AppName)
 	if app == nil {
 		return fmt.Errorf("error: nonexistent app %q", *cfg.AppName)
@@ -15,6 +15,7 @@
 package stage0
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -194,6 +195,34 @@ func generateRuntimeApp(appRunConfig *apps.App, am *schema.ImageManifest, podMou
 		ra.Annotations.Set(stage1types.AppStderrMode, appRunConfig.Stderr.String())
 	}
 
+	if appRunConfig.HealthCheck != nil {
+		if err := apps.ValidateHealthCheck(appRunConfig.HealthCheck); err != nil {
+			return ra, err
+		}
+		hc, err := json.Marshal(appRunConfig.HealthCheck)
+		if err != nil {
+			return ra, errwrap.Wrap(errors.New("error marshaling health check"), err)
+		}
+		ra.Annotations.Set(stage1types.AppHealthCheck, string(hc))
+	}
+
+	if appRunConfig.RestartPolicy != "" {
+		ra.Annotations.Set(stage1types.AppRestartPolicy, appRunConfig.RestartPolicy.String())
+	}
+
+	if appRunConfig.UnitOptions != nil {
+		for _, o := range appRunConfig.UnitOptions {
+			if _, _, _, err := apps.ParseUnitOption(o); err != nil {
+				return ra, err
+			}
+		}
+		uo, err := json.Marshal(appRunConfig.UnitOptions)
+		if err != nil {
+			return ra, errwrap.Wrap(errors.New("error marshaling unit options"), err)
+		}
+		ra.Annotations.Set(stage1types.AppUnitOptions, string(uo))
+	}
+
 	if appRunConfig.Environments != nil {
 		envs := make([]string, 0, len(appRunConfig.Environments))
 		for name, value := range appRunConfig.Environments {
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"syscall"
 
@@ -36,20 +37,58 @@ func Enter(cdir string, podPID int, appName types.ACName, stage1Path string, cmd
 		return errwrap.Wrap(errors.New("error changing to dir"), err)
 	}
 
+	argv, err := enterArgv(cdir, podPID, &appName, stage1Path, cmdline)
+	if err != nil {
+		return err
+	}
+	if err := syscall.Exec(argv[0], argv, os.Environ()); err != nil {
+		return errwrap.Wrap(errors.New("error execing enter"), err)
+	}
+
+	// never reached
+	return nil
+}
+
+// EnterCmd returns a command running cmdline in the pod/app through the
+// stage1's /enter, like Enter, but as a child of the calling process.
+func EnterCmd(cdir string, podPID int, appName types.ACName, stage1Path string, cmdline []string) (*exec.Cmd, error) {
+	return enterCmd(cdir, podPID, &appName, stage1Path, cmdline)
+}
+
+// EnterStage1Cmd returns a command running cmdline in the pod's stage1
+// through the stage1's /enter, as a child of the calling process.
+func EnterStage1Cmd(cdir string, podPID int, stage1Path string, cmdline []string) (*exec.Cmd, error) {
+	return enterCmd(cdir, podPID, nil, stage1Path, cmdline)
+}
+
+func enterCmd(cdir string, podPID int, appName *types.ACName, stage1Path string, cmdline []string) (*exec.Cmd, error) {
+	argv, err := enterArgv(cdir, podPID, appName, stage1Path, cmdline)
+	if err != nil {
+		return nil, err
+	}
+
+	return &exec.Cmd{
+		Path: argv[0],
+		Args: argv,
+		Dir:  cdir,
+	}, nil
+}
+
+// enterArgv returns the command line invoking the stage1's /enter for
+// cmdline. If appName is nil, cmdline is run in the stage1 instead of the app.
+func enterArgv(cdir string, podPID int, appName *types.ACName, stage1Path string, cmdline []string) ([]string, error) {
 	ep, err := getStage1Entrypoint(cdir, enterEntrypoint)
 	if err != nil {
-		return errwrap.Wrap(errors.New("error determining 'enter' entrypoint"), err)
+		return nil, errwrap.Wrap(errors.New("error determining 'enter' entrypoint"), err)
 	}
 
 	argv := []string{filepath.Join(stage1Path, ep)}
 	argv = append(argv, fmt.Sprintf("--pid=%d", podPID))
-	argv = append(argv, fmt.Sprintf("--appname=%s", appName.String()))
+	if appName != nil {
+		argv = append(argv, fmt.Sprintf("--appname=%s", appName.String()))
+	}
 	argv = append(argv, "--")
 	argv = append(argv, cmdline...)
-	if err := syscall.Exec(argv[0], argv, os.Environ()); err != nil {
-		return errwrap.Wrap(errors.New("error execing enter"), err)
-	}
 
-	// never reached
-	return nil
+	return argv, nil
 }
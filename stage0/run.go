@@ -46,13 +46,12 @@ import (
 	"github.com/rkt/rkt/common/apps"
 	commonnet "github.com/rkt/rkt/common/networking"
 	"github.com/rkt/rkt/common/overlay"
+	"github.com/rkt/rkt/networking"
 	"github.com/rkt/rkt/pkg/aci"
 	"github.com/rkt/rkt/pkg/fileutil"
 	"github.com/rkt/rkt/pkg/sys"
 	"github.com/rkt/rkt/pkg/tpm"
 	"github.com/rkt/rkt/pkg/user"
-	"github.com/rkt/rkt/store/imagestore"
-	"github.com/rkt/rkt/store/treestore"
 	"github.com/rkt/rkt/version"
 )
 
@@ -76,29 +75,32 @@ type PrepareConfig struct {
 // RunConfig defines the configuration parameters needed by Run
 type RunConfig struct {
 	*CommonConfig
-	Net                  common.NetList // pod should have its own network stack
-	LockFd               int            // lock file descriptor
-	Interactive          bool           // whether the pod is interactive or not
-	MDSRegister          bool           // whether to register with metadata service or not
-	Apps                 schema.AppList // applications (prepare gets them via Apps)
-	LocalConfig          string         // Path to local configuration
-	Hostname             string         // hostname of the pod
-	RktGid               int            // group id of the 'rkt' group, -1 ere's no rkt group.
-	DNSConfMode          DNSConfMode    // dns configuration file mode - for stAage1
-	DNSConfig            cnitypes.DNS   // the DNS configuration (nameservers, search, options)
-	InsecureCapabilities bool           // Do not restrict capabilities
-	InsecurePaths        bool           // Do not restrict access to files in sysfs or procfs
-	InsecureSeccomp      bool           // Do not add seccomp restrictions
-	UseOverlay           bool           // run pod with overlay fs
-	HostsEntries         HostsEntries   // The entries in /etc/hosts
-	IPCMode              string         // whether to stay in the host IPC namespace
+	Net                  common.NetList    // pod should have its own network stack
+	LockFd               int               // lock file descriptor
+	Interactive          bool              // whether the pod is interactive or not
+	MDSRegister          bool              // whether to register with metadata service or not
+	Apps                 schema.AppList    // applications (prepare gets them via Apps)
+	LocalConfig          string            // Path to local configuration
+	Hostname             string            // hostname of the pod
+	RktGid               int               // group id of the 'rkt' group, -1 ere's no rkt group.
+	DNSConfMode          DNSConfMode       // dns configuration file mode - for stAage1
+	DNSConfig            cnitypes.DNS      // the DNS configuration (nameservers, search, options)
+	InsecureCapabilities bool              // Do not restrict capabilities
+	InsecurePaths        bool              // Do not restrict access to files in sysfs or procfs
+	InsecureSeccomp      bool              // Do not add seccomp restrictions
+	UseOverlay           bool              // run pod with overlay fs
+	HostsEntries         HostsEntries      // The entries in /etc/hosts
+	IPCMode              string            // whether to stay in the host IPC namespace
+	CgroupRWControllers  []string          // cgroup controllers whose knobs are writable in the pod, all enabled ones if empty
+	EnvTmpfs             bool              // keep the apps' environment files on a tmpfs
+	NetConfOverrides     map[string][]byte // configurations overriding the ones of the named networks for this run
 }
 
 // CommonConfig defines the configuration shared by both Run and Prepare
 type CommonConfig struct {
 	DataDir      string                        // The path to the data directory, e.g. /var/lib/rkt/pods
-	Store        *imagestore.Store             // store containing all of the configured application images
-	TreeStore    *treestore.Store              // store containing all of the configured application images
+	Store        ImageStore                    // store containing all of the configured application images
+	TreeStore    TreeRenderer                  // store containing all of the configured application images
 	Stage1Image  types.Hash                    // stage1 image containing usable /init and /enter entrypoints
 	UUID         *types.UUID                   // UUID of the pod
 	RootHash     string                        // hash of the root filesystem
@@ -247,6 +249,9 @@ func generatePodManifest(cfg PrepareConfig, dir string) ([]byte, error) {
 	// TODO(jonboulle): check that app mountpoint expectations are
 	// satisfied here, rather than waiting for stage1
 	pm.Volumes = cfg.Apps.Volumes
+	if err := common.ValidateMounts(&pm); err != nil {
+		return nil, err
+	}
 
 	// Check to see if ports have any errors
 	pm.Ports = cfg.Ports
@@ -397,6 +402,10 @@ func validatePodManifest(cfg PrepareConfig, dir string) ([]byte, error) {
 		}
 	}
 
+	if err := common.ValidateMounts(&pm); err != nil {
+		return nil, err
+	}
+
 	// Validate forwarded ports
 	if _, err := commonnet.ForwardedPorts(&pm); err != nil {
 		return nil, err
@@ -550,6 +559,24 @@ func writeEtcHosts(cfg *RunConfig, rootfs string) {
 
 // Run mounts the right overlay filesystems and actually runs the prepared
 // pod by exec()ing the stage1 init inside the pod filesystem.
+// writeNetConfOverrides writes the network configuration overrides in
+// the pod directory, where the stage1 networking picks them up.
+func writeNetConfOverrides(cfg *RunConfig, dir string) error {
+	if len(cfg.NetConfOverrides) == 0 {
+		return nil
+	}
+	overrideDir := filepath.Join(dir, networking.NetConfOverrideDir)
+	if err := os.MkdirAll(overrideDir, 0755); err != nil {
+		return err
+	}
+	for name, conf := range cfg.NetConfOverrides {
+		if err := ioutil.WriteFile(filepath.Join(overrideDir, name+".conf"), conf, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func Run(cfg RunConfig, dir string, dataDir string) {
 	privateUsers, err := preparedWithPrivateUsers(dir)
 	if err != nil {
@@ -572,6 +599,16 @@ func Run(cfg RunConfig, dir string, dataDir string) {
 
 	writeDnsConfig(&cfg, destRootfs)
 
+	if err := writeNetConfOverrides(&cfg, dir); err != nil {
+		log.FatalE("error writing network configuration overrides", err)
+	}
+
+	if cfg.EnvTmpfs {
+		if err := mountEnvTmpfs(destRootfs); err != nil {
+			log.FatalE("error mounting tmpfs for environment files", err)
+		}
+	}
+
 	if err := os.Setenv(common.EnvLockFd, fmt.Sprintf("%v", cfg.LockFd)); err != nil {
 		log.FatalE("setting lock fd environment", err)
 	}
@@ -584,6 +621,10 @@ func Run(cfg RunConfig, dir string, dataDir string) {
 		log.FatalE("setting SELinux mount context environment", err)
 	}
 
+	if err := os.Setenv(common.EnvCgroupRWControllers, strings.Join(cfg.CgroupRWControllers, ",")); err != nil {
+		log.FatalE("setting cgroup read-write controllers environment", err)
+	}
+
 	debug("Pivoting to filesystem %s", dir)
 	if err := os.Chdir(dir); err != nil {
 		log.FatalE("failed changing to dir", err)
@@ -729,10 +770,14 @@ func prepareAppImage(cfg PrepareConfig, appName types.ACName, img types.Hash, cd
 			return "", fmt.Errorf("cannot use both overlay and user namespace: not implemented yet. (Try --no-overlay)")
 		}
 
-		treeStoreID, _, err = cfg.TreeStore.Render(img.String(), false)
+		var rendered bool
+		treeStoreID, rendered, err = cfg.TreeStore.CheckOrRender(img.String())
 		if err != nil {
 			return "", errwrap.Wrap(errors.New("error rendering tree image"), err)
 		}
+		if !rendered {
+			debug("Reusing the rendered tree image %s", treeStoreID)
+		}
 
 		if err := ioutil.WriteFile(common.AppTreeStoreIDPath(cdir, appName), []byte(treeStoreID), common.DefaultRegularFilePerm); err != nil {
 			return "", errwrap.Wrap(errors.New("error writing app treeStoreID"), err)
@@ -883,6 +928,24 @@ func setupStage1Image(cfg RunConfig, cdir string, useOverlay bool) error {
 	return nil
 }
 
+// envDirPath returns the directory of the apps' environment files in the
+// given stage1 rootfs.
+func envDirPath(stage1Rootfs string) string {
+	return filepath.Join(stage1Rootfs, "rkt", "env")
+}
+
+// mountEnvTmpfs mounts a tmpfs on the directory of the apps' environment
+// files, so they are kept in memory rather than in the pod directory. The
+// mount is cleaned up with the other pod mounts when the pod is garbage
+// collected.
+func mountEnvTmpfs(stage1Rootfs string) error {
+	dir := envDirPath(stage1Rootfs)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return errwrap.Wrap(fmt.Errorf("error creating %q", dir), err)
+	}
+	return syscall.Mount("tmpfs", dir, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV|syscall.MS_NOEXEC, "mode=0755")
+}
+
 // writeManifest takes an img ID and writes the corresponding manifest in dest
 func writeManifest(cfg CommonConfig, img types.Hash, dest string) error {
 	mb, err := cfg.Store.GetImageManifestJSON(img.String())
@@ -0,0 +1,52 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stage0
+
+import (
+	"github.com/appc/spec/pkg/acirenderer"
+	"github.com/appc/spec/schema"
+	"github.com/appc/spec/schema/types"
+	"github.com/rkt/rkt/store/imagestore"
+	"github.com/rkt/rkt/store/treestore"
+)
+
+// ManifestGetter gets the manifests of the images in a store.
+type ManifestGetter interface {
+	GetImageManifest(key string) (*schema.ImageManifest, error)
+	GetImageManifestJSON(key string) ([]byte, error)
+}
+
+// ImageStore is the store the images of a pod are taken from. Besides
+// the manifests, it provides the contents of the images, so they can be
+// rendered without overlay.
+type ImageStore interface {
+	ManifestGetter
+	acirenderer.ACIProvider
+	GetACI(name types.ACIdentifier, labels types.Labels) (string, error)
+}
+
+// TreeRenderer renders the images in a tree store, so they are used as
+// the lower directory of overlay mounts or copied to the pod.
+type TreeRenderer interface {
+	Render(key string, rebuild bool) (id string, hash string, err error)
+	CheckOrRender(key string) (id string, rendered bool, err error)
+	GetRootFS(id string) string
+}
+
+var (
+	_ ImageStore              = (*imagestore.Store)(nil)
+	_ acirenderer.ACIRegistry = ImageStore(nil)
+	_ TreeRenderer            = (*treestore.Store)(nil)
+)
@@ -108,6 +108,10 @@ func appAddStage0(appName *types.ACName, uuid *types.UUID) error {
 		return errwrap.Wrapf("failed to load pod", err)
 	}
 
+	if err := stage1init.CheckSystemctl(root); err != nil {
+		return err
+	}
+
 	ra := p.Manifest.Apps.Get(*appName)
 	if ra == nil {
 		return fmt.Errorf("failed to find app %q", *appName)
@@ -128,12 +132,17 @@ func appAddStage0(appName *types.ACName, uuid *types.UUID) error {
 		return errwrap.Wrapf("adding mounts failed", err)
 	}
 
+	unitOpts, err := stage1init.AppUnitOptions(ra)
+	if err != nil {
+		return errwrap.Wrapf("invalid unit options", err)
+	}
+
 	// write service files
 	w := stage1init.NewUnitWriter(p)
-	w.AppUnit(ra, binPath,
+	w.AppUnit(ra, binPath, append([]*unit.UnitOption{
 		unit.NewUnitOption("Unit", "Before", "halt.target"),
 		unit.NewUnitOption("Unit", "Conflicts", "halt.target"),
-	)
+	}, unitOpts...)...)
 	w.AppReaperUnit(ra.Name, binPath)
 	if err := w.Error(); err != nil {
 		return errwrap.Wrapf("error generating app units", err)
@@ -142,7 +151,7 @@ func appAddStage0(appName *types.ACName, uuid *types.UUID) error {
 	// stage2 environment is ready at this point, but systemd does not know
 	// about the new application yet
 	args := enterCmd
-	args = append(args, "/usr/bin/systemctl")
+	args = append(args, stage1init.SystemctlPath)
 	args = append(args, "daemon-reload")
 
 	cmd := exec.Cmd{
@@ -261,7 +270,7 @@ func addMountStage0(
 	src := m.Source(hostPodRoot)
 	warn := warner(diag.Printf)
 
-	pg, err := newPlayground(src, "", "rkt.propagate.stage1", mnt)
+	pg, err := newPlayground(src, "", stage1common.PropagationDirPrefix+"stage1", mnt)
 	if err != nil {
 		return errwrap.Wrapf("error creating stage1 playground", err)
 	}
@@ -280,7 +289,7 @@ func addMountStage0(
 	}
 
 	// move mount it to the propagation directory prepared by systemd-nspawn
-	propagate := filepath.Join("/run/systemd/nspawn/propagate/", "rkt-"+p.UUID.String(), "rkt.mount")
+	propagate := filepath.Join(stage1common.PodPropagateDir(&p.UUID), stage1common.PropagationMount)
 	if err := stage1init.EnsureTargetExists(src, propagate); err != nil {
 		return errwrap.Wrapf("error creating propagate mountpoint", err)
 	}
@@ -407,7 +416,7 @@ func newPlayground(src, dir, prefix string, mnt fs.MountUnmounter) (*playground,
 		return nil, errwrap.Wrapf("slave mount of "+prefix+" failed", err)
 	}
 
-	p.playground = filepath.Join(p.tmpDir, "playground")
+	p.playground = filepath.Join(p.tmpDir, stage1common.PropagationPlayground)
 	if err := stage1init.EnsureTargetExists(src, p.playground); err != nil {
 		return nil, errwrap.Wrapf("creating rkt.propagate.stage2/mount failed", err)
 	}
@@ -18,6 +18,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"os/exec"
@@ -56,10 +57,18 @@ func main() {
 		log.FatalE("invalid app name", err)
 	}
 
+	root, err := os.Getwd()
+	if err != nil {
+		log.FatalE("failed to determine current directory", err)
+	}
+	if err := stage1initcommon.CheckSystemctl(root); err != nil {
+		log.FatalE(fmt.Sprintf("cannot start %q", appName), err)
+	}
+
 	enterCmd := stage1common.PrepareEnterCmd(false)
 
 	args := enterCmd
-	args = append(args, "/usr/bin/systemctl")
+	args = append(args, stage1initcommon.SystemctlPath)
 	args = append(args, "start")
 	args = append(args, appName.String())
 
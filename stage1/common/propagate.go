@@ -0,0 +1,112 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/appc/spec/schema/types"
+	"github.com/hashicorp/errwrap"
+	"github.com/rkt/rkt/pkg/fs"
+)
+
+const (
+	// PropagationDirPrefix is the prefix of the temporary playground
+	// directories used to propagate mounts into a running pod.
+	PropagationDirPrefix = "rkt.propagate."
+	// PropagationPlayground is the name of the playground in such a
+	// directory.
+	PropagationPlayground = "playground"
+	// PropagationMount is the name of the mount moved to the propagation
+	// directory of a pod.
+	PropagationMount = "rkt.mount"
+
+	nspawnPropagateDir = "/run/systemd/nspawn/propagate"
+)
+
+// PodPropagateDir returns the directory in which systemd-nspawn propagates
+// mounts into the pod with the given UUID.
+func PodPropagateDir(uuid *types.UUID) string {
+	return filepath.Join(nspawnPropagateDir, "rkt-"+uuid.String())
+}
+
+// CleanupStalePropagationDirs removes the propagation playgrounds older than
+// olderThan from the temporary directory. They are normally removed right
+// after use, so old ones were left behind by a crashed app add.
+func CleanupStalePropagationDirs(olderThan time.Duration) error {
+	return cleanupStalePropagationDirs(os.TempDir(), time.Now().Add(-olderThan), fs.UnmounterFunc(syscall.Unmount))
+}
+
+// CleanupPodPropagateDir removes the propagation directory of a pod which is
+// not running anymore, if systemd-nspawn did not get to remove it.
+func CleanupPodPropagateDir(uuid *types.UUID) error {
+	return cleanupPodPropagateDir(PodPropagateDir(uuid), fs.UnmounterFunc(syscall.Unmount))
+}
+
+func cleanupStalePropagationDirs(tmpDir string, before time.Time, mnt fs.Unmounter) error {
+	entries, err := ioutil.ReadDir(tmpDir)
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+	for _, e := range entries {
+		if !e.IsDir() || !strings.HasPrefix(e.Name(), PropagationDirPrefix) || !e.ModTime().Before(before) {
+			continue
+		}
+		dir := filepath.Join(tmpDir, e.Name())
+		if err := removeMountpoint(filepath.Join(dir, PropagationPlayground), mnt); err != nil {
+			failed = append(failed, dir)
+			continue
+		}
+		if err := removeMountpoint(dir, mnt); err != nil {
+			failed = append(failed, dir)
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("could not remove stale propagation directories: %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+func cleanupPodPropagateDir(dir string, mnt fs.Unmounter) error {
+	if err := removeMountpoint(filepath.Join(dir, PropagationMount), mnt); err != nil {
+		return err
+	}
+	if err := os.Remove(dir); err != nil && !os.IsNotExist(err) {
+		return errwrap.Wrap(fmt.Errorf("could not remove %q", dir), err)
+	}
+	return nil
+}
+
+// removeMountpoint unmounts whatever is mounted on path and removes it. It
+// never removes recursively: the mount may be a host volume that would
+// otherwise be wiped if unmounting failed.
+func removeMountpoint(path string, mnt fs.Unmounter) error {
+	if err := mnt.Unmount(path, syscall.MNT_DETACH); err != nil && err != syscall.EINVAL && err != syscall.ENOENT {
+		return errwrap.Wrap(fmt.Errorf("could not unmount %q", path), err)
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return errwrap.Wrap(fmt.Errorf("could not remove %q", path), err)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+// Copyright 2017 The rkt Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/rkt/rkt/pkg/fs"
+)
+
+func TestCleanupStalePropagationDirs(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "rkt-propagate-test")
+	if err != nil {
+		t.Fatalf("error creating a temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	now := time.Now()
+	old := now.Add(-2 * time.Hour)
+
+	mkdir := func(name string, mtime time.Time, playground bool) {
+		dir := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("error creating %q: %v", dir, err)
+		}
+		if playground {
+			if err := os.Mkdir(filepath.Join(dir, PropagationPlayground), 0755); err != nil {
+				t.Fatalf("error creating the playground of %q: %v", dir, err)
+			}
+		}
+		if err := os.Chtimes(dir, mtime, mtime); err != nil {
+			t.Fatalf("error setting the times of %q: %v", dir, err)
+		}
+	}
+	mkdir("rkt.propagate.stage1.stale", old, true)
+	mkdir("rkt.propagate.stage1.stale-empty", old, false)
+	mkdir("rkt.propagate.stage1.fresh", now, true)
+	mkdir("unrelated", old, false)
+
+	// a stale playground still holding files, as if unmounting a host
+	// volume failed, must not be wiped
+	mkdir("rkt.propagate.stage1.busy", old, true)
+	busyFile := filepath.Join(tmpDir, "rkt.propagate.stage1.busy", PropagationPlayground, "data")
+	if err := ioutil.WriteFile(busyFile, []byte("data"), 0644); err != nil {
+		t.Fatalf("error writing %q: %v", busyFile, err)
+	}
+	if err := os.Chtimes(filepath.Join(tmpDir, "rkt.propagate.stage1.busy"), old, old); err != nil {
+		t.Fatalf("error setting times: %v", err)
+	}
+
+	var unmounted []string
+	mnt := fs.UnmounterFunc(func(target string, flags int) error {
+		unmounted = append(unmounted, filepath.Base(target))
+		return syscall.EINVAL
+	})
+
+	if err := cleanupStalePropagationDirs(tmpDir, now.Add(-time.Hour), mnt); err == nil {
+		t.Errorf("expected an error for the busy playground")
+	}
+
+	entries, err := ioutil.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("error reading %q: %v", tmpDir, err)
+	}
+	var left []string
+	for _, e := range entries {
+		left = append(left, e.Name())
+	}
+	sort.Strings(left)
+	expected := []string{"rkt.propagate.stage1.busy", "rkt.propagate.stage1.fresh", "unrelated"}
+	if len(left) != len(expected) {
+		t.Fatalf("expected %v to be left, got %v", expected, left)
+	}
+	for i := range expected {
+		if left[i] != expected[i] {
+			t.Errorf("expected %v to be left, got %v", expected, left)
+			break
+		}
+	}
+	if _, err := os.Stat(busyFile); err != nil {
+		t.Errorf("expected the busy playground content to be kept: %v", err)
+	}
+	if len(unmounted) == 0 {
+		t.Errorf("expected the stale playgrounds to be unmounted")
+	}
+}
+
+func TestCleanupPodPropagateDir(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "rkt-propagate-test")
+	if err != nil {
+		t.Fatalf("error creating a temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	dir := filepath.Join(tmpDir, "rkt-uuid")
+	if err := os.MkdirAll(filepath.Join(dir, PropagationMount), 0755); err != nil {
+		t.Fatalf("error creating %q: %v", dir, err)
+	}
+
+	mnt := fs.UnmounterFunc(func(string, int) error { return syscall.EINVAL })
+	if err := cleanupPodPropagateDir(dir, mnt); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("expected %q to be removed, got %v", dir, err)
+	}
+
+	// cleaning up again is fine
+	if err := cleanupPodPropagateDir(dir, mnt); err != nil {
+		t.Errorf("unexpected error cleaning up a missing directory: %v", err)
+	}
+}
@@ -37,6 +37,13 @@ const (
 	AppStdinMode  = "coreos.com/rkt/stage2/stdin"
 	AppStdoutMode = "coreos.com/rkt/stage2/stdout"
 	AppStderrMode = "coreos.com/rkt/stage2/stderr"
+
+	// App-level annotation: JSON-encoded health check command
+	AppHealthCheck = "coreos.com/rkt/stage2/health-check"
+	// App-level annotation: restart policy for an external supervisor
+	AppRestartPolicy = "coreos.com/rkt/stage2/restart-policy"
+	// App-level annotation: JSON-encoded extra systemd unit options
+	AppUnitOptions = "coreos.com/rkt/stage2/unit-options"
 )
 
 // Pod encapsulates a PodManifest and ImageManifests
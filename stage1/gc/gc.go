@@ -27,6 +27,7 @@ import (
 	"sort"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/appc/spec/schema/types"
 	"github.com/hashicorp/errwrap"
@@ -36,10 +37,16 @@ import (
 	"github.com/rkt/rkt/common/cgroup/v1"
 	"github.com/rkt/rkt/networking"
 	rktlog "github.com/rkt/rkt/pkg/log"
+	stage1common "github.com/rkt/rkt/stage1/common"
 )
 
 const (
 	cgroupFsPath = "/sys/fs/cgroup"
+
+	// stalePropagationAge is the age after which leftover propagation
+	// playgrounds are considered stale. app add removes its playground
+	// as soon as the mount is propagated.
+	stalePropagationAge = time.Hour
 )
 
 var (
@@ -67,9 +74,9 @@ func main() {
 		diag.SetOutput(ioutil.Discard)
 	}
 
-	podID, err := types.NewUUID(flag.Arg(0))
+	podID, err := common.ParsePodUUID(flag.Arg(0))
 	if err != nil {
-		log.Fatal("UUID is missing or malformed")
+		log.FatalE("UUID is missing or malformed", err)
 	}
 
 	diag.Printf("Removing journal link.")
@@ -82,6 +89,14 @@ func main() {
 		log.PrintE("error cleaning up cgroups", err)
 	}
 
+	diag.Printf("Cleaning up propagation directories.")
+	if err := stage1common.CleanupPodPropagateDir(podID); err != nil {
+		log.PrintE("error cleaning up the pod's propagation directory", err)
+	}
+	if err := stage1common.CleanupStalePropagationDirs(stalePropagationAge); err != nil {
+		log.PrintE("error cleaning up stale propagation directories", err)
+	}
+
 	diag.Printf("Tearing down networks.")
 	if err := gcNetworking(podID); err != nil {
 		log.FatalE("", err)
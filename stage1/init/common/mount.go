@@ -258,7 +258,7 @@ func BindMount(mnt fs.MountUnmounter, source, destination string, readOnly bool)
 		return errwrap.Wrap(fmt.Errorf("Could not bind mount %v to %v", absSource, destination), err)
 	}
 	if readOnly {
-		err := mnt.Mount(source, destination, "bind", syscall.MS_REMOUNT|syscall.MS_RDONLY|syscall.MS_BIND, "")
+		err := remountReadOnly(mnt, source, destination)
 
 		// If we failed to remount ro, unmount
 		if err != nil {
@@ -269,6 +269,39 @@ func BindMount(mnt fs.MountUnmounter, source, destination string, readOnly bool)
 	return nil
 }
 
+// stRdonly is ST_RDONLY, the statfs flag of read-only mounts.
+const stRdonly = 0x1
+
+// isReadOnlyMount reports whether the mount at path is read-only. It is a
+// variable so it can be replaced in tests.
+var isReadOnlyMount = func(path string) (bool, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return false, err
+	}
+	return uint64(st.Flags)&stRdonly != 0, nil
+}
+
+// remountReadOnly remounts the bind mount at destination read-only and
+// verifies that it took effect. A silently writable volume is worse than a
+// failed pod, so the remount is retried once before giving up.
+func remountReadOnly(mnt fs.Mounter, source, destination string) error {
+	flags := uintptr(syscall.MS_REMOUNT | syscall.MS_RDONLY | syscall.MS_BIND)
+	for i := 0; i < 2; i++ {
+		if err := mnt.Mount(source, destination, "bind", flags, ""); err != nil {
+			return err
+		}
+		ro, err := isReadOnlyMount(destination)
+		if err != nil {
+			return errwrap.Wrap(fmt.Errorf("could not check whether %v is read-only", destination), err)
+		}
+		if ro {
+			return nil
+		}
+	}
+	return fmt.Errorf("%v is still writable after remounting it read-only", destination)
+}
+
 // EnsureTargetExists will recursively create a given mountpoint. If directories
 // are created, their permissions are initialized to common.SharedVolumePerm
 func EnsureTargetExists(source, destination string) error {
@@ -15,10 +15,12 @@
 package common
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 
 	"github.com/kr/pretty"
+	"github.com/rkt/rkt/pkg/fs"
 
 	"github.com/appc/spec/schema"
 	"github.com/appc/spec/schema/types"
@@ -187,3 +189,42 @@ func TestGenerateMounts(t *testing.T) {
 		}
 	}
 }
+
+func TestRemountReadOnly(t *testing.T) {
+	tests := []struct {
+		mountErr error
+		roChecks []bool
+
+		expectedMounts int
+		expectedErr    bool
+	}{
+		{nil, []bool{true}, 1, false},
+		{nil, []bool{false, true}, 2, false},
+		{nil, []bool{false, false}, 2, true},
+		{errors.New("mount failed"), nil, 1, true},
+	}
+
+	defer func(f func(string) (bool, error)) { isReadOnlyMount = f }(isReadOnlyMount)
+
+	for i, tt := range tests {
+		mounts := 0
+		mnt := fs.MounterFunc(func(_, _, _ string, _ uintptr, _ string) error {
+			mounts++
+			return tt.mountErr
+		})
+		checks := tt.roChecks
+		isReadOnlyMount = func(string) (bool, error) {
+			ro := checks[0]
+			checks = checks[1:]
+			return ro, nil
+		}
+
+		err := remountReadOnly(mnt, "/source", "/destination")
+		if gotErr := err != nil; gotErr != tt.expectedErr {
+			t.Errorf("test %d: expected error %t, got %v", i, tt.expectedErr, err)
+		}
+		if mounts != tt.expectedMounts {
+			t.Errorf("test %d: expected %d mounts, got %d", i, tt.expectedMounts, mounts)
+		}
+	}
+}
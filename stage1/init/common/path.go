@@ -26,7 +26,9 @@ import (
 
 const (
 	// UnitsDir is the default path to systemd unit directory
-	UnitsDir        = "/usr/lib/systemd/system"
+	UnitsDir = "/usr/lib/systemd/system"
+	// SystemctlPath is the path to systemctl in the stage1 rootfs
+	SystemctlPath   = "/usr/bin/systemctl"
 	envDir          = "/rkt/env"
 	statusDir       = "/rkt/status"
 	ioMuxDir        = "/rkt/iottymux"
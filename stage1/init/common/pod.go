@@ -611,6 +611,28 @@ func GetFlavor(p *stage1commontypes.Pod) (flavor string, systemdVersion int, err
 	return flavor, systemdVersion, nil
 }
 
+// CheckSystemctl checks that systemctl is available to the stage1 of the pod
+// at root. It is needed to manage the units of apps added to a running pod,
+// which stage1 flavors without systemd, like fly, do not support.
+func CheckSystemctl(root string) error {
+	flavor, err := os.Readlink(filepath.Join(common.Stage1RootfsPath(root), "flavor"))
+	if err != nil {
+		return errwrap.Wrap(errors.New("unable to determine stage1 flavor"), err)
+	}
+
+	if flavor == "host" {
+		// The host's /usr is only bind mounted in the pod's mount
+		// namespace, look for systemctl on the host instead.
+		_, err = common.LookupPath("systemctl", os.Getenv("PATH"))
+	} else {
+		_, err = os.Lstat(filepath.Join(common.Stage1RootfsPath(root), SystemctlPath))
+	}
+	if err != nil {
+		return errwrap.Wrap(fmt.Errorf("stage1 flavor %q does not support app add/start: systemctl not found", flavor), err)
+	}
+	return nil
+}
+
 // GetAppHashes returns a list of hashes of the apps in this pod
 func GetAppHashes(p *stage1commontypes.Pod) []types.Hash {
 	var names []types.Hash
@@ -19,6 +19,7 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
 	stage1commontypes "github.com/rkt/rkt/stage1/common/types"
@@ -269,3 +270,38 @@ func hasRbindArg(output []string) bool {
 	}
 	return false
 }
+
+func TestCheckSystemctl(t *testing.T) {
+	root, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		t.Fatalf("error creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(root)
+
+	stage1Rootfs := filepath.Join(root, "stage1", "rootfs")
+	if err := os.MkdirAll(stage1Rootfs, 0755); err != nil {
+		t.Fatalf("error creating stage1 rootfs: %v", err)
+	}
+	if err := os.Symlink("fly", filepath.Join(stage1Rootfs, "flavor")); err != nil {
+		t.Fatalf("error creating flavor symlink: %v", err)
+	}
+
+	err = CheckSystemctl(root)
+	if err == nil {
+		t.Fatalf("expected an error for a stage1 without systemctl")
+	}
+	if !strings.Contains(err.Error(), `stage1 flavor "fly" does not support app add/start`) {
+		t.Errorf("expected a clear error for a stage1 without systemctl, got %q", err)
+	}
+
+	systemctl := filepath.Join(stage1Rootfs, SystemctlPath)
+	if err := os.MkdirAll(filepath.Dir(systemctl), 0755); err != nil {
+		t.Fatalf("error creating systemctl directory: %v", err)
+	}
+	if err := ioutil.WriteFile(systemctl, nil, 0755); err != nil {
+		t.Fatalf("error creating systemctl: %v", err)
+	}
+	if err := CheckSystemctl(root); err != nil {
+		t.Errorf("unexpected error for a stage1 with systemctl: %v", err)
+	}
+}
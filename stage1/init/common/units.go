@@ -17,6 +17,7 @@
 package common
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -29,6 +30,7 @@ import (
 	"github.com/appc/spec/schema"
 	"github.com/appc/spec/schema/types"
 	"github.com/rkt/rkt/common"
+	"github.com/rkt/rkt/common/apps"
 	"github.com/rkt/rkt/pkg/user"
 	stage1commontypes "github.com/rkt/rkt/stage1/common/types"
 
@@ -229,13 +231,15 @@ func (uw *UnitWriter) SetupAppIO(p *stage1commontypes.Pod, ra *schema.RuntimeApp
 	if ((flavor == "src" || flavor == "host") && systemdVersion < 232) ||
 		((flavor == "coreos" || flavor == "kvm") && systemdVersion < 231) {
 		// Explicit error if systemd is too old for attaching
-		if stdin != "" || stdout != "" || stderr != "" {
+		stdoutMode, stdoutOk := plainOutputMode(stdout)
+		stderrMode, stderrOk := plainOutputMode(stderr)
+		if stdin != "" || !stdoutOk || !stderrOk {
 			uw.err = fmt.Errorf("stage1 systemd %d does not support attachable I/O", systemdVersion)
 			return opts
 		}
 		opts = append(opts, unit.NewUnitOption("Service", "StandardInput", "null"))
-		opts = append(opts, unit.NewUnitOption("Service", "StandardOutput", "journal+console"))
-		opts = append(opts, unit.NewUnitOption("Service", "StandardError", "journal+console"))
+		opts = append(opts, unit.NewUnitOption("Service", "StandardOutput", stdoutMode))
+		opts = append(opts, unit.NewUnitOption("Service", "StandardError", stderrMode))
 		return opts
 	}
 
@@ -274,6 +278,8 @@ func (uw *UnitWriter) SetupAppIO(p *stage1commontypes.Pod, ra *schema.RuntimeApp
 		opts = append(opts, unit.NewUnitOption("Service", "StandardOutput", "tty"))
 	case "interactive":
 		opts = append(opts, unit.NewUnitOption("Service", "StandardOutput", "tty"))
+	case "journal":
+		opts = append(opts, unit.NewUnitOption("Service", "StandardOutput", "journal"))
 	case "null":
 		opts = append(opts, unit.NewUnitOption("Service", "StandardOutput", "null"))
 	default:
@@ -294,6 +300,8 @@ func (uw *UnitWriter) SetupAppIO(p *stage1commontypes.Pod, ra *schema.RuntimeApp
 		opts = append(opts, unit.NewUnitOption("Service", "StandardError", "tty"))
 	case "interactive":
 		opts = append(opts, unit.NewUnitOption("Service", "StandardError", "tty"))
+	case "journal":
+		opts = append(opts, unit.NewUnitOption("Service", "StandardError", "journal"))
 	case "null":
 		opts = append(opts, unit.NewUnitOption("Service", "StandardError", "null"))
 	default:
@@ -348,6 +356,46 @@ func (uw *UnitWriter) SetupAppIO(p *stage1commontypes.Pod, ra *schema.RuntimeApp
 	return opts
 }
 
+// AppUnitOptions returns the extra unit options requested for the app in its
+// annotations. The options are validated again, as the pod manifest could
+// have been modified after the app was added.
+func AppUnitOptions(ra *schema.RuntimeApp) ([]*unit.UnitOption, error) {
+	uo, ok := ra.Annotations.Get(stage1commontypes.AppUnitOptions)
+	if !ok {
+		return nil, nil
+	}
+
+	var options []string
+	if err := json.Unmarshal([]byte(uo), &options); err != nil {
+		return nil, errwrap.Wrap(errors.New("error unmarshaling unit options"), err)
+	}
+
+	var opts []*unit.UnitOption
+	for _, o := range options {
+		section, name, value, err := apps.ParseUnitOption(o)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, unit.NewUnitOption(section, name, value))
+	}
+	return opts, nil
+}
+
+// plainOutputMode returns the systemd StandardOutput/StandardError value for
+// an output stream mode which does not need iottymux, and whether the mode is
+// such a plain mode. An empty mode is the legacy log mode.
+func plainOutputMode(mode string) (string, bool) {
+	switch mode {
+	case "", "log":
+		return "journal+console", true
+	case "journal":
+		return "journal", true
+	case "null":
+		return "null", true
+	}
+	return "", false
+}
+
 // UnitWriter is the type that writes systemd units preserving the first previously occurred error.
 // Any method of this type can be invoked multiple times without error checking.
 // If a previous invocation generated an error, any invoked method will be skipped.
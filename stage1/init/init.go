@@ -33,7 +33,6 @@ import (
 	"syscall"
 
 	"github.com/appc/goaci/proj2aci"
-	"github.com/appc/spec/schema/types"
 	"github.com/coreos/go-systemd/util"
 	"github.com/coreos/pkg/dlopen"
 	"github.com/godbus/dbus"
@@ -531,7 +530,7 @@ func getArgsEnv(p *stage1commontypes.Pod, flavor string, canMachinedRegister boo
 }
 
 func stage1(rp *stage1commontypes.RuntimePod) int {
-	uuid, err := types.NewUUID(flag.Arg(0))
+	uuid, err := common.ParsePodUUID(flag.Arg(0))
 	if err != nil {
 		log.FatalE("UUID is missing or malformed", err)
 	}
@@ -847,7 +846,8 @@ func mountContainerV1Cgroups(m fs.Mounter, p *stage1commontypes.Pod, enabledCgro
 		return errwrap.Wrap(errors.New("error creating container cgroups"), err)
 	}
 
-	if err := v1.RemountCgroups(m, stage1Root, enabledCgroups, subcgroup, p.InsecureOptions.DisablePaths); err != nil {
+	rwControllers := strings.FieldsFunc(os.Getenv(common.EnvCgroupRWControllers), func(r rune) bool { return r == ',' })
+	if err := v1.RemountCgroups(m, stage1Root, enabledCgroups, subcgroup, rwControllers, p.InsecureOptions.DisablePaths); err != nil {
 		return errwrap.Wrap(errors.New("error restricting container cgroups"), err)
 	}
 
@@ -245,9 +245,9 @@ func evaluateMounts(rfs string, app string, p *stage1commontypes.Pod) ([]flyMoun
 }
 
 func stage1(rp *stage1commontypes.RuntimePod) int {
-	uuid, err := types.NewUUID(flag.Arg(0))
+	uuid, err := common.ParsePodUUID(flag.Arg(0))
 	if err != nil {
-		log.Print("UUID is missing or malformed\n")
+		log.PrintE("UUID is missing or malformed", err)
 		return 254
 	}
 
@@ -24,6 +24,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 	"syscall"
@@ -550,6 +551,92 @@ func (s *Store) RemoveACI(key string) error {
 	return nil
 }
 
+// ACIRemovalStatus describes the outcome of the removal of a single ACI.
+type ACIRemovalStatus string
+
+const (
+	// ACIRemoved means that the ACI was removed. Some stale non
+	// transactional data may have been left behind, in that case
+	// the Err field of the ACIRemovalResult is a StoreRemovalError.
+	ACIRemoved ACIRemovalStatus = "removed"
+	// ACIReferenced means that the ACI was skipped because it is
+	// referenced.
+	ACIReferenced ACIRemovalStatus = "referenced"
+	// ACIRemovalFailed means that the ACI could not be removed.
+	ACIRemovalFailed ACIRemovalStatus = "failed"
+)
+
+// ACIRemovalResult is the result of the removal of a single ACI.
+type ACIRemovalResult struct {
+	Key    string
+	Name   string
+	Status ACIRemovalStatus
+	Err    error
+}
+
+// RemoveACIsByPattern removes all the ACIs with a name matching the
+// given glob pattern (with the syntax of path.Match). ACIs whose keys
+// are in referenced are skipped. The returned slice contains a result
+// for every matching ACI, an error is returned only if the pattern is
+// malformed or the ACIs cannot be enumerated.
+func (s *Store) RemoveACIsByPattern(pattern string, referenced map[string]struct{}) ([]*ACIRemovalResult, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, errwrap.Wrap(fmt.Errorf("invalid image name pattern %q", pattern), err)
+	}
+	aciInfos, err := s.GetAllACIInfos(nil, false)
+	if err != nil {
+		return nil, errwrap.Wrap(errors.New("cannot get aci infos"), err)
+	}
+
+	var results []*ACIRemovalResult
+	for _, ai := range aciInfos {
+		// the pattern has already been validated
+		if matched, _ := path.Match(pattern, ai.Name); !matched {
+			continue
+		}
+		res := &ACIRemovalResult{
+			Key:  ai.BlobKey,
+			Name: ai.Name,
+		}
+		results = append(results, res)
+		if _, ok := referenced[ai.BlobKey]; ok {
+			res.Status = ACIReferenced
+			continue
+		}
+		res.Err = s.RemoveACI(ai.BlobKey)
+		switch res.Err.(type) {
+		case nil, *StoreRemovalError:
+			res.Status = ACIRemoved
+		default:
+			res.Status = ACIRemovalFailed
+		}
+	}
+	return results, nil
+}
+
+// GetUnreferencedACIInfos returns the ACIInfos of the ACIs last used
+// before lastUsedBefore whose keys are not in referenced, sorted by
+// last usage time. Nothing is removed, the returned ACIs are the
+// candidates for an image garbage collection.
+func (s *Store) GetUnreferencedACIInfos(referenced map[string]struct{}, lastUsedBefore time.Time) ([]*ACIInfo, error) {
+	aciInfos, err := s.GetAllACIInfos([]string{"lastused"}, true)
+	if err != nil {
+		return nil, errwrap.Wrap(errors.New("cannot get aci infos"), err)
+	}
+
+	var candidates []*ACIInfo
+	for _, ai := range aciInfos {
+		if !ai.LastUsed.Before(lastUsedBefore) {
+			break
+		}
+		if _, ok := referenced[ai.BlobKey]; ok {
+			continue
+		}
+		candidates = append(candidates, ai)
+	}
+	return candidates, nil
+}
+
 // GetRemote tries to retrieve a remote with the given ACIURL.
 // If remote doesn't exist, it returns ErrRemoteNotFound error.
 func (s *Store) GetRemote(aciURL string) (*Remote, error) {
@@ -427,3 +427,156 @@ func TestRemoveACI(t *testing.T) {
 		t.Fatalf("expected StoreRemovalError got: %v", err)
 	}
 }
+
+func TestRemoveACIsByPattern(t *testing.T) {
+	dir, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		t.Fatalf("error creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	keys := make(map[string]string)
+	for _, name := range []string{
+		"localhost/test-unreferenced",
+		"localhost/test-referenced",
+		"localhost/other",
+	} {
+		aciFile, err := aci.NewBasicACI(dir, name)
+		if err != nil {
+			t.Fatalf("error creating test tar: %v", err)
+		}
+		// Rewind the ACI
+		if _, err := aciFile.Seek(0, 0); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		key, err := s.WriteACI(aciFile, ACIFetchInfo{Latest: false})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		aciFile.Close()
+		keys[name] = key
+	}
+
+	if _, err := s.RemoveACIsByPattern("localhost/[", nil); err == nil {
+		t.Fatalf("expected an error for an invalid pattern")
+	}
+
+	referenced := map[string]struct{}{
+		keys["localhost/test-referenced"]: {},
+	}
+	results, err := s.RemoveACIsByPattern("localhost/test-*", referenced)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := map[string]ACIRemovalStatus{
+		"localhost/test-unreferenced": ACIRemoved,
+		"localhost/test-referenced":   ACIReferenced,
+	}
+	if len(results) != len(expected) {
+		t.Fatalf("expected %d results, got %d", len(expected), len(results))
+	}
+	for _, res := range results {
+		status, ok := expected[res.Name]
+		if !ok {
+			t.Errorf("unexpected result for image %q", res.Name)
+			continue
+		}
+		if res.Status != status {
+			t.Errorf("expected status %q for image %q, got %q", status, res.Name, res.Status)
+		}
+		if res.Key != keys[res.Name] {
+			t.Errorf("expected key %q for image %q, got %q", keys[res.Name], res.Name, res.Key)
+		}
+		if res.Err != nil {
+			t.Errorf("unexpected error for image %q: %v", res.Name, res.Err)
+		}
+	}
+
+	for name, key := range keys {
+		_, err := s.GetACIInfoWithBlobKey(key)
+		removed := err != nil
+		if wremoved := name == "localhost/test-unreferenced"; removed != wremoved {
+			t.Errorf("image %q: expected removed %t, got %t", name, wremoved, removed)
+		}
+	}
+}
+
+func TestGetUnreferencedACIInfos(t *testing.T) {
+	dir, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		t.Fatalf("error creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	s, err := NewStore(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer s.Close()
+
+	now := time.Now()
+	images := []struct {
+		name     string
+		lastUsed time.Time
+	}{
+		{"example.com/old-referenced", now.Add(-48 * time.Hour)},
+		{"example.com/old-unreferenced", now.Add(-72 * time.Hour)},
+		{"example.com/recent-unreferenced", now.Add(-time.Hour)},
+		{"example.com/recent-referenced", now.Add(-time.Minute)},
+	}
+	keys := make(map[string]string)
+	for _, img := range images {
+		aciFile, err := aci.NewBasicACI(dir, img.name)
+		if err != nil {
+			t.Fatalf("error creating test tar: %v", err)
+		}
+		// Rewind the ACI
+		if _, err := aciFile.Seek(0, 0); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		key, err := s.WriteACI(aciFile, ACIFetchInfo{Latest: false})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		aciFile.Close()
+		keys[img.name] = key
+
+		ai, err := s.GetACIInfoWithBlobKey(key)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ai.LastUsed = img.lastUsed
+		if err := s.db.Do(func(tx *sql.Tx) error {
+			return WriteACIInfo(tx, ai)
+		}); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	referenced := map[string]struct{}{
+		keys["example.com/old-referenced"]:    {},
+		keys["example.com/recent-referenced"]: {},
+	}
+	candidates, err := s.GetUnreferencedACIInfos(referenced, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("expected 1 candidate, got %d", len(candidates))
+	}
+	if candidates[0].BlobKey != keys["example.com/old-unreferenced"] {
+		t.Errorf("expected candidate %q, got %q (%q)", keys["example.com/old-unreferenced"], candidates[0].BlobKey, candidates[0].Name)
+	}
+
+	// nothing must have been removed
+	for name, key := range keys {
+		if _, err := s.GetACIInfoWithBlobKey(key); err != nil {
+			t.Errorf("image %q unexpectedly removed: %v", name, err)
+		}
+	}
+}
@@ -146,6 +146,34 @@ func (ts *Store) Render(key string, rebuild bool) (id string, hash string, err e
 	return id, hash, nil
 }
 
+// CheckOrRender returns the id of the treestore for the given image key,
+// reusing an already rendered treestore if it passes the consistency
+// check. The treestore is only rendered if it is missing, partially
+// rendered or inconsistent, in which case rendered is true.
+func (ts *Store) CheckOrRender(key string) (id string, rendered bool, err error) {
+	id, err = ts.GetID(key)
+	if err != nil {
+		return "", false, errwrap.Wrap(errors.New("cannot calculate treestore id"), err)
+	}
+
+	ok, err := ts.IsRendered(id)
+	if err != nil {
+		return "", false, errwrap.Wrap(errors.New("cannot determine if tree is already rendered"), err)
+	}
+	if ok {
+		if _, err := ts.Check(id); err == nil {
+			return id, false, nil
+		}
+	}
+
+	// rebuild to replace an inconsistent treestore, Render would
+	// reuse it as it is already marked as rendered
+	if id, _, err = ts.Render(key, ok); err != nil {
+		return "", false, err
+	}
+	return id, true, nil
+}
+
 // Check verifies the treestore consistency for the specified id.
 func (ts *Store) Check(id string) (string, error) {
 	treeStoreKeyLock, err := lock.SharedKeyLock(ts.lockDir, id)
@@ -132,6 +132,66 @@ func TestTreeStoreRender(t *testing.T) {
 	}
 }
 
+func TestTreeStoreCheckOrRender(t *testing.T) {
+	if !sys.HasChrootCapability() {
+		t.Skipf("chroot capability not available. Disabling test.")
+	}
+
+	dir, err := ioutil.TempDir("", tstprefix)
+	if err != nil {
+		t.Fatalf("error creating tempdir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	s, err := imagestore.NewStore(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ts, err := NewStore(dir, s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	key, err := testStoreWriteACI(dir, s)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	id, rendered, err := ts.CheckOrRender(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !rendered {
+		t.Errorf("expected the missing treestore to be rendered")
+	}
+
+	// The second time the image is used, the treestore is reused
+	id2, rendered, err := ts.CheckOrRender(key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rendered {
+		t.Errorf("expected the rendered treestore to be reused")
+	}
+	if id2 != id {
+		t.Errorf("expected treestore id %q, got %q", id, id2)
+	}
+
+	// An inconsistent treestore is rendered again
+	if err := ioutil.WriteFile(filepath.Join(ts.GetRootFS(id), "newfile"), []byte("newfile"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, rendered, err = ts.CheckOrRender(key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !rendered {
+		t.Errorf("expected the inconsistent treestore to be rendered again")
+	}
+	if _, err := ts.Check(id); err != nil {
+		t.Errorf("expected the treestore to be consistent after rendering it again: %v", err)
+	}
+}
+
 func TestTreeStoreRemove(t *testing.T) {
 	if !sys.HasChrootCapability() {
 		t.Skipf("chroot capability not available. Disabling test.")
@@ -26,10 +26,14 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"syscall"
 	"testing"
 	"time"
 
+	"github.com/appc/spec/schema/types"
 	"github.com/coreos/gexpect"
+	"github.com/rkt/rkt/common/apps"
+	rkt "github.com/rkt/rkt/lib"
 	"github.com/rkt/rkt/tests/testutils"
 )
 
@@ -555,6 +559,316 @@ func TestAppSandboxAppAnnotations(t *testing.T) {
 	})
 }
 
+// TestAppSandboxRestartPolicy adds an app with a restart policy to a sandbox
+// and reads it back from the pod manifest.
+func TestAppSandboxRestartPolicy(t *testing.T) {
+	testSandbox(t, func(ctx *testutils.RktRunCtx, child *gexpect.ExpectSubprocess, podUUID string) {
+		imageName := "coreos.com/rkt-inspect/hello"
+		appName := "restart-policy-app"
+
+		aciHello := patchTestACI("rkt-inspect-hello.aci", "--name="+imageName, "--exec=/inspect --print-msg=Hello")
+		defer os.Remove(aciHello)
+
+		combinedOutput(t, ctx.ExecCmd("fetch", "--insecure-options=image", aciHello))
+		combinedOutput(t, ctx.ExecCmd("app", "add", "--debug", podUUID, imageName, "--name="+appName, "--restart=OnFailure"))
+
+		podInfo := getPodInfo(t, ctx, podUUID)
+		policy, ok := podInfo.manifest.Apps[0].Annotations.Get("coreos.com/rkt/stage2/restart-policy")
+		if !ok || policy != "OnFailure" {
+			t.Errorf("expected the OnFailure restart policy annotation, got %q", policy)
+		}
+
+		libPolicy, err := rkt.AppRestartPolicy(podUUID, ctx.DataDir(), appName)
+		if err != nil {
+			t.Fatalf("error getting the restart policy: %v", err)
+		}
+		if libPolicy != apps.RestartPolicyOnFailure {
+			t.Errorf("expected the %q restart policy, got %q", apps.RestartPolicyOnFailure, libPolicy)
+		}
+	})
+}
+
+// TestAppSandboxAppLogs adds and starts an app printing a known line and
+// checks that the line appears in the app's log stream.
+func TestAppSandboxAppLogs(t *testing.T) {
+	if TestedFlavor.Kvm || TestedFlavor.Fly {
+		t.Skip("app logs are not supported in kvm and fly flavors yet")
+	}
+
+	testSandbox(t, func(ctx *testutils.RktRunCtx, child *gexpect.ExpectSubprocess, podUUID string) {
+		actionTimeout := 30 * time.Second
+		imageName := "coreos.com/rkt-inspect/hello"
+		appName := "logs-app"
+		msg := "HelloFromAppLogs"
+
+		aciHello := patchTestACI("rkt-inspect-hello.aci", "--name="+imageName, "--exec=/inspect --print-msg="+msg)
+		defer os.Remove(aciHello)
+
+		combinedOutput(t, ctx.ExecCmd("fetch", "--insecure-options=image", aciHello))
+		combinedOutput(t, ctx.ExecCmd("app", "add", "--debug", podUUID, imageName, "--name="+appName))
+		combinedOutput(t, ctx.ExecCmd("app", "start", "--debug", podUUID, "--app="+appName))
+
+		if err := expectTimeoutWithOutput(child, msg, actionTimeout); err != nil {
+			t.Fatalf("Expected %q but not found: %v", msg, err)
+		}
+
+		logs, err := rkt.AppLogs(podUUID, ctx.DataDir(), types.ACName(appName), false)
+		if err != nil {
+			t.Fatalf("error getting the app logs: %v", err)
+		}
+		defer logs.Close()
+
+		out, err := ioutil.ReadAll(logs)
+		if err != nil {
+			t.Fatalf("error reading the app logs: %v", err)
+		}
+		if !strings.Contains(string(out), msg) {
+			t.Errorf("expected the app logs to contain %q, got %q", msg, out)
+		}
+	})
+}
+
+// TestAppSandboxHealthCheck adds an app with a health check to a sandbox and
+// runs the health check, checking its exit code.
+func TestAppSandboxHealthCheck(t *testing.T) {
+	testSandbox(t, func(ctx *testutils.RktRunCtx, child *gexpect.ExpectSubprocess, podUUID string) {
+		imageName := "coreos.com/rkt-inspect/hello"
+		appName := "health-check-app"
+
+		aciHello := patchTestACI("rkt-inspect-hello.aci", "--name="+imageName, "--exec=/inspect --sleep=60")
+		defer os.Remove(aciHello)
+
+		combinedOutput(t, ctx.ExecCmd("fetch", "--insecure-options=image", aciHello))
+		combinedOutput(t, ctx.ExecCmd("app", "add", "--debug", podUUID, imageName, "--name="+appName, "--health-check=/inspect --exit-code=42"))
+		combinedOutput(t, ctx.ExecCmd("app", "start", "--debug", podUUID, "--app="+appName))
+
+		exitCode, err := rkt.RunHealthCheck(podUUID, ctx.DataDir(), appName)
+		if err != nil {
+			t.Fatalf("error running the health check: %v", err)
+		}
+		if exitCode != 42 {
+			t.Errorf("expected the health check to exit with 42, got %d", exitCode)
+		}
+
+		combinedOutput(t, ctx.ExecCmd("app", "rm", "--debug", podUUID, "--app="+appName))
+	})
+}
+
+// TestAppSandboxSeccomp adds an app with a seccomp override to a sandbox,
+// checks that the isolator is in the pod manifest and that it is enforced.
+func TestAppSandboxSeccomp(t *testing.T) {
+	testSandbox(t, func(ctx *testutils.RktRunCtx, child *gexpect.ExpectSubprocess, podUUID string) {
+		actionTimeout := 30 * time.Second
+		imageName := "coreos.com/rkt-inspect/stat"
+		appName := "seccomp-app"
+
+		aciStat := patchTestACI("rkt-inspect-stat.aci", "--name="+imageName, "--exec=/inspect --file-name=/ --stat-file")
+		defer os.Remove(aciStat)
+
+		combinedOutput(t, ctx.ExecCmd("fetch", "--insecure-options=image", aciStat))
+		combinedOutput(t, ctx.ExecCmd("app", "add", "--debug", podUUID, imageName, "--name="+appName, "--seccomp=mode=remove,errno=EXFULL,"+getStatCall()))
+
+		podInfo := getPodInfo(t, ctx, podUUID)
+		is := podInfo.manifest.Apps[0].App.Isolators.GetByName(types.LinuxSeccompRemoveSetName)
+		if is == nil {
+			t.Fatalf("expected a %s isolator in the pod manifest", types.LinuxSeccompRemoveSetName)
+		}
+		set, ok := is.Value().(types.LinuxSeccompSet)
+		if !ok || string(set.Errno()) != "EXFULL" || !reflect.DeepEqual(set.Set(), []types.LinuxSeccompEntry{types.LinuxSeccompEntry(getStatCall())}) {
+			t.Errorf("unexpected seccomp isolator %s", *is.ValueRaw)
+		}
+
+		combinedOutput(t, ctx.ExecCmd("app", "start", "--debug", podUUID, "--app="+appName))
+		if err := expectTimeoutWithOutput(child, "exchange full", actionTimeout); err != nil {
+			t.Fatalf("Expected the stat call to be denied: %v", err)
+		}
+	})
+}
+
+// TestAppSandboxShowUnit adds an app to a sandbox and checks that its
+// service unit is printed by `rkt app show-unit`.
+func TestAppSandboxShowUnit(t *testing.T) {
+	testSandbox(t, func(ctx *testutils.RktRunCtx, child *gexpect.ExpectSubprocess, podUUID string) {
+		imageName := "coreos.com/rkt-inspect/hello"
+		appName := "show-unit-app"
+
+		aciHello := patchTestACI("rkt-inspect-hello.aci", "--name="+imageName, "--exec=/inspect --print-msg=HelloFromShowUnit")
+		defer os.Remove(aciHello)
+
+		combinedOutput(t, ctx.ExecCmd("fetch", "--insecure-options=image", aciHello))
+		combinedOutput(t, ctx.ExecCmd("app", "add", "--debug", podUUID, imageName, "--name="+appName))
+
+		out := combinedOutput(t, ctx.ExecCmd("app", "show-unit", podUUID, "--app="+appName))
+		var execStart string
+		for _, line := range strings.Split(out, "\n") {
+			if strings.HasPrefix(line, "ExecStart=") {
+				execStart = line
+				break
+			}
+		}
+		if !strings.Contains(execStart, "/inspect") || !strings.Contains(execStart, "--print-msg=HelloFromShowUnit") {
+			t.Errorf("expected an ExecStart running the app, got unit:\n%s", out)
+		}
+
+		cmd := ctx.ExecCmd("app", "show-unit", podUUID, "--app=missing-app")
+		if out, err := cmd.CombinedOutput(); err == nil {
+			t.Errorf("expected an error showing the unit of a missing app, got:\n%s", out)
+		}
+	})
+}
+
+// TestAppSandboxOutputModes adds an app with custom stdout and stderr modes
+// to a sandbox and checks that its service unit reflects them.
+func TestAppSandboxOutputModes(t *testing.T) {
+	testSandbox(t, func(ctx *testutils.RktRunCtx, child *gexpect.ExpectSubprocess, podUUID string) {
+		imageName := "coreos.com/rkt-inspect/hello"
+		appName := "output-modes-app"
+
+		aciHello := patchTestACI("rkt-inspect-hello.aci", "--name="+imageName, "--exec=/inspect --print-msg=Hello")
+		defer os.Remove(aciHello)
+
+		combinedOutput(t, ctx.ExecCmd("fetch", "--insecure-options=image", aciHello))
+		combinedOutput(t, ctx.ExecCmd("app", "add", "--debug", podUUID, imageName, "--name="+appName, "--stdout=journal", "--stderr=null"))
+
+		out := combinedOutput(t, ctx.ExecCmd("app", "show-unit", podUUID, "--app="+appName))
+		lines := strings.Split(out, "\n")
+		for _, expected := range []string{"StandardOutput=journal", "StandardError=null"} {
+			found := false
+			for _, line := range lines {
+				if strings.TrimSpace(line) == expected {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("expected %q in the app unit, got unit:\n%s", expected, out)
+			}
+		}
+
+		cmd := ctx.ExecCmd("app", "add", "--debug", podUUID, imageName, "--name=invalid-output-app", "--stdout=file")
+		if out, err := cmd.CombinedOutput(); err == nil {
+			t.Errorf("expected an error adding an app with an invalid stdout mode, got:\n%s", out)
+		}
+	})
+}
+
+// TestAppSandboxUnitOptions adds an app with extra unit options to a sandbox
+// and checks that they reach its service unit, and that disallowed options
+// are rejected.
+func TestAppSandboxUnitOptions(t *testing.T) {
+	testSandbox(t, func(ctx *testutils.RktRunCtx, child *gexpect.ExpectSubprocess, podUUID string) {
+		imageName := "coreos.com/rkt-inspect/hello"
+		appName := "unit-options-app"
+
+		aciHello := patchTestACI("rkt-inspect-hello.aci", "--name="+imageName, "--exec=/inspect --print-msg=Hello")
+		defer os.Remove(aciHello)
+
+		combinedOutput(t, ctx.ExecCmd("fetch", "--insecure-options=image", aciHello))
+		combinedOutput(t, ctx.ExecCmd("app", "add", "--debug", podUUID, imageName, "--name="+appName,
+			"--unit-option=Service.LimitNOFILE=65536", "--unit-option=Service.KillSignal=SIGINT"))
+
+		out := combinedOutput(t, ctx.ExecCmd("app", "show-unit", podUUID, "--app="+appName))
+		lines := strings.Split(out, "\n")
+		for _, expected := range []string{"LimitNOFILE=65536", "KillSignal=SIGINT"} {
+			found := false
+			for _, line := range lines {
+				if strings.TrimSpace(line) == expected {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("expected %q in the app unit, got unit:\n%s", expected, out)
+			}
+		}
+
+		cmd := ctx.ExecCmd("app", "add", "--debug", podUUID, imageName, "--name=disallowed-unit-option-app", "--unit-option=Service.ExecStartPre=/bin/sh")
+		if out, err := cmd.CombinedOutput(); err == nil {
+			t.Errorf("expected an error adding an app with a disallowed unit option, got:\n%s", out)
+		}
+	})
+}
+
+// TestAppSandboxAppsFrom adds two apps to a sandbox from app specs read
+// from stdin, and checks that both are added with their flags, and that
+// nothing is added if one of the specs is invalid.
+func TestAppSandboxAppsFrom(t *testing.T) {
+	testSandbox(t, func(ctx *testutils.RktRunCtx, child *gexpect.ExpectSubprocess, podUUID string) {
+		imageName := "coreos.com/rkt-inspect/hello"
+
+		aciHello := patchTestACI("rkt-inspect-hello.aci", "--name="+imageName, "--exec=/inspect --print-msg=Hello")
+		defer os.Remove(aciHello)
+
+		combinedOutput(t, ctx.ExecCmd("fetch", "--insecure-options=image", aciHello))
+
+		specs := fmt.Sprintf(`[
+			{"image": %q, "flags": ["--name=from-stdin-1"]},
+			{"image": %q, "flags": ["--name=from-stdin-2", "--restart=Always"], "args": ["--print-msg=World"]}
+		]`, imageName, imageName)
+		cmd := ctx.ExecCmd("app", "add", "--debug", "--apps-from=-", podUUID)
+		cmd.Stdin = strings.NewReader(specs)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to add the apps from stdin: %v\n%s", err, out)
+		}
+
+		out := combinedOutput(t, ctx.ExecCmd("app", "list", "--no-legend", podUUID))
+		for _, appName := range []string{"from-stdin-1", "from-stdin-2"} {
+			if !strings.Contains(out, appName) {
+				t.Errorf("expected app %q to be added, got:\n%s", appName, out)
+			}
+		}
+
+		// the second spec is invalid, the first app is not added either
+		specs = fmt.Sprintf(`[
+			{"image": %q, "flags": ["--name=from-stdin-3"]},
+			{"image": %q, "flags": ["--name=from-stdin-1"]}
+		]`, imageName, imageName)
+		cmd = ctx.ExecCmd("app", "add", "--debug", "--apps-from=-", podUUID)
+		cmd.Stdin = strings.NewReader(specs)
+		if out, err := cmd.CombinedOutput(); err == nil {
+			t.Errorf("expected an error adding an app with a duplicate name, got:\n%s", out)
+		}
+
+		out = combinedOutput(t, ctx.ExecCmd("app", "list", "--no-legend", podUUID))
+		if strings.Contains(out, "from-stdin-3") {
+			t.Errorf("expected app %q to be rolled back, got:\n%s", "from-stdin-3", out)
+		}
+	})
+}
+
+// TestAppSandboxEnvTmpfs starts a sandbox with --env-tmpfs, adds an app and
+// checks that its environment file is written to a tmpfs.
+func TestAppSandboxEnvTmpfs(t *testing.T) {
+	testSandboxWithArgs(t, []string{"--env-tmpfs"}, func(ctx *testutils.RktRunCtx, child *gexpect.ExpectSubprocess, podUUID string) {
+		imageName := "coreos.com/rkt-inspect/hello"
+		appName := "env-tmpfs-app"
+
+		aciHello := patchTestACI("rkt-inspect-hello.aci", "--name="+imageName, "--exec=/inspect --print-msg=Hello")
+		defer os.Remove(aciHello)
+
+		combinedOutput(t, ctx.ExecCmd("fetch", "--insecure-options=image", aciHello))
+		combinedOutput(t, ctx.ExecCmd("app", "add", "--debug", podUUID, imageName, "--name="+appName, "--environment=SECRET=hunter2"))
+
+		envDir := filepath.Join(getPodDir(t, ctx, podUUID), "stage1", "rootfs", "rkt", "env")
+		var st syscall.Statfs_t
+		if err := syscall.Statfs(envDir, &st); err != nil {
+			t.Fatalf("cannot statfs %q: %v", envDir, err)
+		}
+		const tmpfsMagic = 0x01021994
+		if st.Type != tmpfsMagic {
+			t.Errorf("expected %q to be on a tmpfs, got filesystem type %#x", envDir, st.Type)
+		}
+
+		env, err := ioutil.ReadFile(filepath.Join(envDir, appName))
+		if err != nil {
+			t.Fatalf("cannot read the environment file: %v", err)
+		}
+		if !strings.Contains(string(env), "SECRET=hunter2") {
+			t.Errorf("expected the environment file to contain the app's environment, got %q", env)
+		}
+	})
+}
+
 func TestAppSandboxCRILogs(t *testing.T) {
 	if TestedFlavor.Kvm || TestedFlavor.Fly {
 		t.Skip("CRI logs are not supported in kvm and fly flavors yet")
@@ -19,7 +19,9 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"testing"
 
 	"github.com/rkt/rkt/tests/testutils"
@@ -57,10 +59,15 @@ type stage1 struct {
 	Location string `json:"location,omitempty"`
 }
 
+type images struct {
+	InsecureOptions []string `json:"insecureOptions,omitempty"`
+}
+
 type cfg struct {
 	*auth
 	*paths
 	*stage1
+	*images
 
 	RktVersion string `json:"rktVersion"`
 	RktKind    string `json:"rktKind"`
@@ -207,6 +214,38 @@ func TestConfig(t *testing.T) {
 			},
 		},
 
+		{ // default insecure options overridden in user dir
+			configFunc: func(ctx *testutils.RktRunCtx) {
+				writeConfig(t, imagesDir(ctx.SystemDir()), "images.json", mustMarshalJSON(
+					cfg{
+						RktVersion: "v1",
+						RktKind:    "images",
+						images: &images{
+							InsecureOptions: []string{"image"},
+						},
+					},
+				))
+				writeConfig(t, imagesDir(ctx.UserDir()), "images.json", mustMarshalJSON(
+					cfg{
+						RktVersion: "v1",
+						RktKind:    "images",
+						images: &images{
+							InsecureOptions: []string{"image", "http"},
+						},
+					},
+				))
+			},
+			expected: []cfg{
+				{
+					RktVersion: "v1",
+					RktKind:    "images",
+					images: &images{
+						InsecureOptions: []string{"image", "http"},
+					},
+				},
+			},
+		},
+
 		{ // one stage1 entry
 			configFunc: func(ctx *testutils.RktRunCtx) {
 				writeConfig(t, stage1Dir(ctx.LocalDir()), "stage1.json", mustMarshalJSON(
@@ -482,3 +521,34 @@ func mustMarshalJSON(data interface{}) string {
 
 	return string(buf)
 }
+
+func TestConfigSources(t *testing.T) {
+	ctx := testutils.NewRktRunCtx()
+	defer ctx.Cleanup()
+
+	localAuth := authDir(ctx.LocalDir())
+	writeConfig(t, localAuth, "basic.json", `{"rktKind": "auth", "rktVersion": "v1", "domains": ["coreos.com"], "type": "basic", "credentials": {"user": "user", "password": "userPassword"}}`)
+	writeConfig(t, localAuth, "README", "not a configuration file")
+	writeConfig(t, localAuth, "paths.json", `{"rktKind": "paths", "rktVersion": "v1", "data": "/var/lib/rkt"}`)
+	writeConfig(t, localAuth, "future.json", `{"rktKind": "auth", "rktVersion": "v2"}`)
+	writeConfig(t, filepath.Join(ctx.LocalDir(), "foo.d"), "foo.json", `{}`)
+
+	rktCmd := ctx.Cmd() + " config sources"
+	nobodyUid, _ := testutils.GetUnprivilegedUidGid()
+	out, status := runRkt(t, rktCmd, nobodyUid, 0)
+	if status != 0 {
+		t.Fatalf("expected exit status code 0, got %d, output:\n%s", status, out)
+	}
+
+	for _, expected := range []string{
+		fmt.Sprintf(`parsed\s+%s\s+auth v1`, filepath.Join(localAuth, "basic.json")),
+		fmt.Sprintf(`ignored\s+%s\s+no .json extension`, filepath.Join(localAuth, "README")),
+		fmt.Sprintf(`invalid\s+%s\s+.*expects to have configuration files of kinds`, filepath.Join(localAuth, "paths.json")),
+		fmt.Sprintf(`invalid\s+%s\s+.*version "v2"`, filepath.Join(localAuth, "future.json")),
+		fmt.Sprintf(`ignored\s+%s\s+not a configuration subdirectory`, filepath.Join(ctx.LocalDir(), "foo.d")),
+	} {
+		if !regexp.MustCompile(expected).MatchString(out) {
+			t.Errorf("expected the output to match %q, got:\n%s", expected, out)
+		}
+	}
+}
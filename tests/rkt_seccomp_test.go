@@ -22,7 +22,6 @@ import (
 	"strings"
 	"testing"
 
-	"github.com/rkt/rkt/common"
 	"github.com/rkt/rkt/tests/testutils"
 )
 
@@ -30,19 +29,6 @@ const (
 	baseApp = `--exec=/inspect -file-name / -stat-file`
 )
 
-// Returns the syscall used by syscall.Stat()
-func getStatCall() string {
-	m := map[string]string{
-		"default": "stat",
-		"aarch64": "newfstatat",
-	}
-
-	if v, ok := m[common.GetArch()]; ok {
-		return v
-	}
-	return m["default"]
-}
-
 var seccompTestCases = []struct {
 	name           string
 	aciBuildArgs   []string
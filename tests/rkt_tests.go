@@ -208,6 +208,19 @@ func spawnAndWaitOrFail(t *testing.T, cmd string, expectedStatus int) {
 	waitOrFail(t, child, expectedStatus)
 }
 
+// Returns the syscall used by syscall.Stat()
+func getStatCall() string {
+	m := map[string]string{
+		"default": "stat",
+		"aarch64": "newfstatat",
+	}
+
+	if v, ok := m[common.GetArch()]; ok {
+		return v
+	}
+	return m["default"]
+}
+
 func getEmptyImagePath() string {
 	return testutils.GetValueFromEnvOrPanic("RKT_EMPTY_IMAGE")
 }
@@ -914,6 +927,10 @@ func stage1Dir(confDir string) string {
 	return filepath.Join(confDir, "stage1.d")
 }
 
+func imagesDir(confDir string) string {
+	return filepath.Join(confDir, "images.d")
+}
+
 func writeConfig(t *testing.T, dir, filename, contents string) {
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		t.Fatalf("Failed to create config directory %q: %v", dir, err)
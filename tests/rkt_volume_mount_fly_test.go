@@ -28,5 +28,6 @@ func TestVolumeMount(t *testing.T) {
 		volumeMountTestCasesNonRecursivePodManifest,
 		volumeMountTestCasesNonRecursive,
 		volumeMountTestCasesDuplicateVolume,
+		volumeMountTestCasesInvalidMounts,
 	}).Execute(t)
 }
@@ -29,6 +29,7 @@ func TestVolumeMount(t *testing.T) {
 		volumeMountTestCasesNonRecursivePodManifest,
 		volumeMountTestCasesNonRecursive,
 		volumeMountTestCasesDuplicateVolume,
+		volumeMountTestCasesInvalidMounts,
 		{
 			{
 				"CLI: duplicate mount given",
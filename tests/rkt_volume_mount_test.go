@@ -315,6 +315,94 @@ var (
 			outerFileContent,
 			false,
 		},
+		{
+			"Write to a read-only volume must fail even if the mount point is writable",
+			[]imagePatch{
+				{"rkt-test-run-pod-manifest-read-only-volume-write.aci", []string{}},
+			},
+			"",
+			&schema.PodManifest{
+				Apps: []schema.RuntimeApp{
+					{
+						Name: baseAppName,
+						App: &types.App{
+							Exec:  []string{"/inspect", "--write-file"},
+							User:  "0",
+							Group: "0",
+							Environment: []types.EnvironmentVariable{
+								{"FILE", path.Join(mountDir, "ro-write")},
+								{"CONTENT", "should-not-be-written"},
+							},
+							MountPoints: []types.MountPoint{
+								{mountName, mountDir, false},
+							},
+						},
+					},
+				},
+				Volumes: []types.Volume{
+					{Name: mountName, Kind: "host", Source: volDir,
+						ReadOnly: &boolTrue, Recursive: &boolFalse,
+						Mode: nil, UID: nil, GID: nil},
+				},
+			},
+			"read-only file system",
+			false,
+		},
+	}
+
+	volumeMountTestCasesInvalidMounts = []volumeMountTestCase{
+		{
+			"PodManifest: duplicate mount target in an app",
+			[]imagePatch{
+				{"rkt-test-run-pod-manifest-duplicate-target.aci", []string{}},
+			},
+			"",
+			&schema.PodManifest{
+				Apps: []schema.RuntimeApp{
+					{
+						Name: baseAppName,
+						App: &types.App{
+							Exec:  []string{"/inspect", "--read-file"},
+							User:  "0",
+							Group: "0",
+						},
+						Mounts: []schema.Mount{
+							{Volume: mountName, Path: mountDir},
+							{Volume: mountName, Path: mountDir + "/"},
+						},
+					},
+				},
+				Volumes: []types.Volume{
+					{Name: mountName, Kind: "host", Source: volDir},
+				},
+			},
+			"multiple mounts on",
+			true,
+		},
+		{
+			"PodManifest: mount of an undeclared volume",
+			[]imagePatch{
+				{"rkt-test-run-pod-manifest-dangling-volume.aci", []string{}},
+			},
+			"",
+			&schema.PodManifest{
+				Apps: []schema.RuntimeApp{
+					{
+						Name: baseAppName,
+						App: &types.App{
+							Exec:  []string{"/inspect", "--read-file"},
+							User:  "0",
+							Group: "0",
+						},
+						Mounts: []schema.Mount{
+							{Volume: *types.MustACName("undeclared"), Path: mountDir},
+						},
+					},
+				},
+			},
+			"the pod has no such volume",
+			true,
+		},
 	}
 
 	volumeMountTestCasesDuplicateVolume = []volumeMountTestCase{